@@ -0,0 +1,118 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+var (
+	secretsMu sync.Mutex
+	secrets   = make(map[string]string)
+)
+
+// Passphrase returns the passphrase supplied by the user.  This is taken from
+// the first of the following that is present:
+//   - an interactive prompt, if --passphrase is "-"
+//   - the contents of the file given by --passphrase-file
+//   - the environment variable named by --passphrase-env
+//   - the value of --passphrase
+//
+// The passphrase is obtained once and cached, so the user is prompted at most once.
+func Passphrase() (string, error) {
+	return obtainSecret("passphrase", "Passphrase")
+}
+
+// PrivateKey returns the private key supplied by the user.  This is taken from
+// the first of the following that is present:
+//   - an interactive prompt, if --privatekey is "-"
+//   - the contents of the file given by --privatekey-file
+//   - the value of --privatekey
+//
+// The private key is obtained once and cached, so the user is prompted at most once.
+func PrivateKey() (string, error) {
+	return obtainSecret("privatekey", "Private key")
+}
+
+// ReadSecretFile reads a secret from a file, refusing to do so if the file
+// can be accessed by users other than its owner.
+func ReadSecretFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("permissions %#o for %s are too open; it should not be accessible by other users", info.Mode().Perm(), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func obtainSecret(name string, description string) (string, error) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	if secret, exists := secrets[name]; exists {
+		return secret, nil
+	}
+
+	var secret string
+	var err error
+	switch {
+	case viper.GetString(name) == "-":
+		secret, err = promptSecret(description)
+	case viper.GetString(fmt.Sprintf("%s-file", name)) != "":
+		secret, err = ReadSecretFile(viper.GetString(fmt.Sprintf("%s-file", name)))
+	case viper.GetString(fmt.Sprintf("%s-env", name)) != "":
+		env := viper.GetString(fmt.Sprintf("%s-env", name))
+		var exists bool
+		secret, exists = os.LookupEnv(env)
+		if !exists {
+			err = fmt.Errorf("environment variable %s is not set", env)
+		}
+	default:
+		secret = viper.GetString(name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	secrets[name] = secret
+	return secret, nil
+}
+
+// promptSecret prompts for a secret on the terminal without echoing the input.
+func promptSecret(description string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("cannot prompt for %s; input is not a terminal", strings.ToLower(description))
+	}
+	fmt.Fprintf(os.Stderr, "%s: ", description)
+	data, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
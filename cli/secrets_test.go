@@ -0,0 +1,121 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSecretFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on windows")
+	}
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		contents string
+		perm     os.FileMode
+		res      string
+		err      string
+	}{
+		{
+			name:     "Good",
+			contents: "secret\n",
+			perm:     0o600,
+			res:      "secret",
+		},
+		{
+			name:     "TooOpen",
+			contents: "secret\n",
+			perm:     0o644,
+			err:      "permissions 0644 for %s are too open; it should not be accessible by other users",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.name)
+			require.NoError(t, os.WriteFile(path, []byte(test.contents), test.perm))
+			require.NoError(t, os.Chmod(path, test.perm))
+			res, err := ReadSecretFile(path)
+			if test.err != "" {
+				require.EqualError(t, err, fmt.Sprintf(test.err, path))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.res, res)
+			}
+		})
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "passphrase")
+	require.NoError(t, os.WriteFile(path, []byte("from file\n"), 0o600))
+	t.Setenv("ETHEREAL_TEST_PASSPHRASE", "from env")
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		res    string
+		err    string
+	}{
+		{
+			name:   "Flag",
+			values: map[string]string{"passphrase": "from flag"},
+			res:    "from flag",
+		},
+		{
+			name:   "File",
+			values: map[string]string{"passphrase": "from flag", "passphrase-file": path},
+			res:    "from file",
+		},
+		{
+			name:   "Env",
+			values: map[string]string{"passphrase": "from flag", "passphrase-env": "ETHEREAL_TEST_PASSPHRASE"},
+			res:    "from env",
+		},
+		{
+			name:   "EnvMissing",
+			values: map[string]string{"passphrase-env": "ETHEREAL_TEST_MISSING"},
+			err:    "environment variable ETHEREAL_TEST_MISSING is not set",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			viper.Reset()
+			secrets = make(map[string]string)
+			for k, v := range test.values {
+				viper.Set(k, v)
+			}
+			res, err := Passphrase()
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.res, res)
+			}
+		})
+	}
+	viper.Reset()
+}
@@ -60,9 +60,13 @@ func ObtainWallets(chainID *big.Int, debug bool) ([]accounts.Wallet, error) {
 // ObtainWalletAndAccount obtains the wallet and account for an address.
 func ObtainWalletAndAccount(chainID *big.Int, address common.Address) (accounts.Wallet, *accounts.Account, error) {
 	var account *accounts.Account
+	passphrase, err := Passphrase()
+	if err != nil {
+		return nil, nil, err
+	}
 	wallet, err := ObtainWallet(chainID, address)
 	if err == nil {
-		account, err = ObtainAccount(&wallet, &address, viper.GetString("passphrase"))
+		account, err = ObtainAccount(&wallet, &address, passphrase)
 	}
 	return wallet, account, err
 }
@@ -82,7 +86,8 @@ func ObtainWallet(chainID *big.Int, address common.Address) (accounts.Wallet, er
 	return wallet, fmt.Errorf("failed to obtain wallet for %s", address.Hex())
 }
 
-func obtainGethWallet(chainID *big.Int, address common.Address) (accounts.Wallet, error) {
+// GethKeystoreDir returns the directory of the geth keystore for a given chain.
+func GethKeystoreDir(chainID *big.Int) string {
 	keydir := DefaultDataDir()
 	switch {
 	case chainID.Cmp(params.MainnetChainConfig.ChainID) == 0:
@@ -95,6 +100,12 @@ func obtainGethWallet(chainID *big.Int, address common.Address) (accounts.Wallet
 		keydir = filepath.Join(keydir, "holesky")
 	}
 	keydir = filepath.Join(keydir, "keystore")
+
+	return keydir
+}
+
+func obtainGethWallet(chainID *big.Int, address common.Address) (accounts.Wallet, error) {
+	keydir := GethKeystoreDir(chainID)
 	backends := []accounts.Backend{keystore.NewKeyStore(keydir, keystore.StandardScryptN, keystore.StandardScryptP)}
 	accountManager := accounts.NewManager(nil, backends...)
 	defer accountManager.Close()
@@ -104,18 +115,7 @@ func obtainGethWallet(chainID *big.Int, address common.Address) (accounts.Wallet
 }
 
 func obtainGethWallets(chainID *big.Int, debug bool) ([]accounts.Wallet, error) {
-	keydir := DefaultDataDir()
-	switch {
-	case chainID.Cmp(params.MainnetChainConfig.ChainID) == 0:
-		// Nothing to add for mainnet.
-	case chainID.Cmp(params.GoerliChainConfig.ChainID) == 0:
-		keydir = filepath.Join(keydir, "goerli")
-	case chainID.Cmp(params.SepoliaChainConfig.ChainID) == 0:
-		keydir = filepath.Join(keydir, "sepolia")
-	case chainID.Cmp(params.HoleskyChainConfig.ChainID) == 0:
-		keydir = filepath.Join(keydir, "holesky")
-	}
-	keydir = filepath.Join(keydir, "keystore")
+	keydir := GethKeystoreDir(chainID)
 	if debug {
 		fmt.Printf("Geth key directory is %s\n", keydir)
 	}
@@ -41,8 +41,10 @@ Note that this will only work for filesystem-based keystores.  Hardware wallets
 In quiet mode this will return 0 if the account was successfully decoded, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		address := viper.GetString("address")
-		passphrase := viper.GetString("passphrase")
-		privateKey := viper.GetString("privatekey")
+		passphrase, err := cli.Passphrase()
+		cli.ErrCheck(err, quiet, "Failed to obtain passphrase")
+		privateKey, err := cli.PrivateKey()
+		cli.ErrCheck(err, quiet, "Failed to obtain private key")
 		cli.Assert((address != "" && passphrase != "") || privateKey != "", quiet, "--privatekey or both of --address and --passphrase are required")
 
 		var key *ecdsa.PrivateKey
@@ -68,6 +70,5 @@ func init() {
 	offlineCmds["account:keys"] = true
 	accountCmd.AddCommand(accountKeysCmd)
 	accountKeysCmd.Flags().String("address", "", "address for account keys")
-	accountKeysCmd.Flags().String("passphrase", "", "passphrase for account keys")
-	accountKeysCmd.Flags().String("privatekey", "", "private key for account keys")
+	addSecretFlags(accountKeysCmd, "account keys")
 }
@@ -32,6 +32,8 @@ var accountNonceCmd = &cobra.Command{
 
     ethereal account nonce --address=0x5FfC014343cd971B7eb70732021E26C35B744cc4
 
+The nonce at an earlier point, excluding pending transactions, can be obtained with --block or --at-time.
+
 In quiet mode this will return 0 if the nonce can be obtained, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(accountNonceAddress != "", quiet, "--address is required")
@@ -41,8 +43,13 @@ In quiet mode this will return 0 if the nonce can be obtained, otherwise 1.`,
 		ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
 		defer cancel()
 
-		nonce, err := c.Client().PendingNonceAt(ctx, address)
-		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain nonce for %s", accountNonceAddress))
+		var nonce uint64
+		if stateBlock == "" && stateAtTime == "" {
+			nonce, err = c.Client().PendingNonceAt(ctx, address)
+		} else {
+			nonce, err = c.Client().NonceAt(ctx, address, stateBlockNumber(ctx))
+		}
+		stateErrCheck(err, fmt.Sprintf("Failed to obtain nonce for %s", accountNonceAddress))
 
 		if !quiet {
 			fmt.Println(nonce)
@@ -53,4 +60,5 @@ In quiet mode this will return 0 if the nonce can be obtained, otherwise 1.`,
 func init() {
 	accountCmd.AddCommand(accountNonceCmd)
 	accountNonceCmd.Flags().StringVar(&accountNonceAddress, "address", "", "Address of the account for which to obtain the nonce")
+	addStateFlags(accountNonceCmd)
 }
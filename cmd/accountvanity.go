@@ -0,0 +1,126 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+)
+
+var (
+	accountVanityPrefix        string
+	accountVanitySuffix        string
+	accountVanityCaseSensitive bool
+	accountVanityWorkers       int
+)
+
+// vanityCalibration is the time spent measuring the throughput of a vanity miner.
+const vanityCalibration = 2 * time.Second
+
+// accountVanityCmd represents the account vanity command.
+var accountVanityCmd = &cobra.Command{
+	Use:   "vanity",
+	Short: "Create an account with a vanity address",
+	Long: `Create an account whose address starts and/or ends with the given hex characters.  For example:
+
+    ethereal account vanity --prefix=0x1234 --passphrase=secret
+
+Keys are generated on all CPU cores until one matches, and the matching key is stored in the local keystore encrypted with the supplied passphrase.  The throughput and expected time to find a match are reported before mining in earnest; each additional character makes the search 16 times longer.
+
+If --case-sensitive is supplied then the letters must also match those of the checksummed address, which makes the search twice as long for each letter.
+
+In quiet mode this will return 0 if the account is created, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		pattern, err := util.NewVanityPattern(accountVanityPrefix, accountVanitySuffix, accountVanityCaseSensitive)
+		cli.ErrCheck(err, quiet, "Invalid pattern")
+		// Obtain the passphrase up front to avoid losing the key after mining.
+		passphrase, err := cli.Passphrase()
+		cli.ErrCheck(err, quiet, "Failed to obtain passphrase")
+		cli.Assert(passphrase != "", quiet, "--passphrase is required")
+
+		var key *ecdsa.PrivateKey
+		runVanityMiner(pattern, accountVanityWorkers, func(ctx context.Context, attempts *atomic.Uint64) error {
+			key, err = util.MineVanityKey(ctx, pattern, accountVanityWorkers, attempts)
+			return err
+		})
+
+		account, err := util.StoreKey(c.ChainID(), key, passphrase)
+		cli.ErrCheck(err, quiet, "Failed to store key")
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+		fmt.Printf("Address:\t%s\n", crypto.PubkeyToAddress(key.PublicKey).Hex())
+		fmt.Printf("Keystore:\t%s\n", account.URL.Path)
+	},
+}
+
+// runVanityMiner runs a vanity miner, briefly at first to measure and report
+// its throughput and then until it finds a match.
+func runVanityMiner(pattern *util.VanityPattern, workers int, mine func(ctx context.Context, attempts *atomic.Uint64) error) {
+	cli.Assert(workers > 0, quiet, "--workers must be at least 1")
+	var attempts atomic.Uint64
+	ctx, cancel := context.WithTimeout(context.Background(), vanityCalibration)
+	started := time.Now()
+	err := mine(ctx, &attempts)
+	cancel()
+	if err == nil {
+		// Found a match while calibrating.
+		return
+	}
+	cli.Assert(errors.Is(err, context.DeadlineExceeded), quiet, fmt.Sprintf("Failed to mine: %v", err))
+
+	rate := float64(attempts.Load()) / time.Since(started).Seconds()
+	if !quiet {
+		fmt.Printf("Workers:\t%d\n", workers)
+		fmt.Printf("Throughput:\t%.0f addresses/s\n", rate)
+		fmt.Printf("Expected time:\t%s\n", vanityDuration(pattern.Difficulty()/rate))
+	}
+
+	started = time.Now()
+	cli.ErrCheck(mine(context.Background(), &attempts), quiet, "Failed to mine")
+	outputIf(verbose, fmt.Sprintf("Found match after %d attempts in %v", attempts.Load(), time.Since(started).Round(time.Second)))
+}
+
+// vanityDuration formats an expected mining time in seconds.
+func vanityDuration(seconds float64) string {
+	year := 365 * 24 * time.Hour
+	if seconds > 100*year.Seconds() {
+		return fmt.Sprintf("%.3g years", seconds/year.Seconds())
+	}
+
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+func init() {
+	offlineCmds["account:vanity"] = true
+	accountCmd.AddCommand(accountVanityCmd)
+	accountVanityCmd.Flags().StringVar(&accountVanityPrefix, "prefix", "", "Hex characters with which the address must start")
+	accountVanityCmd.Flags().StringVar(&accountVanitySuffix, "suffix", "", "Hex characters with which the address must end")
+	accountVanityCmd.Flags().BoolVar(&accountVanityCaseSensitive, "case-sensitive", false, "Match the case of letters against the checksummed address")
+	accountVanityCmd.Flags().IntVar(&accountVanityWorkers, "workers", runtime.NumCPU(), "Number of CPU cores to use")
+	accountVanityCmd.Flags().String("passphrase", "", "passphrase with which to encrypt the new account; \"-\" to prompt")
+	accountVanityCmd.Flags().String("passphrase-file", "", "file containing the passphrase with which to encrypt the new account")
+	accountVanityCmd.Flags().String("passphrase-env", "", "name of the environment variable containing the passphrase with which to encrypt the new account")
+}
@@ -37,6 +37,8 @@ var (
 	contractFunction string
 	contractJSON     string
 	contractName     string
+	// contractFollowProxy is set if the ABI of the implementation behind a proxy should be used.
+	contractFollowProxy bool
 )
 
 // contractCmd represents the contract command.
@@ -54,8 +56,8 @@ func contractFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&contractStr, "contract", "", "address of the contract")
 	cmd.Flags().StringVar(&contractAbi, "abi", "", "ABI, or path to ABI, for the contract")
 	cmd.Flags().StringVar(&contractFunction, "function", "", "Signature of function")
-	cmd.Flags().StringVar(&contractJSON, "json", "", "JSON, or path to JSON, for the contract as output by solc --combined-json=bin,abi")
-	cmd.Flags().StringVar(&contractName, "name", "", "Name of the contract (required when using json)")
+	cmd.Flags().StringVar(&contractJSON, "json", "", "JSON, or path to JSON, for the contract as a Foundry or Hardhat artifact, or as output by solc --standard-json or --combined-json=bin,abi")
+	cmd.Flags().StringVar(&contractName, "name", "", "Name of the contract, optionally prefixed with its source file (required when using json)")
 }
 
 // parse contract given the information from various flags.
@@ -66,7 +68,7 @@ func parseContract(binStr string) *util.Contract {
 			// Attempt to obtain the contract name from the JSON file.
 			contractName = strings.Split(filepath.Base(contractJSON), ".")[0]
 		}
-		contract, err = util.ParseCombinedJSON(contractJSON, contractName)
+		contract, err = util.ParseContractJSON(contractJSON, contractName)
 		cli.ErrCheck(err, quiet, "Failed to parse JSON")
 	} else {
 		contract = &util.Contract{}
@@ -77,10 +79,8 @@ func parseContract(binStr string) *util.Contract {
 		}
 
 		// Add binary if present.
-		var bin []byte
-		bin, err = hex.DecodeString(strings.TrimPrefix(binStr, "0x"))
+		contract.Binary, contract.LinkReferences, err = util.DecodeBytecode(binStr)
 		cli.ErrCheck(err, quiet, "Failed to decode data")
-		contract = &util.Contract{Binary: bin}
 
 		// Add ABI if present either directly or via a function.
 		if contractAbi != "" {
@@ -96,11 +96,77 @@ func parseContract(binStr string) *util.Contract {
 	return contract
 }
 
+// parseContractAt parses a contract at the given address.  If no ABI is
+// supplied then it is obtained from the ABI store, using the ABI of the
+// implementation if the contract is a proxy without a stored ABI of its own.
+// If --follow-proxy is supplied then the ABI of the implementation is always
+// used, and must be present.
+func parseContractAt(address common.Address) *util.Contract {
+	if contractAbi != "" || contractJSON != "" || contractFunction != "" {
+		return parseContract("")
+	}
+	if offline {
+		cli.Assert(!contractFollowProxy, quiet, "--follow-proxy cannot be used offline")
+		return parseContract("")
+	}
+
+	storedAbi, implementation := storedContractABI(address, contractFollowProxy)
+	if storedAbi == nil {
+		cli.Assert(!contractFollowProxy, quiet, fmt.Sprintf("No ABI stored for %s", implementation.Hex()))
+		outputIf(verbose, fmt.Sprintf("No ABI stored for %s", address.Hex()))
+		return parseContract("")
+	}
+
+	return &util.Contract{Abi: *storedAbi}
+}
+
+// storedContractABI returns the stored ABI for a contract, or for the
+// implementation behind it if it is a proxy, along with the address whose ABI
+// was used.  If implementationOnly is set then the ABI of the proxy itself is
+// not considered, and failure to inspect the proxy is an error.
+func storedContractABI(address common.Address, implementationOnly bool) (*abi.ABI, common.Address) {
+	store := contractABIStore()
+	if !implementationOnly {
+		storedAbi, err := store.ABI(c.ChainID(), address)
+		cli.ErrCheck(err, quiet, "Failed to obtain ABI from the ABI store")
+		if storedAbi != nil {
+			return storedAbi, address
+		}
+	}
+
+	ctx, cancel := localContext()
+	defer cancel()
+	chain, err := util.ProxyChain(ctx, c.Client(), address, nil)
+	if implementationOnly {
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to inspect proxy %s", address.Hex()))
+	} else if err != nil || len(chain) == 0 {
+		return nil, address
+	}
+	implementation := address
+	for _, proxy := range chain {
+		outputIf(verbose, fmt.Sprintf("%s is an %s proxy for %s", proxy.Address.Hex(), proxy.Type, proxy.Implementation.Hex()))
+		implementation = proxy.Implementation
+	}
+
+	storedAbi, err := store.ABI(c.ChainID(), implementation)
+	cli.ErrCheck(err, quiet, "Failed to obtain ABI from the ABI store")
+
+	return storedAbi, implementation
+}
+
+// contractABIStore returns the local ABI store.
+func contractABIStore() *util.ABIStore {
+	dir, err := util.DefaultABIStoreDir()
+	cli.ErrCheck(err, quiet, "Failed to locate the ABI store")
+
+	return util.NewABIStore(dir)
+}
+
 func contractParseAbi(input string) (abi.ABI, error) {
 	var reader io.Reader
 	var err error
 
-	if strings.HasPrefix(contractAbi, "[") {
+	if strings.HasPrefix(input, "[") {
 		// ABI is direct.
 		reader = strings.NewReader(input)
 	} else {
@@ -175,6 +241,14 @@ func contractParseFunction(input string) (*abi.ABI, error) {
 }
 
 func contractValueToString(argType abi.Type, val interface{}) (string, error) {
+	return abiValueToString(argType, val, func(addr common.Address) string {
+		return ens.Format(c.Client(), addr)
+	})
+}
+
+// abiValueToString turns a value in to a string, formatting addresses with
+// the supplied function.
+func abiValueToString(argType abi.Type, val interface{}, formatAddress func(common.Address) string) (string, error) {
 	switch argType.T {
 	case abi.IntTy:
 		return fmt.Sprintf("%v", val), nil
@@ -191,7 +265,7 @@ func contractValueToString(argType abi.Type, val interface{}) (string, error) {
 		res := make([]string, 0)
 		arrayVal := reflect.ValueOf(val)
 		for i := 0; i < arrayVal.Len(); i++ {
-			elemRes, err := contractValueToString(*argType.Elem, arrayVal.Index(i).Interface())
+			elemRes, err := abiValueToString(*argType.Elem, arrayVal.Index(i).Interface(), formatAddress)
 			if err != nil {
 				return "", err
 			}
@@ -202,7 +276,7 @@ func contractValueToString(argType abi.Type, val interface{}) (string, error) {
 		res := make([]string, 0)
 		arrayVal := reflect.ValueOf(val)
 		for i := 0; i < arrayVal.Len(); i++ {
-			elemRes, err := contractValueToString(*argType.Elem, arrayVal.Index(i).Interface())
+			elemRes, err := abiValueToString(*argType.Elem, arrayVal.Index(i).Interface(), formatAddress)
 			if err != nil {
 				return "", err
 			}
@@ -210,8 +284,7 @@ func contractValueToString(argType abi.Type, val interface{}) (string, error) {
 		}
 		return "[" + strings.Join(res, ",") + "]", nil
 	case abi.AddressTy:
-		addr := val.(common.Address)
-		return ens.Format(c.Client(), addr), nil
+		return formatAddress(val.(common.Address)), nil
 	case abi.FixedBytesTy:
 		arrayVal := reflect.ValueOf(val)
 		castVal := make([]byte, arrayVal.Len())
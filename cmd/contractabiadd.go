@@ -0,0 +1,66 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+)
+
+// contractAbiAddCmd represents the contract abi add command.
+var contractAbiAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Store the ABI of a contract",
+	Long: `Store the ABI of a contract, replacing any ABI already stored for it.  For example:
+
+   ethereal contract abi add --contract=0xd26114cd6EE289AccF82350c8d8487fedB8A0C07 --abi=./erc20.abi
+
+The ABI can be supplied directly or as a path with --abi, or from a contract artifact with --json.
+
+In quiet mode this will return 0 if the ABI is stored, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(!offline, quiet, "Cannot obtain chain ID to store ABI offline")
+		cli.Assert(contractStr != "", quiet, "--contract is required")
+		contractAddress, err := c.Resolve(contractStr)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", contractStr))
+		cli.Assert(contractFunction == "", quiet, "--function cannot be stored; supply --abi or --json")
+
+		var data []byte
+		switch {
+		case contractJSON != "":
+			data = parseContract("").AbiJSON
+		case strings.HasPrefix(contractAbi, "["):
+			data = []byte(contractAbi)
+		case contractAbi != "":
+			data, err = os.ReadFile(contractAbi)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to read ABI %s", contractAbi))
+		default:
+			cli.Err(quiet, "--abi or --json is required")
+		}
+
+		err = contractABIStore().Put(c.ChainID(), contractAddress, data)
+		cli.ErrCheck(err, quiet, "Failed to store ABI")
+		outputIf(verbose, fmt.Sprintf("Stored ABI for %s on chain %s", contractAddress.Hex(), c.ChainID()))
+		os.Exit(exitSuccess)
+	},
+}
+
+func init() {
+	contractAbiCmd.AddCommand(contractAbiAddCmd)
+	contractFlags(contractAbiAddCmd)
+}
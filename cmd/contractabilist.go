@@ -0,0 +1,66 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+)
+
+// contractAbiListCmd represents the contract abi list command.
+var contractAbiListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List contracts with stored ABIs",
+	Long: `List the contracts on the current chain with stored ABIs.  For example:
+
+   ethereal contract abi list
+
+With --verbose the number of functions and events in each ABI is also shown.
+
+In quiet mode this will return 0 if any ABIs are stored, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(!offline, quiet, "Cannot obtain chain ID to list ABIs offline")
+
+		store := contractABIStore()
+		addresses, err := store.List(c.ChainID())
+		cli.ErrCheck(err, quiet, "Failed to list ABIs")
+
+		if quiet {
+			if len(addresses) > 0 {
+				os.Exit(exitSuccess)
+			}
+			os.Exit(exitFailure)
+		}
+
+		for _, address := range addresses {
+			if !verbose {
+				fmt.Println(address.Hex())
+				continue
+			}
+			contractAbi, err := store.ABI(c.ChainID(), address)
+			if err != nil {
+				fmt.Printf("%s: %v\n", address.Hex(), err)
+				continue
+			}
+			fmt.Printf("%s: %d functions, %d events\n", address.Hex(), len(contractAbi.Methods), len(contractAbi.Events))
+		}
+	},
+}
+
+func init() {
+	contractAbiCmd.AddCommand(contractAbiListCmd)
+}
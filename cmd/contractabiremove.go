@@ -0,0 +1,49 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+)
+
+// contractAbiRemoveCmd represents the contract abi remove command.
+var contractAbiRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove the stored ABI of a contract",
+	Long: `Remove the stored ABI of a contract.  For example:
+
+   ethereal contract abi remove --contract=0xd26114cd6EE289AccF82350c8d8487fedB8A0C07
+
+In quiet mode this will return 0 if the ABI is removed, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(!offline, quiet, "Cannot obtain chain ID to remove ABI offline")
+		cli.Assert(contractStr != "", quiet, "--contract is required")
+		contractAddress, err := c.Resolve(contractStr)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", contractStr))
+
+		err = contractABIStore().Remove(c.ChainID(), contractAddress)
+		cli.ErrCheck(err, quiet, "Failed to remove ABI")
+		outputIf(verbose, fmt.Sprintf("Removed ABI for %s on chain %s", contractAddress.Hex(), c.ChainID()))
+		os.Exit(exitSuccess)
+	},
+}
+
+func init() {
+	contractAbiCmd.AddCommand(contractAbiRemoveCmd)
+	contractAbiRemoveCmd.Flags().StringVar(&contractStr, "contract", "", "address of the contract")
+}
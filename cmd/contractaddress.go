@@ -0,0 +1,142 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+)
+
+var (
+	contractAddressDeployer     string
+	contractAddressNonce        string
+	contractAddressSalt         string
+	contractAddressFactory      string
+	contractAddressData         string
+	contractAddressInitCodeHash string
+	contractAddressConstructor  string
+	contractAddressLinks        []string
+)
+
+// contractAddressCmd represents the contract address command.
+var contractAddressCmd = &cobra.Command{
+	Use:   "address",
+	Short: "Predict the address of a contract",
+	Long: `Predict the address of a contract.  For a contract deployed with CREATE the address depends on the deployer and its nonce, for example:
+
+    ethereal contract address --deployer=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --nonce=5
+
+If --nonce is not supplied the next nonce of the deployer is used.
+
+For a contract deployed with CREATE2 the address depends on the factory, the salt and the hash of the contract's init code, for example:
+
+    ethereal contract address --salt=0x01 --json=MyContract.json --constructor='constructor(1,2,3)'
+
+The init code is supplied in the same way as for 'contract deploy', or its hash can be supplied directly with --init-code-hash.  The default factory is the deterministic deployment proxy used by 'contract deploy --create2'.
+
+In quiet mode this will return 0 if the address is predicted, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var address common.Address
+		if contractAddressSalt == "" {
+			address = contractAddressCreate()
+		} else {
+			address = contractAddressCreate2()
+		}
+
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+		fmt.Println(address.Hex())
+	},
+}
+
+// contractAddressCreate predicts the address of a contract deployed with CREATE.
+func contractAddressCreate() common.Address {
+	cli.Assert(contractAddressDeployer != "", quiet, "--deployer is required")
+	deployer, err := resolveName(contractAddressDeployer)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve deployer address %s", contractAddressDeployer))
+
+	var nonce uint64
+	if contractAddressNonce == "" {
+		cli.Assert(!viper.GetBool("offline"), quiet, "--nonce is required if offline")
+		ctx, cancel := localContext()
+		defer cancel()
+		connection, err := onlineConnection(ctx)
+		cli.ErrCheck(err, quiet, "Failed to connect to Ethereum node")
+		nonce, err = connection.Client().PendingNonceAt(ctx, deployer)
+		cli.ErrCheck(err, quiet, "Failed to obtain nonce of deployer")
+		outputIf(verbose, fmt.Sprintf("Next nonce is %d", nonce))
+	} else {
+		nonce, err = strconv.ParseUint(contractAddressNonce, 10, 64)
+		cli.ErrCheck(err, quiet, "Invalid nonce")
+	}
+
+	return crypto.CreateAddress(deployer, nonce)
+}
+
+// contractAddressCreate2 predicts the address of a contract deployed with CREATE2.
+func contractAddressCreate2() common.Address {
+	cli.Assert(contractAddressDeployer == "" && contractAddressNonce == "", quiet, "--deployer and --nonce cannot be used with --salt")
+	salt, err := util.ParseSalt(contractAddressSalt)
+	cli.ErrCheck(err, quiet, "Invalid salt")
+	factory := create2Factory(contractAddressFactory)
+
+	initCodeHash := contractInitCodeHash(contractAddressInitCodeHash, contractAddressData, contractAddressConstructor, contractAddressLinks)
+	outputIf(verbose, fmt.Sprintf("Init code hash is %s", initCodeHash.Hex()))
+
+	return crypto.CreateAddress2(factory, salt, initCodeHash.Bytes())
+}
+
+// contractInitCodeHash obtains the hash of a contract's init code, either
+// directly or from the contract and its constructor.
+func contractInitCodeHash(initCodeHashStr string, data string, constructor string, links []string) common.Hash {
+	if initCodeHashStr != "" {
+		initCodeHash := common.FromHex(initCodeHashStr)
+		cli.Assert(len(initCodeHash) == common.HashLength && strings.HasPrefix(initCodeHashStr, "0x"), quiet, "Invalid init code hash")
+		return common.BytesToHash(initCodeHash)
+	}
+
+	cli.Assert(data != "" || contractJSON != "", quiet, "one of --data, --json or --init-code-hash is required")
+	contract := parseContract(data)
+	cli.Assert(len(contract.Binary) > 0, quiet, "failed to obtain contract binary data")
+	cli.ErrCheck(contract.Link(parseContractLinks(links)), quiet, "Failed to link libraries")
+	unlinked := contract.UnlinkedLibraries()
+	cli.Assert(len(unlinked) == 0, quiet, fmt.Sprintf("Unlinked libraries %s; use --link to supply their addresses", strings.Join(unlinked, ", ")))
+	appendConstructorArgs(contract, constructor)
+
+	return crypto.Keccak256Hash(contract.Binary)
+}
+
+func init() {
+	offlineCmds["contract:address"] = true
+	contractCmd.AddCommand(contractAddressCmd)
+	contractFlags(contractAddressCmd)
+	contractAddressCmd.Flags().StringVar(&contractAddressDeployer, "deployer", "", "Address of the deployer, for CREATE")
+	contractAddressCmd.Flags().StringVar(&contractAddressNonce, "nonce", "", "Nonce of the deployer, for CREATE (defaults to the next nonce)")
+	contractAddressCmd.Flags().StringVar(&contractAddressSalt, "salt", "", "Salt (as a hex string), for CREATE2")
+	contractAddressCmd.Flags().StringVar(&contractAddressFactory, "factory", "", "Address of the factory, for CREATE2 (defaults to the deterministic deployment proxy)")
+	contractAddressCmd.Flags().StringVar(&contractAddressData, "data", "", "Contract data (as a hex string), for CREATE2")
+	contractAddressCmd.Flags().StringVar(&contractAddressInitCodeHash, "init-code-hash", "", "Hash of the init code (as a hex string), for CREATE2")
+	contractAddressCmd.Flags().StringVar(&contractAddressConstructor, "constructor", "", "Constructor invocation (if required), for CREATE2")
+	contractAddressCmd.Flags().StringSliceVar(&contractAddressLinks, "link", nil, "Library to link, as name=address (can be supplied multiple times), for CREATE2")
+}
@@ -18,10 +18,13 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/spf13/cobra"
 	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
 	"github.com/wealdtech/ethereal/v2/util/funcparser"
 )
 
@@ -29,6 +32,12 @@ var (
 	contractCallFromAddress string
 	contractCallCall        string
 	contractCallData        string
+	contractCallFromBlock   string
+	contractCallToBlock     string
+	contractCallStep        uint64
+	contractCallEvery       time.Duration
+	contractCallConcurrency int
+	contractCallFormat      string
 )
 
 // contractCallCmd represents the contract call command.
@@ -41,6 +50,14 @@ var contractCallCmd = &cobra.Command{
 
    ethereal contract call --contract=0xd26114cd6EE289AccF82350c8d8487fedB8A0C07 --signature="balanceOf(address)" --from=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --call="balanceOf(@wealdtech.eth)"
 
+The call is made against the latest state unless --block or --at-time is supplied.
+
+Alternatively, the call can be made at blocks across a range to see how its result changes over time.  Blocks are sampled every --step blocks, or every --every interval of time, from --from-block to --to-block.  For example:
+
+   ethereal contract call --contract=0x6B175474E89094C44Da98b954EedeAC495271d0F --function="totalSupply() returns (uint256)" --from=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --call="totalSupply()" --from-block=19000000 --to-block=19100000 --every=24h
+
+The results are output as CSV or, with --format=jsonl, JSON lines, with the block, its time and the decoded outputs for each sample.  Samples at which the call fails are skipped with a warning.
+
 In quiet mode this will return 0 if the contract is successfully called, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(contractCallFromAddress != "", quiet, "--from is required")
@@ -52,6 +69,7 @@ In quiet mode this will return 0 if the contract is successfully called, otherwi
 		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", contractStr))
 
 		if contractCallData != "" {
+			cli.Assert(contractCallFromBlock == "", quiet, "--from-block cannot be used with --data")
 			// Raw data in and out.
 			data, err := hex.DecodeString(strings.TrimPrefix(contractCallData, "0x"))
 			cli.ErrCheck(err, quiet, "Failed to decode data")
@@ -63,8 +81,8 @@ In quiet mode this will return 0 if the contract is successfully called, otherwi
 			}
 			ctx, cancel := localContext()
 			defer cancel()
-			result, err := c.Client().CallContract(ctx, msg, nil)
-			cli.ErrCheck(err, quiet, "Call failed")
+			result, err := c.Client().CallContract(ctx, msg, stateBlockNumber(ctx))
+			stateErrCheck(err, "Call failed")
 			outputIf(!quiet, fmt.Sprintf("%x", result))
 			os.Exit(exitSuccess)
 		}
@@ -72,7 +90,7 @@ In quiet mode this will return 0 if the contract is successfully called, otherwi
 		// We need to have 'call'.
 		cli.Assert(contractCallCall != "", quiet, "--call is required")
 
-		contract := parseContract("")
+		contract := parseContractAt(contractAddress)
 		method, methodArgs, err := funcparser.ParseCall(c.Client(), contract, contractCallCall)
 		cli.ErrCheck(err, quiet, "Failed to parse call")
 		data, err := contract.Abi.Pack(method.Name, methodArgs...)
@@ -80,6 +98,11 @@ In quiet mode this will return 0 if the contract is successfully called, otherwi
 
 		outputIf(verbose, fmt.Sprintf("Data is %x", data))
 
+		if contractCallFromBlock != "" {
+			contractCallSeries(fromAddress, contractAddress, contract, method, data)
+			return
+		}
+
 		// Make the call.
 		msg := ethereum.CallMsg{
 			From: fromAddress,
@@ -88,8 +111,8 @@ In quiet mode this will return 0 if the contract is successfully called, otherwi
 		}
 		ctx, cancel := localContext()
 		defer cancel()
-		result, err := c.Client().CallContract(ctx, msg, nil)
-		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to call %s", method.Name))
+		result, err := c.Client().CallContract(ctx, msg, stateBlockNumber(ctx))
+		stateErrCheck(err, fmt.Sprintf("Failed to call %s", method.Name))
 		if len(method.Outputs) == 0 {
 			// No output.
 			os.Exit(exitSuccess)
@@ -102,25 +125,44 @@ In quiet mode this will return 0 if the contract is successfully called, otherwi
 
 		outputIf(verbose, fmt.Sprintf("Result is %x", result))
 
-		outputs, err := contract.Abi.Unpack(method.Name, result)
-		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to parse output of %s: %v", method.Name, err))
-
-		results := []string{}
-		for i := range outputs {
-			val, err := contractValueToString(method.Outputs[i].Type, outputs[i])
-			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to turn value %v in to suitable output", outputs[i]))
-			results = append(results, val)
-		}
+		results, err := contractCallDecode(contract, method, result)
+		cli.ErrCheck(err, quiet, "Failed to decode output")
 
 		// Output the result.
 		fmt.Printf("%s\n", strings.Join(results, ","))
 	},
 }
 
+// contractCallDecode returns the decoded outputs of a call.
+func contractCallDecode(contract *util.Contract, method *abi.Method, result []byte) ([]string, error) {
+	outputs, err := contract.Abi.Unpack(method.Name, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse output of %s: %w", method.Name, err)
+	}
+
+	results := make([]string, len(outputs))
+	for i := range outputs {
+		results[i], err = contractValueToString(method.Outputs[i].Type, outputs[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to turn value %v in to suitable output: %w", outputs[i], err)
+		}
+	}
+
+	return results, nil
+}
+
 func init() {
 	contractCmd.AddCommand(contractCallCmd)
 	contractFlags(contractCallCmd)
 	contractCallCmd.Flags().StringVar(&contractCallFromAddress, "from", "", "Address from which to call the contract method")
 	contractCallCmd.Flags().StringVar(&contractCallData, "data", "", "Raw hex data to use in the call")
 	contractCallCmd.Flags().StringVar(&contractCallCall, "call", "", "Contract method to call")
+	addStateFlags(contractCallCmd)
+	contractCallCmd.Flags().StringVar(&contractCallFromBlock, "from-block", "", "Block from which to sample the call over a range")
+	contractCallCmd.Flags().StringVar(&contractCallToBlock, "to-block", "latest", "Block up to which to sample the call over a range")
+	contractCallCmd.Flags().Uint64Var(&contractCallStep, "step", 1, "Number of blocks between samples over a range")
+	contractCallCmd.Flags().DurationVar(&contractCallEvery, "every", 0, "Time between samples over a range, for example 1h (instead of --step)")
+	contractCallCmd.Flags().IntVar(&contractCallConcurrency, "concurrency", 4, "Maximum number of calls to run concurrently over a range")
+	contractCallCmd.Flags().StringVar(&contractCallFormat, "format", "csv", "Output format over a range (csv or jsonl)")
+	contractCallCmd.Flags().BoolVar(&contractFollowProxy, "follow-proxy", false, "Use the stored ABI of the implementation if the contract is a proxy and no ABI is supplied")
 }
@@ -0,0 +1,204 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+)
+
+// contractCallSeries makes the call at each block sampled from the range, and outputs the results.
+func contractCallSeries(fromAddress common.Address, contractAddress common.Address, contract *util.Contract, method *abi.Method, data []byte) {
+	cli.Assert(!offline, quiet, "Cannot call contracts offline")
+	cli.Assert(stateBlock == "" && stateAtTime == "", quiet, "--block and --at-time cannot be used with --from-block")
+	cli.Assert(len(method.Outputs) > 0, quiet, fmt.Sprintf("%s has no outputs to sample", method.Name))
+
+	blocks := contractCallSeriesBlocks()
+	outputIf(verbose, fmt.Sprintf("Sampling %d blocks from %d to %d", len(blocks), blocks[0], blocks[len(blocks)-1]))
+
+	writer := newContractCallSeriesWriter(contractCallFormat, method)
+	found := false
+	ctx, cancel := interruptContext()
+	defer cancel()
+	err := util.CallSeries(ctx, c.Client(), ethereum.CallMsg{
+		From: fromAddress,
+		To:   &contractAddress,
+		Data: data,
+	}, blocks, contractCallConcurrency, viper.GetDuration("timeout"), func(point *util.CallSeriesPoint) error {
+		cli.Assert(!util.IsMissingStateError(point.Err), quiet, fmt.Sprintf("Connection does not hold state for block %d; historical state requires an archive node", point.Block))
+		if point.Err != nil {
+			cli.Warn(quiet, fmt.Sprintf("Call to %s failed at block %d: %v", method.Name, point.Block, point.Err))
+			return nil
+		}
+		if len(point.Output) == 0 {
+			cli.Warn(quiet, fmt.Sprintf("Call to %s did not return expected data at block %d", method.Name, point.Block))
+			return nil
+		}
+		found = true
+		if quiet {
+			return nil
+		}
+		results, err := contractCallDecode(contract, method, point.Output)
+		if err != nil {
+			return fmt.Errorf("block %d: %w", point.Block, err)
+		}
+		return writer.write(point, results)
+	})
+	cli.ErrCheck(err, quiet, "Failed to sample call")
+	cli.ErrCheck(writer.flush(), quiet, "Failed to write results")
+
+	if quiet {
+		if found {
+			os.Exit(exitSuccess)
+		}
+		os.Exit(exitFailure)
+	}
+}
+
+// contractCallSeriesBlocks returns the blocks at which to sample the call.
+func contractCallSeriesBlocks() []uint64 {
+	ctx, cancel := localContext()
+	fromBlock := blockNumberOf(ctx, contractCallFromBlock)
+	toBlock := blockNumberOf(ctx, contractCallToBlock)
+	cancel()
+	cli.Assert(fromBlock <= toBlock, quiet, "--from-block must not be after --to-block")
+
+	if contractCallEvery != 0 {
+		cli.Assert(contractCallStep == 1, quiet, "only one of --step and --every can be supplied")
+		backend := &timeoutHeaderBackend{backend: c.Client(), timeout: viper.GetDuration("timeout")}
+		ctx, cancel := interruptContext()
+		defer cancel()
+		first, err := backend.HeaderByNumber(ctx, new(big.Int).SetUint64(fromBlock))
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain block %d", fromBlock))
+		last, err := backend.HeaderByNumber(ctx, new(big.Int).SetUint64(toBlock))
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain block %d", toBlock))
+		// The search can take many requests for long ranges, so the timeout
+		// applies to each request rather than to the search as a whole.
+		blocks, err := util.BlocksAtInterval(ctx, backend, first, last, contractCallEvery)
+		cli.ErrCheck(err, quiet, "Failed to obtain blocks to sample")
+		return blocks
+	}
+
+	cli.Assert(contractCallStep > 0, quiet, "--step must be at least 1")
+	blocks := make([]uint64, 0, (toBlock-fromBlock)/contractCallStep+1)
+	for block := fromBlock; block <= toBlock; block += contractCallStep {
+		blocks = append(blocks, block)
+		if toBlock-block < contractCallStep {
+			break
+		}
+	}
+
+	return blocks
+}
+
+// timeoutHeaderBackend applies a timeout to each header request.
+type timeoutHeaderBackend struct {
+	backend util.HeaderBackend
+	timeout time.Duration
+}
+
+// HeaderByNumber obtains the header of the given block.
+func (b *timeoutHeaderBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	return b.backend.HeaderByNumber(ctx, number)
+}
+
+// contractCallSeriesWriter writes sampled results in the requested format.
+type contractCallSeriesWriter struct {
+	format string
+	csv    *csv.Writer
+	names  []string
+}
+
+func newContractCallSeriesWriter(format string, method *abi.Method) *contractCallSeriesWriter {
+	writer := &contractCallSeriesWriter{
+		format: format,
+		names:  make([]string, len(method.Outputs)),
+	}
+	for i, output := range method.Outputs {
+		writer.names[i] = output.Name
+		if writer.names[i] == "" {
+			writer.names[i] = fmt.Sprintf("output%d", i)
+		}
+	}
+
+	switch format {
+	case "jsonl":
+	case "csv":
+		writer.csv = csv.NewWriter(os.Stdout)
+		if !quiet {
+			header := append([]string{"block", "timestamp"}, writer.names...)
+			cli.ErrCheck(writer.csv.Write(header), quiet, "Failed to write header")
+		}
+	default:
+		cli.Err(quiet, fmt.Sprintf("Unknown format %s; should be csv or jsonl", format))
+	}
+
+	return writer
+}
+
+func (w *contractCallSeriesWriter) write(point *util.CallSeriesPoint, results []string) error {
+	timestamp := time.Unix(int64(point.Time), 0).UTC().Format(time.RFC3339)
+
+	if w.format == "jsonl" {
+		outputs := make(map[string]string, len(results))
+		for i, result := range results {
+			outputs[w.names[i]] = result
+		}
+		data, err := json.Marshal(&contractCallSeriesJSON{
+			Block:     point.Block,
+			Timestamp: timestamp,
+			Outputs:   outputs,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	return w.csv.Write(append([]string{strconv.FormatUint(point.Block, 10), timestamp}, results...))
+}
+
+func (w *contractCallSeriesWriter) flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	w.csv.Flush()
+
+	return w.csv.Error()
+}
+
+// contractCallSeriesJSON is the JSON representation of a sampled result.
+type contractCallSeriesJSON struct {
+	Block     uint64            `json:"block"`
+	Timestamp string            `json:"timestamp"`
+	Outputs   map[string]string `json:"outputs"`
+}
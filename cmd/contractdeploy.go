@@ -20,13 +20,19 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/wealdtech/ethereal/v2/cli"
 	"github.com/wealdtech/ethereal/v2/conn"
+	"github.com/wealdtech/ethereal/v2/util"
 	"github.com/wealdtech/ethereal/v2/util/funcparser"
 	string2eth "github.com/wealdtech/go-string2eth"
 )
@@ -37,6 +43,12 @@ var (
 	contractDeployData        string
 	contractDeployAmount      string
 	contractDeployRepeat      int
+	contractDeployLinks       []string
+	contractDeployLibraries   bool
+	contractDeployCreate2     bool
+	contractDeploySalt        string
+	contractDeployFactory     string
+	contractDeployProxy       bool
 )
 
 // contractDeployCmd represents the contract deploy command.
@@ -55,6 +67,10 @@ Usually the easiest way to deploy a contract is to use the combined JSON output
 
    ethereal contract deploy --json='./MyContract.json' --constructor='constructor(1,2,3') --from=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --passphrase=secret
 
+If the contract uses external libraries their addresses are supplied with --link, for example --link=MathLib=0x52f1A3027d3aA514F17E454C93ae1F79b3B12d5d.  The library name can be prefixed with its source file if required, for example --link=contracts/MathLib.sol:MathLib=0x52f1...2d5d.  Libraries that are not supplied with --link can be deployed before the contract with --deploy-libraries, in which case they are obtained from the same JSON as the contract or, for Foundry and Hardhat, from their own artifacts.  Each library is mined before the next transaction is created.
+
+If --create2 is supplied the contract is deployed with CREATE2 through a factory, so that its address depends only on the factory, the salt supplied with --salt and the contract's init code.  This allows the contract to be deployed at the same address on every chain.  The default factory is the deterministic deployment proxy at 0x4e59b44847b379578588920cA78FbF26c0B4956C; another factory can be supplied with --factory, as long as it takes the salt followed by the init code as its call data.  If the deterministic deployment proxy is not present, for example on a new development chain, --deploy-proxy-if-missing will install it with its presigned transaction.  This transaction is not replay-protected, so the node must accept such transactions.
+
 This will return an exit status of 0 if the transaction is successfully submitted (and mined if --wait is supplied), 1 if the transaction is not successfully submitted, and 2 if the transaction is successfully submitted but not mined within the supplied time limit.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(contractDeployFromAddress != "", quiet, "--from is required")
@@ -62,17 +78,17 @@ This will return an exit status of 0 if the transaction is successfully submitte
 		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve from address %s", contractDeployFromAddress))
 		cli.Assert(contractDeployData != "" || contractJSON != "", quiet, "either --data or --json is required")
 
+		var gasLimit *uint64
+		limit := uint64(viper.GetInt64("gaslimit"))
+		if limit > 0 {
+			gasLimit = &limit
+		}
+
 		contract := parseContract(contractDeployData)
 		cli.Assert(len(contract.Binary) > 0, quiet, "failed to obtain contract binary data")
-		if contractDeployConstructor != "" {
-			_, constructorArgs, err := funcparser.ParseCall(c.Client(), contract, contractDeployConstructor)
-			cli.ErrCheck(err, quiet, "Failed to parse constructor")
-
-			argData, err := contract.Abi.Pack("", constructorArgs...)
-			cli.ErrCheck(err, quiet, "Failed to convert arguments")
-			outputIf(verbose, fmt.Sprintf("Constructor data is %x", argData))
-			contract.Binary = append(contract.Binary, argData...)
-		}
+
+		linkContractLibraries(contract, parseContractLinks(contractDeployLinks), fromAddress, gasLimit, make(map[string]bool))
+		appendConstructorArgs(contract, contractDeployConstructor)
 
 		amount := big.NewInt(0)
 		if contractDeployAmount != "" {
@@ -80,10 +96,29 @@ This will return an exit status of 0 if the transaction is successfully submitte
 			cli.ErrCheck(err, quiet, fmt.Sprintf("Invalid amount %s", contractDeployAmount))
 		}
 
-		var gasLimit *uint64
-		limit := uint64(viper.GetInt64("gaslimit"))
-		if limit > 0 {
-			gasLimit = &limit
+		var to *common.Address
+		data := contract.Binary
+		if contractDeployCreate2 {
+			cli.Assert(contractDeployRepeat == 1, quiet, "--repeat cannot be used with --create2")
+			salt, err := util.ParseSalt(contractDeploySalt)
+			cli.ErrCheck(err, quiet, "Invalid salt")
+			factory := create2Factory(contractDeployFactory)
+			if !offline {
+				ensureCreate2Factory(factory, fromAddress)
+			}
+			address := util.Create2Address(factory, salt, contract.Binary)
+			if !offline {
+				ctx, cancel := localContext()
+				defer cancel()
+				code, err := c.Client().CodeAt(ctx, address, nil)
+				cli.ErrCheck(err, quiet, "Failed to obtain code at contract address")
+				cli.Assert(len(code) == 0, quiet, fmt.Sprintf("Contract already deployed at %s", address.Hex()))
+			}
+			outputIf(verbose, fmt.Sprintf("Contract will be deployed at %s", address.Hex()))
+			to = &factory
+			data = util.Create2CallData(salt, contract.Binary)
+		} else {
+			cli.Assert(contractDeploySalt == "" && contractDeployFactory == "", quiet, "--salt and --factory require --create2")
 		}
 
 		var signedTx *types.Transaction
@@ -91,9 +126,10 @@ This will return an exit status of 0 if the transaction is successfully submitte
 			// Create and sign the transaction.
 			signedTx, err = c.CreateSignedTransaction(context.Background(), &conn.TransactionData{
 				From:     fromAddress,
+				To:       to,
 				Value:    amount,
 				GasLimit: gasLimit,
-				Data:     contract.Binary,
+				Data:     data,
 			})
 			cli.ErrCheck(err, quiet, "Failed to create contract deployment transaction")
 			outputIf(verbose, fmt.Sprintf("Transaction data is %x", signedTx.Data()))
@@ -128,5 +164,218 @@ func init() {
 	contractDeployCmd.Flags().StringVar(&contractDeployData, "data", "", "Contract data (as a hex string)")
 	contractDeployCmd.Flags().StringVar(&contractDeployFromAddress, "from", "", "Address from which to deploy the contract")
 	contractDeployCmd.Flags().IntVar(&contractDeployRepeat, "repeat", 1, "Number of times to repeat sending the transaction (incrementing the nonce each time)")
+	contractDeployCmd.Flags().StringSliceVar(&contractDeployLinks, "link", nil, "Library to link, as name=address (can be supplied multiple times)")
+	contractDeployCmd.Flags().BoolVar(&contractDeployLibraries, "deploy-libraries", false, "Deploy libraries that are not supplied with --link before the contract")
+	contractDeployCmd.Flags().BoolVar(&contractDeployCreate2, "create2", false, "Deploy the contract with CREATE2 through a factory")
+	contractDeployCmd.Flags().StringVar(&contractDeploySalt, "salt", "", "Salt for CREATE2 deployment (as a hex string)")
+	contractDeployCmd.Flags().StringVar(&contractDeployFactory, "factory", "", "Address of the CREATE2 factory (defaults to the deterministic deployment proxy)")
+	contractDeployCmd.Flags().BoolVar(&contractDeployProxy, "deploy-proxy-if-missing", false, "Install the deterministic deployment proxy if it is not present")
 	addTransactionFlags(contractDeployCmd, "Passphrase for the address from which to deploy the conract")
 }
+
+// parseContractLinks parses library links of the form name=address.
+func parseContractLinks(links []string) map[string]common.Address {
+	libraries := make(map[string]common.Address)
+	for _, link := range links {
+		name, addressStr, found := strings.Cut(link, "=")
+		cli.Assert(found && name != "", quiet, fmt.Sprintf("Invalid link %s; must be name=address", link))
+		address, err := resolveName(addressStr)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve library address %s", addressStr))
+		libraries[name] = address
+	}
+
+	return libraries
+}
+
+// appendConstructorArgs appends the arguments of the constructor to the contract binary.
+func appendConstructorArgs(contract *util.Contract, constructor string) {
+	if constructor == "" {
+		return
+	}
+	_, constructorArgs, err := funcparser.ParseCall(c.Client(), contract, constructor)
+	cli.ErrCheck(err, quiet, "Failed to parse constructor")
+
+	argData, err := contract.Abi.Pack("", constructorArgs...)
+	cli.ErrCheck(err, quiet, "Failed to convert arguments")
+	outputIf(verbose, fmt.Sprintf("Constructor data is %x", argData))
+	contract.Binary = append(contract.Binary, argData...)
+}
+
+// create2Factory returns the address of the CREATE2 factory.
+func create2Factory(input string) common.Address {
+	if input == "" {
+		return util.DeterministicDeploymentProxy
+	}
+	factory, err := resolveName(input)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve factory address %s", input))
+
+	return factory
+}
+
+// ensureCreate2Factory ensures that the CREATE2 factory is present, installing
+// the deterministic deployment proxy if requested.
+func ensureCreate2Factory(factory common.Address, fromAddress common.Address) {
+	ctx, cancel := localContext()
+	defer cancel()
+	code, err := c.Client().CodeAt(ctx, factory, nil)
+	cli.ErrCheck(err, quiet, "Failed to obtain code for factory")
+	if len(code) > 0 {
+		return
+	}
+	cli.Assert(factory == util.DeterministicDeploymentProxy, quiet, fmt.Sprintf("No factory at %s", factory.Hex()))
+	cli.Assert(contractDeployProxy, quiet, "Deterministic deployment proxy not present; use --deploy-proxy-if-missing to install it")
+
+	proxyTx, err := util.DeterministicDeploymentProxyTransaction()
+	cli.ErrCheck(err, quiet, "Failed to obtain deterministic deployment proxy transaction")
+
+	// Fund the deployer of the proxy if required.
+	balance, err := c.Client().BalanceAt(ctx, util.DeterministicDeploymentProxyDeployer, nil)
+	cli.ErrCheck(err, quiet, "Failed to obtain balance of deterministic deployment proxy deployer")
+	if balance.Cmp(util.DeterministicDeploymentProxyCost) < 0 {
+		fundingTx, err := c.CreateSignedTransaction(context.Background(), &conn.TransactionData{
+			From:  fromAddress,
+			To:    &util.DeterministicDeploymentProxyDeployer,
+			Value: new(big.Int).Sub(util.DeterministicDeploymentProxyCost, balance),
+		})
+		cli.ErrCheck(err, quiet, "Failed to create funding transaction for deterministic deployment proxy deployer")
+		err = c.SendTransaction(context.Background(), fundingTx)
+		cli.ErrCheck(err, quiet, "Failed to send funding transaction for deterministic deployment proxy deployer")
+		logTransaction(fundingTx, log.Fields{
+			"group":   "contract",
+			"command": "deploy",
+		})
+		mined := util.WaitForTransaction(c.Client(), fundingTx.Hash(), viper.GetDuration("limit"))
+		cli.Assert(mined, quiet, fmt.Sprintf("Funding transaction %s submitted but not mined", fundingTx.Hash().Hex()))
+	}
+
+	err = c.SendTransaction(context.Background(), proxyTx)
+	cli.ErrCheck(err, quiet, "Failed to send deterministic deployment proxy transaction")
+	mined := util.WaitForTransaction(c.Client(), proxyTx.Hash(), viper.GetDuration("limit"))
+	cli.Assert(mined, quiet, fmt.Sprintf("Deterministic deployment proxy transaction %s submitted but not mined", proxyTx.Hash().Hex()))
+	outputIf(!quiet, fmt.Sprintf("Deterministic deployment proxy deployed at %s", util.DeterministicDeploymentProxy.Hex()))
+}
+
+// linkContractLibraries links the libraries used by the contract, deploying
+// them first if requested.
+func linkContractLibraries(contract *util.Contract,
+	libraries map[string]common.Address,
+	fromAddress common.Address,
+	gasLimit *uint64,
+	deploying map[string]bool,
+) {
+	contract.NameLibraries(contractLibraryNames(libraries))
+	cli.ErrCheck(contract.Link(libraries), quiet, "Failed to link libraries")
+	if contractDeployLibraries {
+		for _, name := range contract.UnlinkedLibraries() {
+			address := deployContractLibrary(name, libraries, fromAddress, gasLimit, deploying)
+			libraries[name] = address
+			cli.ErrCheck(contract.Link(libraries), quiet, "Failed to link libraries")
+		}
+	}
+
+	unlinked := contract.UnlinkedLibraries()
+	cli.Assert(len(unlinked) == 0, quiet, fmt.Sprintf("Unlinked libraries %s; use --link to supply their addresses", strings.Join(unlinked, ", ")))
+}
+
+// contractLibraryNames returns the fully-qualified names of libraries that
+// could be used by the contract, so that hashed placeholders can be named.
+func contractLibraryNames(libraries map[string]common.Address) []string {
+	names := make([]string, 0, len(libraries))
+	for name := range libraries {
+		if strings.Contains(name, ":") {
+			names = append(names, name)
+		}
+	}
+	if contractJSON != "" {
+		jsonNames, err := util.ContractNames(contractJSON)
+		cli.ErrCheck(err, quiet, "Failed to obtain contract names from JSON")
+		names = append(names, jsonNames...)
+	}
+
+	return names
+}
+
+// deployContractLibrary deploys a library, returning its address.
+func deployContractLibrary(name string,
+	libraries map[string]common.Address,
+	fromAddress common.Address,
+	gasLimit *uint64,
+	deploying map[string]bool,
+) common.Address {
+	cli.Assert(!deploying[name], quiet, fmt.Sprintf("Circular dependency for library %s", name))
+	cli.Assert(!strings.HasPrefix(name, "$"), quiet, fmt.Sprintf("Cannot deploy library with placeholder %s; use --link with its fully-qualified name to supply its address", name))
+	deploying[name] = true
+
+	library, err := findContractLibrary(name)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain library %s", name))
+	cli.Assert(len(library.Binary) > 0, quiet, fmt.Sprintf("Failed to obtain binary data for library %s", name))
+	linkContractLibraries(library, libraries, fromAddress, gasLimit, deploying)
+
+	signedTx, err := c.CreateSignedTransaction(context.Background(), &conn.TransactionData{
+		From:     fromAddress,
+		Value:    big.NewInt(0),
+		GasLimit: gasLimit,
+		Data:     library.Binary,
+	})
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to create deployment transaction for library %s", name))
+	address := crypto.CreateAddress(fromAddress, signedTx.Nonce())
+
+	if offline {
+		outputIf(verbose, fmt.Sprintf("Library %s will be deployed at %s", name, address.Hex()))
+		if !quiet {
+			buf := new(bytes.Buffer)
+			cli.ErrCheck(signedTx.EncodeRLP(buf), quiet, "failed to encode transaction")
+			fmt.Printf("0x%s\n", hex.EncodeToString(buf.Bytes()))
+		}
+		return address
+	}
+
+	err = c.SendTransaction(context.Background(), signedTx)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to send deployment transaction for library %s", name))
+	logTransaction(signedTx, log.Fields{
+		"group":   "contract",
+		"command": "deploy",
+		"library": name,
+	})
+	// Later transactions may rely on the library, so it must be mined first.
+	mined := util.WaitForTransaction(c.Client(), signedTx.Hash(), viper.GetDuration("limit"))
+	cli.Assert(mined, quiet, fmt.Sprintf("Deployment transaction %s for library %s submitted but not mined", signedTx.Hash().Hex(), name))
+	outputIf(!quiet, fmt.Sprintf("Library %s deployed at %s", name, address.Hex()))
+
+	return address
+}
+
+// findContractLibrary finds the JSON for a library used by the contract.
+func findContractLibrary(name string) (*util.Contract, error) {
+	if contractJSON == "" {
+		return nil, errors.New("--json is required to deploy libraries")
+	}
+
+	file, libraryName := "", name
+	if pos := strings.LastIndex(name, ":"); pos != -1 {
+		file, libraryName = name[:pos], name[pos+1:]
+	}
+
+	// Foundry and Hardhat artifacts are in directories named after their source files.
+	if file != "" && !strings.HasPrefix(strings.TrimSpace(contractJSON), "{") {
+		dir := filepath.Dir(filepath.Dir(contractJSON))
+		for {
+			for _, candidate := range []string{
+				filepath.Join(dir, file, fmt.Sprintf("%s.json", libraryName)),
+				filepath.Join(dir, filepath.Base(file), fmt.Sprintf("%s.json", libraryName)),
+			} {
+				if _, err := os.Stat(candidate); err == nil {
+					return util.ParseContractJSON(candidate, libraryName)
+				}
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+
+	// Standard and combined JSON contain libraries alongside the contract.
+	return util.ParseContractJSON(contractJSON, name)
+}
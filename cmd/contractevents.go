@@ -0,0 +1,366 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+)
+
+var (
+	contractEventsEvent       string
+	contractEventsFilters     []string
+	contractEventsFromBlock   string
+	contractEventsToBlock     string
+	contractEventsChunkSize   uint64
+	contractEventsConcurrency int
+	contractEventsFormat      string
+)
+
+// contractEventsCmd represents the contract events command.
+var contractEventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Obtain historical events emitted by a contract",
+	Long: `Obtain historical events emitted by a contract, decoded with the contract's ABI.  For example:
+
+    ethereal contract events --contract=0x6B175474E89094C44Da98b954EedeAC495271d0F --event="Transfer(address indexed src,address indexed dst,uint256 wad)" --filter=dst=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --from-block=19000000 --to-block=19010000
+
+The event can be supplied as a signature, or as a name if the ABI of the contract is supplied or stored.  If no event is supplied then all events in the ABI are returned.
+
+Filters match indexed parameters by name, with multiple filters for the same parameter matching any of their values.  Large block ranges are fetched in chunks, which are split further if the node rejects them.
+
+Output is in text by default, or can be JSON lines or CSV with --format.
+
+In quiet mode this will return 0 if any events are found, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(!offline, quiet, "Cannot obtain events offline")
+		cli.Assert(contractStr != "", quiet, "--contract is required")
+		contractAddress, err := c.Resolve(contractStr)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", contractStr))
+
+		events := contractEventsEvents(contractAddress)
+		topics := contractEventsTopics(events)
+
+		ctx, cancel := localContext()
+		defer cancel()
+		fromBlock, toBlock := contractEventsRange(ctx)
+		outputIf(verbose, fmt.Sprintf("Fetching events from block %d to block %d", fromBlock, toBlock))
+
+		writer := newContractEventsWriter(contractEventsFormat, events)
+		found := false
+		err = util.FetchLogs(context.Background(), c.Client(), ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(fromBlock),
+			ToBlock:   new(big.Int).SetUint64(toBlock),
+			Addresses: []common.Address{contractAddress},
+			Topics:    topics,
+		}, contractEventsChunkSize, contractEventsConcurrency, func(logs []types.Log) error {
+			for i := range logs {
+				if logs[i].Removed {
+					continue
+				}
+				found = true
+				if quiet {
+					continue
+				}
+				event := events[logs[i].Topics[0]]
+				args, err := util.DecodeEvent(event, &logs[i])
+				if err != nil {
+					cli.Warn(quiet, fmt.Sprintf("Failed to decode log %d in block %d: %v", logs[i].Index, logs[i].BlockNumber, err))
+					continue
+				}
+				if err := writer.write(&logs[i], event, args); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		cli.ErrCheck(err, quiet, "Failed to obtain events")
+		cli.ErrCheck(writer.flush(), quiet, "Failed to write events")
+
+		if quiet {
+			if found {
+				os.Exit(exitSuccess)
+			}
+			os.Exit(exitFailure)
+		}
+	},
+}
+
+// contractEventsEvents returns the events to fetch, keyed by their IDs.
+func contractEventsEvents(contractAddress common.Address) map[common.Hash]*abi.Event {
+	events := make(map[common.Hash]*abi.Event)
+	if strings.Contains(contractEventsEvent, "(") {
+		event, err := util.ParseEventSignature(contractEventsEvent)
+		cli.ErrCheck(err, quiet, "Invalid event")
+		events[event.ID] = event
+		return events
+	}
+
+	contract := parseContractAt(contractAddress)
+	cli.Assert(len(contract.Abi.Events) > 0, quiet, "No events in ABI; --event must be a signature if the ABI is not supplied or stored")
+	events = contractABIEvents(&contract.Abi, contractEventsEvent)
+	cli.Assert(len(events) > 0, quiet, fmt.Sprintf("Event %s not found in ABI", contractEventsEvent))
+
+	return events
+}
+
+// contractABIEvents returns the non-anonymous events in an ABI with the given
+// name, or all of them if no name is given, keyed by their IDs.
+func contractABIEvents(contractAbi *abi.ABI, name string) map[common.Hash]*abi.Event {
+	events := make(map[common.Hash]*abi.Event)
+	for key := range contractAbi.Events {
+		event := contractAbi.Events[key]
+		if event.Anonymous {
+			continue
+		}
+		if name == "" || name == event.Name {
+			events[event.ID] = &event
+		}
+	}
+
+	return events
+}
+
+// contractEventsTopics returns the topics with which to filter the events.
+func contractEventsTopics(events map[common.Hash]*abi.Event) [][]common.Hash {
+	filters := make(map[string][]string)
+	for _, filter := range contractEventsFilters {
+		name, value, found := strings.Cut(filter, "=")
+		cli.Assert(found, quiet, fmt.Sprintf("Invalid filter %s; should be name=value", filter))
+		filters[name] = append(filters[name], value)
+	}
+
+	if len(events) > 1 {
+		cli.Assert(len(filters) == 0, quiet, "--filter requires a single event")
+		ids := make([]common.Hash, 0, len(events))
+		for id := range events {
+			ids = append(ids, id)
+		}
+		return [][]common.Hash{ids}
+	}
+
+	var topics [][]common.Hash
+	for _, event := range events {
+		var err error
+		topics, err = util.EventTopics(event, filters, c.Resolve)
+		cli.ErrCheck(err, quiet, "Invalid filter")
+	}
+
+	return topics
+}
+
+// contractEventsRange returns the range of blocks from which to fetch events.
+func contractEventsRange(ctx context.Context) (uint64, uint64) {
+	cli.Assert(contractEventsFromBlock != "", quiet, "--from-block is required")
+	fromBlock, err := strconv.ParseUint(contractEventsFromBlock, 10, 64)
+	cli.ErrCheck(err, quiet, "Invalid from block")
+
+	var toBlock uint64
+	if contractEventsToBlock == "" || contractEventsToBlock == "latest" {
+		header, err := c.Client().HeaderByNumber(ctx, nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain latest block")
+		toBlock = header.Number.Uint64()
+	} else {
+		toBlock, err = strconv.ParseUint(contractEventsToBlock, 10, 64)
+		cli.ErrCheck(err, quiet, "Invalid to block")
+	}
+	cli.Assert(fromBlock <= toBlock, quiet, "--from-block must not be after --to-block")
+
+	return fromBlock, toBlock
+}
+
+// contractEventsWriter writes events in the requested format.
+type contractEventsWriter struct {
+	format string
+	csv    *csv.Writer
+	// argNames are the CSV columns for arguments, if there is a single event.
+	argNames []string
+}
+
+func newContractEventsWriter(format string, events map[common.Hash]*abi.Event) *contractEventsWriter {
+	writer := &contractEventsWriter{format: format}
+	switch format {
+	case "text", "jsonl":
+	case "csv":
+		writer.csv = csv.NewWriter(os.Stdout)
+		header := []string{"block", "transaction", "log", "event"}
+		if len(events) == 1 {
+			for _, event := range events {
+				writer.argNames = make([]string, 0, len(event.Inputs))
+				for i, input := range event.Inputs {
+					name := input.Name
+					if name == "" {
+						name = fmt.Sprintf("arg%d", i)
+					}
+					writer.argNames = append(writer.argNames, name)
+				}
+			}
+			header = append(header, writer.argNames...)
+		} else {
+			header = append(header, "args")
+		}
+		if !quiet {
+			cli.ErrCheck(writer.csv.Write(header), quiet, "Failed to write header")
+		}
+	default:
+		cli.Err(quiet, fmt.Sprintf("Unknown format %s; should be text, jsonl or csv", format))
+	}
+
+	return writer
+}
+
+func (w *contractEventsWriter) write(log *types.Log, event *abi.Event, args []*util.EventArg) error {
+	values := make([]string, len(args))
+	for i, arg := range args {
+		var err error
+		values[i], err = abiValueToString(arg.Type, arg.Value, common.Address.Hex)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch w.format {
+	case "jsonl":
+		argMap := make(map[string]string, len(args))
+		for i, arg := range args {
+			argMap[arg.Name] = values[i]
+		}
+		data, err := json.Marshal(&contractEventJSON{
+			Block:       log.BlockNumber,
+			Transaction: log.TxHash.Hex(),
+			Log:         log.Index,
+			Event:       event.Name,
+			Args:        argMap,
+			Removed:     log.Removed,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case "csv":
+		record := []string{strconv.FormatUint(log.BlockNumber, 10), log.TxHash.Hex(), strconv.FormatUint(uint64(log.Index), 10), event.Name}
+		if w.argNames != nil {
+			record = append(record, values...)
+		} else {
+			record = append(record, strings.Join(namedValues(args, values), ";"))
+		}
+		return w.csv.Write(record)
+	default:
+		fmt.Printf("%s: %s(%s)\n", contractEventLocation(log), event.Name, strings.Join(namedValues(args, values), ","))
+	}
+
+	return nil
+}
+
+// writeUndecoded writes a log for which the event is not known, along with
+// its representation from the signature database if available.  It does not
+// support CSV, as the columns depend on the event.
+func (w *contractEventsWriter) writeUndecoded(log *types.Log, decoded string) error {
+	topics := make([]string, len(log.Topics))
+	for i, topic := range log.Topics {
+		topics[i] = topic.Hex()
+	}
+
+	switch w.format {
+	case "jsonl":
+		data, err := json.Marshal(&contractEventJSON{
+			Block:       log.BlockNumber,
+			Transaction: log.TxHash.Hex(),
+			Log:         log.Index,
+			Event:       decoded,
+			Topics:      topics,
+			Data:        fmt.Sprintf("%#x", log.Data),
+			Removed:     log.Removed,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	default:
+		if decoded == "" {
+			decoded = fmt.Sprintf("topics=%s,data=%#x", strings.Join(topics, ";"), log.Data)
+		}
+		fmt.Printf("%s: %s\n", contractEventLocation(log), decoded)
+	}
+
+	return nil
+}
+
+// contractEventLocation returns the textual location of a log, noting if it has been removed by a reorg.
+func contractEventLocation(log *types.Log) string {
+	location := fmt.Sprintf("Block %d, transaction %s, log %d", log.BlockNumber, log.TxHash.Hex(), log.Index)
+	if log.Removed {
+		location = "Removed: " + location
+	}
+
+	return location
+}
+
+func (w *contractEventsWriter) flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	w.csv.Flush()
+
+	return w.csv.Error()
+}
+
+// contractEventJSON is the JSON representation of an event.
+type contractEventJSON struct {
+	Block       uint64            `json:"block"`
+	Transaction string            `json:"transaction"`
+	Log         uint              `json:"log"`
+	Event       string            `json:"event"`
+	Args        map[string]string `json:"args,omitempty"`
+	Topics      []string          `json:"topics,omitempty"`
+	Data        string            `json:"data,omitempty"`
+	Removed     bool              `json:"removed,omitempty"`
+}
+
+// namedValues returns the values of arguments prefixed by their names.
+func namedValues(args []*util.EventArg, values []string) []string {
+	res := make([]string, len(args))
+	for i, arg := range args {
+		res[i] = fmt.Sprintf("%s=%s", arg.Name, values[i])
+	}
+
+	return res
+}
+
+func init() {
+	contractCmd.AddCommand(contractEventsCmd)
+	contractFlags(contractEventsCmd)
+	contractEventsCmd.Flags().StringVar(&contractEventsEvent, "event", "", "Event signature, or name if the ABI is supplied")
+	contractEventsCmd.Flags().StringArrayVar(&contractEventsFilters, "filter", nil, "Filter on an indexed parameter, as name=value (can be supplied multiple times)")
+	contractEventsCmd.Flags().StringVar(&contractEventsFromBlock, "from-block", "", "Block from which to obtain events")
+	contractEventsCmd.Flags().StringVar(&contractEventsToBlock, "to-block", "latest", "Block up to which to obtain events")
+	contractEventsCmd.Flags().Uint64Var(&contractEventsChunkSize, "chunk-size", 10000, "Maximum number of blocks to query at a time")
+	contractEventsCmd.Flags().IntVar(&contractEventsConcurrency, "concurrency", 4, "Maximum number of queries to run concurrently")
+	contractEventsCmd.Flags().StringVar(&contractEventsFormat, "format", "text", "Output format (text, jsonl or csv)")
+	contractEventsCmd.Flags().BoolVar(&contractFollowProxy, "follow-proxy", false, "Use the stored ABI of the implementation if the contract is a proxy and no ABI is supplied")
+}
@@ -0,0 +1,100 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+	ens "github.com/wealdtech/go-ens/v3"
+)
+
+// contractInfoCmd represents the contract info command.
+var contractInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Obtain information about a contract",
+	Long: `Obtain information about a contract, including the chain of proxies behind it.  For example:
+
+    ethereal contract info --contract=0x3c24F71e826D3762f5145f6a27d41545A7dfc8cF
+
+EIP-1967 proxies (including beacon and UUPS proxies), EIP-1822 proxies and EIP-1167 minimal proxies are recognised.  If the contract or its final implementation has an ABI in the ABI store then other contract commands use it when no ABI is supplied.
+
+Information at an earlier point can be obtained with --block or --at-time.
+
+In quiet mode this will return 0 if the contract is a proxy, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(contractStr != "", quiet, "--contract is required")
+		contractAddress, err := c.Resolve(contractStr)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", contractStr))
+
+		ctx, cancel := localContext()
+		defer cancel()
+		blockNumber := stateBlockNumber(ctx)
+		code, err := c.Client().CodeAt(ctx, contractAddress, blockNumber)
+		stateErrCheck(err, "Failed to obtain code")
+		cli.Assert(len(code) > 0, quiet, fmt.Sprintf("No contract at %s", contractAddress.Hex()))
+		chain, err := util.ProxyChain(ctx, c.Client(), contractAddress, blockNumber)
+		stateErrCheck(err, "Failed to inspect proxy")
+
+		if quiet {
+			if len(chain) == 0 {
+				os.Exit(exitFailure)
+			}
+			os.Exit(exitSuccess)
+		}
+
+		fmt.Printf("Address:\t\t%s\n", ens.Format(c.Client(), contractAddress))
+		fmt.Printf("Code size:\t\t%d\n", len(code))
+		if len(chain) == 0 {
+			fmt.Printf("Proxy type:\t\tNone\n")
+			return
+		}
+		// The final implementation may not have been deployed.
+		implementationCode, err := c.Client().CodeAt(ctx, chain[len(chain)-1].Implementation, blockNumber)
+		stateErrCheck(err, "Failed to obtain code of implementation")
+		for i, proxy := range chain {
+			fmt.Printf("Proxy type:\t\t%s\n", proxy.Type)
+			if i == len(chain)-1 && len(implementationCode) == 0 {
+				fmt.Printf("Implementation:\t\t%s (no code)\n", ens.Format(c.Client(), proxy.Implementation))
+			} else {
+				fmt.Printf("Implementation:\t\t%s\n", ens.Format(c.Client(), proxy.Implementation))
+			}
+			if proxy.Admin != (common.Address{}) {
+				fmt.Printf("Admin:\t\t\t%s\n", ens.Format(c.Client(), proxy.Admin))
+			}
+			if proxy.Beacon != (common.Address{}) {
+				fmt.Printf("Beacon:\t\t\t%s\n", ens.Format(c.Client(), proxy.Beacon))
+			}
+		}
+
+		implementation := chain[len(chain)-1].Implementation
+		storedAbi, err := contractABIStore().ABI(c.ChainID(), implementation)
+		cli.ErrCheck(err, quiet, "Failed to obtain ABI from the ABI store")
+		if storedAbi != nil {
+			fmt.Printf("Stored ABI:\t\tYes\n")
+		} else {
+			fmt.Printf("Stored ABI:\t\tNo\n")
+		}
+	},
+}
+
+func init() {
+	contractCmd.AddCommand(contractInfoCmd)
+	contractFlags(contractInfoCmd)
+	addStateFlags(contractInfoCmd)
+}
@@ -0,0 +1,221 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/conn"
+	"github.com/wealdtech/ethereal/v2/util"
+	"github.com/wealdtech/ethereal/v2/util/funcparser"
+)
+
+var (
+	contractMulticallCalls          []string
+	contractMulticallFromAddress    string
+	contractMulticallRequireSuccess bool
+	contractMulticallSend           bool
+)
+
+// contractMulticallCmd represents the contract multicall command.
+var contractMulticallCmd = &cobra.Command{
+	Use:   "multicall",
+	Short: "Call multiple contract methods at once",
+	Long: `Call multiple contract methods in a single request using Multicall3.  For example:
+
+   ethereal contract multicall --call='0x6B175474E89094C44Da98b954EedeAC495271d0F:totalSupply() returns (uint256):totalSupply()' --call='0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48:balanceOf(address) returns (uint256):balanceOf(0x5FfC014343cd971B7eb70732021E26C35B744cc4)'
+
+Each call is of the form <contract>:<ABI>:<call>, where the ABI is a function signature or a path to an ABI file.  If the ABI is empty, as in <contract>::<call>, then the ABI supplied with --abi, --json or --function is used.
+
+The calls are made against the latest state unless --block or --at-time is supplied.  The results are output one line per call, in order.  By default a failing call does not stop the others, and its error is output in place of its result; --require-success fails the entire request instead.
+
+With --send the calls are sent as a single transaction to Multicall3.  Note that the calls are then made by Multicall3 rather than the sender, so this is only suitable for methods that do not depend on the caller, and the calls cannot include Ether.
+
+In quiet mode this will return 0 if all calls succeed (or the transaction is submitted with --send), otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(len(contractMulticallCalls) > 0, quiet, "--call is required")
+
+		contracts := make([]*util.Contract, len(contractMulticallCalls))
+		methods := make([]*abi.Method, len(contractMulticallCalls))
+		calls := make([]*util.MulticallCall, len(contractMulticallCalls))
+		for i, input := range contractMulticallCalls {
+			contracts[i], methods[i], calls[i] = contractMulticallParseCall(input)
+			outputIf(verbose, fmt.Sprintf("Call %d is to %s with data %x", i, calls[i].Target.Hex(), calls[i].Data))
+		}
+
+		if contractMulticallSend {
+			contractMulticallSendCalls(calls)
+			return
+		}
+
+		cli.Assert(!offline, quiet, "Cannot call contracts offline")
+		var fromAddress common.Address
+		if contractMulticallFromAddress != "" {
+			var err error
+			fromAddress, err = c.Resolve(contractMulticallFromAddress)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve from address %s", contractMulticallFromAddress))
+		}
+		ctx, cancel := localContext()
+		defer cancel()
+		results, err := util.Aggregate3(ctx, c.Client(), fromAddress, stateBlockNumber(ctx), calls)
+		stateErrCheck(err, "Failed to call contracts")
+
+		success := true
+		for i, result := range results {
+			if !result.Success {
+				success = false
+				outputIf(!quiet, fmt.Sprintf("Error: %v", util.RevertError(result.ReturnData)))
+				continue
+			}
+			if quiet {
+				continue
+			}
+			res, err := contractMulticallResult(contracts[i], methods[i], result.ReturnData)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			fmt.Println(res)
+		}
+
+		if quiet {
+			if success {
+				os.Exit(exitSuccess)
+			}
+			os.Exit(exitFailure)
+		}
+	},
+}
+
+// contractMulticallParseCall parses a call of the form <contract>:<ABI>:<call>.
+func contractMulticallParseCall(input string) (*util.Contract, *abi.Method, *util.MulticallCall) {
+	parts := strings.SplitN(input, ":", 3)
+	cli.Assert(len(parts) == 3, quiet, fmt.Sprintf("Invalid call %s; should be <contract>:<ABI>:<call>", input))
+
+	contractAddress, err := c.Resolve(parts[0])
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", parts[0]))
+
+	var contract *util.Contract
+	switch {
+	case parts[1] == "":
+		contract = parseContractAt(contractAddress)
+	case strings.Contains(parts[1], "("):
+		contractAbi, err := contractParseFunction(parts[1])
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to parse function %s", parts[1]))
+		contract = &util.Contract{Abi: *contractAbi}
+	default:
+		contractAbi, err := contractParseAbi(parts[1])
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to parse ABI %s", parts[1]))
+		contract = &util.Contract{Abi: contractAbi}
+	}
+
+	method, methodArgs, err := funcparser.ParseCall(c.Client(), contract, parts[2])
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to parse call %s", parts[2]))
+	data, err := contract.Abi.Pack(method.Name, methodArgs...)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to convert arguments for %s", parts[2]))
+
+	return contract, method, &util.MulticallCall{
+		Target:       contractAddress,
+		AllowFailure: !contractMulticallRequireSuccess,
+		Data:         data,
+	}
+}
+
+// contractMulticallResult returns the decoded result of a call.
+func contractMulticallResult(contract *util.Contract, method *abi.Method, data []byte) (string, error) {
+	if len(method.Outputs) == 0 {
+		return "", nil
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("call to %s did not return expected data", method.Name)
+	}
+	results, err := contractCallDecode(contract, method, data)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(results, ","), nil
+}
+
+// contractMulticallSendCalls sends the calls as a single transaction to Multicall3.
+func contractMulticallSendCalls(calls []*util.MulticallCall) {
+	cli.Assert(contractMulticallFromAddress != "", quiet, "--from is required with --send")
+	fromAddress, err := c.Resolve(contractMulticallFromAddress)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve from address %s", contractMulticallFromAddress))
+	cli.Assert(viper.GetString("value") == "", quiet, "--value cannot be used with --send")
+	if !offline {
+		// A transaction to an address without code would succeed without making any calls.
+		ctx, cancel := localContext()
+		code, err := c.Client().CodeAt(ctx, util.Multicall3Address, nil)
+		cancel()
+		cli.ErrCheck(err, quiet, "Failed to check for Multicall3")
+		cli.Assert(len(code) > 0, quiet, fmt.Sprintf("Multicall3 is not deployed at %s on this chain", util.Multicall3Address.Hex()))
+	}
+
+	data, err := util.PackAggregate3(calls)
+	cli.ErrCheck(err, quiet, "Failed to pack calls")
+	outputIf(verbose, fmt.Sprintf("Data is %x", data))
+
+	var gasLimit *uint64
+	limit := uint64(viper.GetInt64("gaslimit"))
+	if limit > 0 {
+		gasLimit = &limit
+	}
+
+	signedTx, err := c.CreateSignedTransaction(context.Background(), &conn.TransactionData{
+		From:     fromAddress,
+		To:       &util.Multicall3Address,
+		GasLimit: gasLimit,
+		Data:     data,
+	})
+	cli.ErrCheck(err, quiet, "Failed to create multicall transaction")
+
+	if offline {
+		if !quiet {
+			buf := new(bytes.Buffer)
+			cli.ErrCheck(signedTx.EncodeRLP(buf), quiet, "failed to encode transaction")
+			fmt.Printf("0x%s\n", hex.EncodeToString(buf.Bytes()))
+		}
+		os.Exit(exitSuccess)
+	}
+	err = c.SendTransaction(context.Background(), signedTx)
+	cli.ErrCheck(err, quiet, "Failed to send transaction")
+	handleSubmittedTransaction(signedTx, log.Fields{
+		"group":   "contract",
+		"command": "multicall",
+	}, false)
+}
+
+func init() {
+	contractCmd.AddCommand(contractMulticallCmd)
+	contractFlags(contractMulticallCmd)
+	contractMulticallCmd.Flags().StringArrayVar(&contractMulticallCalls, "call", nil, "Call of the form <contract>:<ABI>:<call> (can be supplied multiple times)")
+	contractMulticallCmd.Flags().StringVar(&contractMulticallFromAddress, "from", "", "Address from which to make the calls (required with --send)")
+	contractMulticallCmd.Flags().BoolVar(&contractMulticallRequireSuccess, "require-success", false, "Fail if any call fails")
+	contractMulticallCmd.Flags().BoolVar(&contractMulticallSend, "send", false, "Send the calls as a single transaction")
+	contractMulticallCmd.Flags().BoolVar(&contractFollowProxy, "follow-proxy", false, "Use the stored ABI of the implementation if the contract is a proxy and no ABI is supplied")
+	addStateFlags(contractMulticallCmd)
+	addTransactionFlags(contractMulticallCmd, "Passphrase for the address from which to send the multicall transaction")
+}
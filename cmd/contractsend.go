@@ -56,7 +56,11 @@ This will return an exit status of 0 if the transaction is successfully submitte
 		// We need to have 'call'.
 		cli.Assert(contractSendCall != "", quiet, "--call is required")
 
-		contract := parseContract("")
+		cli.Assert(contractStr != "", quiet, "--contract is required")
+		contractAddress, err := c.Resolve(contractStr)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", contractStr))
+
+		contract := parseContractAt(contractAddress)
 		method, methodArgs, err := funcparser.ParseCall(c.Client(), contract, contractSendCall)
 		cli.ErrCheck(err, quiet, "Failed to parse call")
 
@@ -64,10 +68,6 @@ This will return an exit status of 0 if the transaction is successfully submitte
 		cli.ErrCheck(err, quiet, "Failed to convert arguments")
 		outputIf(verbose, fmt.Sprintf("Data is %x", data))
 
-		cli.Assert(contractStr != "", quiet, "--contract is required")
-		contractAddress, err := c.Resolve(contractStr)
-		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", contractStr))
-
 		amount := big.NewInt(0)
 		if contractSendAmount != "" {
 			amount, err = string2eth.StringToWei(contractSendAmount)
@@ -113,5 +113,6 @@ func init() {
 	contractSendCmd.Flags().StringVar(&contractSendAmount, "amount", "", "Amount of Ether to send with the contract method")
 	contractSendCmd.Flags().StringVar(&contractSendFromAddress, "from", "", "Address from which to call the contract function")
 	contractSendCmd.Flags().StringVar(&contractSendCall, "call", "", "Contract function to call")
+	contractSendCmd.Flags().BoolVar(&contractFollowProxy, "follow-proxy", false, "Use the stored ABI of the implementation if the contract is a proxy and no ABI is supplied")
 	addTransactionFlags(contractSendCmd, "Passphrase for the address from which to send the contract transaction")
 }
@@ -14,16 +14,25 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"strings"
 
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cobra"
 	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
 )
 
-var contractStorageKey string
+var (
+	contractStorageKey      string
+	contractStorageVariable string
+	contractStorageLayout   string
+)
 
 // contractStorageCmd represents the contract storage command.
 var contractStorageCmd = &cobra.Command{
@@ -33,18 +42,32 @@ var contractStorageCmd = &cobra.Command{
 
    ethereal contract storage --contract=0xd26114cd6EE289AccF82350c8d8487fedB8A0C07 --key=0x01
 
+Alternatively, obtain the value of a variable given the contract's storage layout, either from its JSON or supplied directly.  For example:
+
+   ethereal contract storage --contract=0xd26114cd6EE289AccF82350c8d8487fedB8A0C07 --json=Token.json --variable='balances[0x5FfC014343cd971B7eb70732021E26C35B744cc4]'
+
+Variables can select struct members with "." and array elements or mapping values with "[]", for example "config.owner" or "allowances[0x…][0x…]".  Structs and fixed-size arrays are shown as their individual members and elements, and dynamic arrays as their length.
+
+Storage is read from the latest state unless --block or --at-time is supplied.
+
 In quiet mode this will return 0 if the storage contains a non-zero value, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(contractStr != "", quiet, "--contract is required")
 		contractAddress, err := c.Resolve(contractStr)
 		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", contractStr))
 
-		cli.Assert(contractStorageKey != "", quiet, "--key is required")
+		if contractStorageVariable != "" {
+			cli.Assert(contractStorageKey == "", quiet, "only one of --key and --variable can be supplied")
+			contractStorageVariableValue(contractAddress)
+			return
+		}
+
+		cli.Assert(contractStorageKey != "", quiet, "--key or --variable is required")
 		hash := common.HexToHash(strings.TrimPrefix(contractStorageKey, "0x"))
 		ctx, cancel := localContext()
 		defer cancel()
-		value, err := c.Client().StorageAt(ctx, contractAddress, hash, nil)
-		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain storage for contract %s", contractStr))
+		value, err := c.Client().StorageAt(ctx, contractAddress, hash, stateBlockNumber(ctx))
+		stateErrCheck(err, fmt.Sprintf("Failed to obtain storage for contract %s", contractStr))
 
 		if quiet {
 			for _, b := range value {
@@ -60,8 +83,107 @@ In quiet mode this will return 0 if the storage contains a non-zero value, other
 	},
 }
 
+// contractStorageVariableValue outputs the value of a variable from the contract's storage layout.
+func contractStorageVariableValue(contractAddress common.Address) {
+	layout := contractStorageParseLayout()
+	cli.Assert(layout != nil, quiet, "--json with a storage layout, or --storage-layout, is required with --variable")
+
+	loc, err := layout.Locate(contractStorageVariable, c.Resolve)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to locate %s", contractStorageVariable))
+	outputIf(verbose, fmt.Sprintf("%s is at slot %s offset %d", loc.Name, loc.Slot.Hex(), loc.Offset))
+
+	ctx, cancel := localContext()
+	defer cancel()
+	values, err := layout.Read(ctx, c.Client(), contractAddress, stateBlockNumber(ctx), loc)
+	stateErrCheck(err, fmt.Sprintf("Failed to obtain %s", contractStorageVariable))
+
+	if quiet {
+		for _, value := range values {
+			for _, b := range value.Raw {
+				if b != 0 {
+					os.Exit(exitSuccess)
+				}
+			}
+		}
+		os.Exit(exitFailure)
+	}
+
+	for _, value := range values {
+		res, err := contractValueToString(value.Type, value.Value)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to decode %s", value.Name))
+		if len(values) == 1 && value.Name == loc.Name {
+			fmt.Println(res)
+		} else {
+			fmt.Printf("%s: %s\n", value.Name, res)
+		}
+	}
+}
+
+// contractStorageParseLayout returns the storage layout supplied directly or
+// in the contract JSON, or nil if there is none.
+func contractStorageParseLayout() *util.StorageLayout {
+	if contractStorageLayout != "" {
+		layout, err := util.ParseStorageLayout(contractStorageLayout)
+		cli.ErrCheck(err, quiet, "Failed to parse storage layout")
+		return layout
+	}
+	if contractJSON != "" {
+		return parseContract("").StorageLayout
+	}
+
+	return nil
+}
+
+// contractStorageState returns the block hash and transaction index with
+// which debug_storageRangeAt obtains the state at the end of the given block,
+// being the start of the following block.
+func contractStorageState(ctx context.Context, blockNumber uint64) (common.Hash, int) {
+	header, err := c.Client().HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber+1))
+	if errors.Is(err, ethereum.NotFound) {
+		cli.Err(quiet, fmt.Sprintf("State at the end of block %d is not available until the following block is produced", blockNumber))
+	}
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain block %d", blockNumber+1))
+
+	return header.Hash(), 0
+}
+
+// contractStorageBlock returns the block number supplied, or the latest block
+// for which the state at its end is available.
+func contractStorageBlock(ctx context.Context, input string) uint64 {
+	if input != "" && input != "latest" {
+		return blockNumberOf(ctx, input)
+	}
+	header, err := c.Client().HeaderByNumber(ctx, nil)
+	cli.ErrCheck(err, quiet, "Failed to obtain latest block")
+	cli.Assert(header.Number.Uint64() > 0, quiet, "Chain has no state to obtain")
+
+	return header.Number.Uint64() - 1
+}
+
+// contractStorageDump obtains all non-zero storage of a contract at the end of the given block.
+func contractStorageDump(ctx context.Context, contractAddress common.Address, blockNumber uint64, pageSize int, handler func([]*util.StorageEntry) error) {
+	blockHash, txIndex := contractStorageState(ctx, blockNumber)
+	err := util.DumpStorage(ctx, c.Client().Client(), blockHash, txIndex, contractAddress, pageSize, handler)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain storage at block %d (the node requires the debug API and state for the block)", blockNumber))
+}
+
+// contractStorageSlotString returns the slot for a key if known, otherwise the key itself.
+func contractStorageSlotString(labeller *util.StorageLabeller, key common.Hash, slot *common.Hash) string {
+	if slot == nil {
+		slot = labeller.Slot(key)
+	}
+	if slot == nil {
+		return fmt.Sprintf("keccak %s", key.Hex())
+	}
+
+	return slot.Hex()
+}
+
 func init() {
 	contractCmd.AddCommand(contractStorageCmd)
 	contractFlags(contractStorageCmd)
 	contractStorageCmd.Flags().StringVar(&contractStorageKey, "key", "", "Storage key")
+	contractStorageCmd.Flags().StringVar(&contractStorageVariable, "variable", "", "Variable to obtain, for example balances[0x…] or config.owner (requires a storage layout)")
+	contractStorageCmd.Flags().StringVar(&contractStorageLayout, "storage-layout", "", "Storage layout, or path to storage layout, as output by solc (if not in the contract JSON)")
+	addStateFlags(contractStorageCmd)
 }
@@ -0,0 +1,102 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+)
+
+var (
+	contractStorageDiffFromBlock string
+	contractStorageDiffToBlock   string
+)
+
+// contractStorageDiffCmd represents the contract storage diff command.
+var contractStorageDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show changes to a contract's storage between blocks",
+	Long: `Show the slots of a contract's storage that changed between the end of one block and the end of another.  For example:
+
+   ethereal contract storage diff --contract=0xd26114cd6EE289AccF82350c8d8487fedB8A0C07 --from-block=19000000 --to-block=19000100
+
+This requires a node that supports debug_storageRangeAt and holds state for both blocks.  If no to block is supplied then the block before the latest is used, as the state at the end of the latest block is not available until the following block is produced.
+
+Slots are labelled as for "contract storage dump".
+
+In quiet mode this will return 0 if any slots changed, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(!offline, quiet, "Cannot obtain storage offline")
+		cli.Assert(contractStr != "", quiet, "--contract is required")
+		contractAddress, err := c.Resolve(contractStr)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", contractStr))
+		cli.Assert(contractStorageDiffFromBlock != "", quiet, "--from-block is required")
+
+		labeller, err := util.NewStorageLabeller(contractStorageParseLayout())
+		cli.ErrCheck(err, quiet, "Failed to label storage layout")
+
+		ctx, cancel := localContext()
+		fromBlock := contractStorageBlock(ctx, contractStorageDiffFromBlock)
+		toBlock := contractStorageBlock(ctx, contractStorageDiffToBlock)
+		cancel()
+		cli.Assert(fromBlock <= toBlock, quiet, "--from-block must not be after --to-block")
+		outputIf(verbose, fmt.Sprintf("Comparing storage at the end of block %d with the end of block %d", fromBlock, toBlock))
+
+		before := contractStorageEntries(contractAddress, fromBlock)
+		after := contractStorageEntries(contractAddress, toBlock)
+		changes := util.DiffStorage(before, after)
+
+		if quiet {
+			if len(changes) > 0 {
+				os.Exit(exitSuccess)
+			}
+			os.Exit(exitFailure)
+		}
+
+		for _, change := range changes {
+			slot := contractStorageSlotString(labeller, change.Key, change.Slot)
+			if label := labeller.Label(change.Key); label != "" {
+				fmt.Printf("%s: %s -> %s (%s)\n", slot, change.From.Hex(), change.To.Hex(), label)
+			} else {
+				fmt.Printf("%s: %s -> %s\n", slot, change.From.Hex(), change.To.Hex())
+			}
+		}
+	},
+}
+
+// contractStorageEntries returns all non-zero storage of a contract at the end of the given block.
+func contractStorageEntries(contractAddress common.Address, blockNumber uint64) []*util.StorageEntry {
+	entries := make([]*util.StorageEntry, 0)
+	contractStorageDump(context.Background(), contractAddress, blockNumber, contractStoragePageSize, func(page []*util.StorageEntry) error {
+		entries = append(entries, page...)
+		return nil
+	})
+
+	return entries
+}
+
+func init() {
+	contractStorageCmd.AddCommand(contractStorageDiffCmd)
+	contractFlags(contractStorageDiffCmd)
+	contractStorageDiffCmd.Flags().StringVar(&contractStorageDiffFromBlock, "from-block", "", "Block at the end of which to start the comparison")
+	contractStorageDiffCmd.Flags().StringVar(&contractStorageDiffToBlock, "to-block", "", "Block at the end of which to finish the comparison (default the block before the latest)")
+	contractStorageDiffCmd.Flags().IntVar(&contractStoragePageSize, "page-size", 1024, "Number of slots to obtain in each request")
+	contractStorageDiffCmd.Flags().StringVar(&contractStorageLayout, "storage-layout", "", "Storage layout, or path to storage layout, as output by solc (if not in the contract JSON)")
+}
@@ -0,0 +1,90 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+)
+
+var (
+	contractStorageDumpBlock string
+	contractStoragePageSize  int
+)
+
+// contractStorageDumpCmd represents the contract storage dump command.
+var contractStorageDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Dump a contract's storage",
+	Long: `Dump all non-zero slots of a contract's storage at the end of a block.  For example:
+
+   ethereal contract storage dump --contract=0xd26114cd6EE289AccF82350c8d8487fedB8A0C07 --block=19000000
+
+This requires a node that supports debug_storageRangeAt and holds state for the block.  If no block is supplied then the block before the latest is used, as the state at the end of the latest block is not available until the following block is produced.
+
+Slots are labelled with EIP-1967 proxy slots and, if a storage layout is supplied, with the names of variables.  Nodes identify slots by their hashes; if a node does not know a slot and it cannot be found from the layout then its hash is shown instead.
+
+In quiet mode this will return 0 if the contract has any non-zero storage, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(!offline, quiet, "Cannot obtain storage offline")
+		cli.Assert(contractStr != "", quiet, "--contract is required")
+		contractAddress, err := c.Resolve(contractStr)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", contractStr))
+
+		labeller, err := util.NewStorageLabeller(contractStorageParseLayout())
+		cli.ErrCheck(err, quiet, "Failed to label storage layout")
+
+		ctx, cancel := localContext()
+		blockNumber := contractStorageBlock(ctx, contractStorageDumpBlock)
+		cancel()
+		outputIf(verbose, fmt.Sprintf("Dumping storage at the end of block %d", blockNumber))
+
+		found := false
+		contractStorageDump(context.Background(), contractAddress, blockNumber, contractStoragePageSize, func(entries []*util.StorageEntry) error {
+			for _, entry := range entries {
+				found = true
+				if quiet {
+					continue
+				}
+				slot := contractStorageSlotString(labeller, entry.Key, entry.Slot)
+				if label := labeller.Label(entry.Key); label != "" {
+					fmt.Printf("%s: %s (%s)\n", slot, entry.Value.Hex(), label)
+				} else {
+					fmt.Printf("%s: %s\n", slot, entry.Value.Hex())
+				}
+			}
+			return nil
+		})
+
+		if quiet {
+			if found {
+				os.Exit(exitSuccess)
+			}
+			os.Exit(exitFailure)
+		}
+	},
+}
+
+func init() {
+	contractStorageCmd.AddCommand(contractStorageDumpCmd)
+	contractFlags(contractStorageDumpCmd)
+	contractStorageDumpCmd.Flags().StringVar(&contractStorageDumpBlock, "block", "", "Block at the end of which to dump storage: number, hash, safe or finalized (default the block before the latest)")
+	contractStorageDumpCmd.Flags().IntVar(&contractStoragePageSize, "page-size", 1024, "Number of slots to obtain in each request")
+	contractStorageDumpCmd.Flags().StringVar(&contractStorageLayout, "storage-layout", "", "Storage layout, or path to storage layout, as output by solc (if not in the contract JSON)")
+}
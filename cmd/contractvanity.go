@@ -0,0 +1,89 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+)
+
+var (
+	contractVanityPrefix        string
+	contractVanitySuffix        string
+	contractVanityCaseSensitive bool
+	contractVanityWorkers       int
+	contractVanityFactory       string
+	contractVanityData          string
+	contractVanityInitCodeHash  string
+	contractVanityConstructor   string
+	contractVanityLinks         []string
+)
+
+// contractVanityCmd represents the contract vanity command.
+var contractVanityCmd = &cobra.Command{
+	Use:   "vanity",
+	Short: "Find a CREATE2 salt for a vanity contract address",
+	Long: `Find a salt with which a contract deployed with CREATE2 will have an address that starts and/or ends with the given hex characters.  For example:
+
+    ethereal contract vanity --prefix=0x1234 --init-code-hash=0x2f4d...
+
+The init code is supplied in the same way as for 'contract address'.  The default factory is the deterministic deployment proxy used by 'contract deploy --create2', and the salt found can be passed to that command with --salt.
+
+Salts are searched on all CPU cores until one matches.  The throughput and expected time to find a match are reported before mining in earnest; each additional character makes the search 16 times longer.
+
+In quiet mode this will return 0 if a salt is found, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		pattern, err := util.NewVanityPattern(contractVanityPrefix, contractVanitySuffix, contractVanityCaseSensitive)
+		cli.ErrCheck(err, quiet, "Invalid pattern")
+		factory := create2Factory(contractVanityFactory)
+		initCodeHash := contractInitCodeHash(contractVanityInitCodeHash, contractVanityData, contractVanityConstructor, contractVanityLinks)
+		outputIf(verbose, fmt.Sprintf("Init code hash is %s", initCodeHash.Hex()))
+
+		var salt [32]byte
+		var address common.Address
+		runVanityMiner(pattern, contractVanityWorkers, func(ctx context.Context, attempts *atomic.Uint64) error {
+			salt, address, err = util.MineVanitySalt(ctx, pattern, factory, initCodeHash, contractVanityWorkers, attempts)
+			return err
+		})
+
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+		fmt.Printf("Salt:\t\t%#x\n", salt)
+		fmt.Printf("Address:\t%s\n", address.Hex())
+	},
+}
+
+func init() {
+	offlineCmds["contract:vanity"] = true
+	contractCmd.AddCommand(contractVanityCmd)
+	contractFlags(contractVanityCmd)
+	contractVanityCmd.Flags().StringVar(&contractVanityPrefix, "prefix", "", "Hex characters with which the address must start")
+	contractVanityCmd.Flags().StringVar(&contractVanitySuffix, "suffix", "", "Hex characters with which the address must end")
+	contractVanityCmd.Flags().BoolVar(&contractVanityCaseSensitive, "case-sensitive", false, "Match the case of letters against the checksummed address")
+	contractVanityCmd.Flags().IntVar(&contractVanityWorkers, "workers", runtime.NumCPU(), "Number of CPU cores to use")
+	contractVanityCmd.Flags().StringVar(&contractVanityFactory, "factory", "", "Address of the factory (defaults to the deterministic deployment proxy)")
+	contractVanityCmd.Flags().StringVar(&contractVanityData, "data", "", "Contract data (as a hex string)")
+	contractVanityCmd.Flags().StringVar(&contractVanityInitCodeHash, "init-code-hash", "", "Hash of the init code (as a hex string)")
+	contractVanityCmd.Flags().StringVar(&contractVanityConstructor, "constructor", "", "Constructor invocation (if required)")
+	contractVanityCmd.Flags().StringSliceVar(&contractVanityLinks, "link", nil, "Library to link, as name=address (can be supplied multiple times)")
+}
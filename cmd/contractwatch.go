@@ -0,0 +1,100 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+	"github.com/wealdtech/ethereal/v2/util/txdata"
+)
+
+var contractWatchPollInterval time.Duration
+
+// contractWatchCmd represents the contract watch command.
+var contractWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch events emitted by a contract",
+	Long: `Watch events emitted by a contract as they happen, until interrupted.  For example:
+
+    ethereal contract watch --contract=0x6B175474E89094C44Da98b954EedeAC495271d0F --event="Transfer(address indexed src,address indexed dst,uint256 wad)" --filter=dst=0x5FfC014343cd971B7eb70732021E26C35B744cc4
+
+The event and filters are as for "contract events".  If neither an event nor the ABI of the contract is supplied then all events are watched, and decoded with the stored ABI of the contract or where their signatures are otherwise known.
+
+If the connection supports subscriptions then they are used, otherwise the node is polled for changes every --poll-interval.  Failed connections are retried until interrupted, and events emitted while disconnected are reported on reconnection.  Events removed by a chain reorganisation are reported as such.
+
+Output is in text by default, or can be JSON lines with --format=jsonl.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(!offline, quiet, "Cannot watch events offline")
+		cli.Assert(contractStr != "", quiet, "--contract is required")
+		cli.Assert(contractEventsFormat == "text" || contractEventsFormat == "jsonl", quiet, fmt.Sprintf("Unknown format %s; should be text or jsonl", contractEventsFormat))
+		contractAddress, err := c.Resolve(contractStr)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve contract address %s", contractStr))
+
+		var events map[common.Hash]*abi.Event
+		var topics [][]common.Hash
+		if contractEventsEvent != "" || contractAbi != "" || contractJSON != "" || contractFollowProxy {
+			events = contractEventsEvents(contractAddress)
+			topics = contractEventsTopics(events)
+		} else {
+			cli.Assert(len(contractEventsFilters) == 0, quiet, "--filter requires --event")
+			if storedAbi, _ := storedContractABI(contractAddress, false); storedAbi != nil {
+				events = contractABIEvents(storedAbi, "")
+			}
+		}
+
+		ctx, cancel := interruptContext()
+		defer cancel()
+
+		txdata.UseSignatureDBLoader(signatureDB)
+		writer := newContractEventsWriter(contractEventsFormat, events)
+		outputIf(verbose, fmt.Sprintf("Watching events from %s", contractAddress.Hex()))
+		err = newWatcher(contractWatchPollInterval).WatchLogs(ctx, ethereum.FilterQuery{
+			Addresses: []common.Address{contractAddress},
+			Topics:    topics,
+		}, func(log *types.Log) error {
+			if quiet || len(log.Topics) == 0 {
+				return nil
+			}
+			event, exists := events[log.Topics[0]]
+			if !exists {
+				return writer.writeUndecoded(log, txdata.EventToString(c.Client(), log))
+			}
+			args, err := util.DecodeEvent(event, log)
+			if err != nil {
+				cli.Warn(quiet, fmt.Sprintf("Failed to decode log %d in block %d: %v", log.Index, log.BlockNumber, err))
+				return nil
+			}
+			return writer.write(log, event, args)
+		})
+		cli.ErrCheck(err, quiet, "Failed to watch events")
+	},
+}
+
+func init() {
+	contractCmd.AddCommand(contractWatchCmd)
+	contractFlags(contractWatchCmd)
+	contractWatchCmd.Flags().StringVar(&contractEventsEvent, "event", "", "Event signature, or name if the ABI is supplied")
+	contractWatchCmd.Flags().StringArrayVar(&contractEventsFilters, "filter", nil, "Filter on an indexed parameter, as name=value (can be supplied multiple times)")
+	contractWatchCmd.Flags().StringVar(&contractEventsFormat, "format", "text", "Output format (text or jsonl)")
+	contractWatchCmd.Flags().DurationVar(&contractWatchPollInterval, "poll-interval", 4*time.Second, "Interval at which to poll for events if the connection does not support subscriptions")
+	contractWatchCmd.Flags().BoolVar(&contractFollowProxy, "follow-proxy", false, "Use the stored ABI of the implementation if the contract is a proxy and no ABI is supplied")
+}
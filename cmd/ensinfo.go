@@ -17,12 +17,18 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cobra"
 	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
 	ens "github.com/wealdtech/go-ens/v3"
+	"github.com/wealdtech/go-ens/v3/contracts/registry"
+	"github.com/wealdtech/go-ens/v3/contracts/resolver"
 	string2eth "github.com/wealdtech/go-string2eth"
 )
 
@@ -36,6 +42,8 @@ var ensInfoCmd = &cobra.Command{
 
     ens info --domain=enstest.eth
 
+The registration, controller, resolver and records at an earlier point can be obtained with --block or --at-time.
+
 In quiet mode this will return 0 if the domain is owned, otherwise 1.`,
 
 	Run: func(cmd *cobra.Command, args []string) {
@@ -57,25 +65,36 @@ In quiet mode this will return 0 if the domain is owned, otherwise 1.`,
 		cli.ErrCheck(err, quiet, "Failed to obtain label hash of ENS domain")
 		outputIf(verbose, fmt.Sprintf("Label hash of %s is 0x%x", label, labelHash))
 
+		ctx, cancel := localContext()
+		defer cancel()
+		opts := &bind.CallOpts{
+			Context:     ctx,
+			BlockNumber: stateBlockNumber(ctx),
+		}
+
 		if ens.DomainLevel(ensDomain) == 1 && ens.Tld(ensDomain) == "eth" {
 			// Work out if this is on the old or new .eth registrar and act accordingly.
 			registrar, err := ens.NewBaseRegistrar(c.Client(), ens.Tld(ensDomain))
 			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain ENS registrar contract for %s", ens.Tld(ensDomain)))
 			outputIf(debug, fmt.Sprintf("Registrar address is %#x", registrar.ContractAddr))
 
-			domain, err := ens.DomainPart(ensDomain, 1)
-			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain domain part for %s", ensDomain))
-			registrant, err := registrar.Owner(domain)
+			tokenID := new(big.Int).SetBytes(labelHash[:])
+			registrant, err := registrar.Contract.OwnerOf(opts, tokenID)
 			if err != nil {
-				if err.Error() == "abi: attempting to unmarshall an empty string while arguments are expected" {
+				switch err.Error() {
+				case "abi: attempting to unmarshall an empty string while arguments are expected":
 					fmt.Println("Name not recognised by registrar")
 					os.Exit(exitFailure)
+				case "execution reverted":
+					// Registrar reverts rather than provide a 0 owner.
+					registrant = ens.UnknownAddress
+				default:
+					stateErrCheck(err, "Failed to obtain registrant")
 				}
-				cli.ErrCheck(err, quiet, "Failed to obtain registrant")
 			}
 			if registrant == ens.UnknownAddress {
 				fmt.Println("Name not recognised by registrar")
-				unregisteredResolverCheck(ensDomain)
+				unregisteredResolverCheck(ensDomain, opts)
 				os.Exit(exitFailure)
 			}
 
@@ -86,8 +105,8 @@ In quiet mode this will return 0 if the domain is owned, otherwise 1.`,
 			} else {
 				fmt.Printf("Registrant is %s (%s)\n", registrantName, registrant.Hex())
 			}
-			expiry, err := registrar.Expiry(domain)
-			cli.ErrCheck(err, quiet, "Failed to obtain expiry")
+			expiry, err := registrar.Contract.NameExpires(opts, tokenID)
+			stateErrCheck(err, "Failed to obtain expiry")
 			fmt.Printf("Registration expires at %v\n", time.Unix(int64(expiry.Uint64()), 0))
 
 			controller, err := ens.NewETHController(c.Client(), ens.Domain(ensDomain))
@@ -110,7 +129,7 @@ In quiet mode this will return 0 if the domain is owned, otherwise 1.`,
 				}
 				fmt.Printf("Deed value is %s; release with 'ethereal ens release'\n", string2eth.WeiToString(entry.Value, true))
 			}
-			genericInfo(ensDomain)
+			genericInfo(ensDomain, opts.BlockNumber)
 		}
 	},
 }
@@ -118,13 +137,18 @@ In quiet mode this will return 0 if the domain is owned, otherwise 1.`,
 func init() {
 	ensCmd.AddCommand(ensInfoCmd)
 	ensFlags(ensInfoCmd)
+	addStateFlags(ensInfoCmd)
 }
 
 // It is possible for an unregistered domain to have a resolver; report if this is the case.
-func unregisteredResolverCheck(domain string) {
+func unregisteredResolverCheck(domain string, opts *bind.CallOpts) {
 	registry, err := ens.NewRegistry(c.Client())
 	cli.ErrCheck(err, quiet, "Failed to obtain registry contract")
-	resolverAddress, err := registry.ResolverAddress(domain)
+	nameHash, err := ens.NameHash(domain)
+	if err != nil {
+		return
+	}
+	resolverAddress, err := registry.Contract.Resolver(opts, nameHash)
 	if err != nil {
 		return
 	}
@@ -139,13 +163,28 @@ a malicious part could register the domain and change the resolution.`)
 	}
 }
 
-// genericInfo prints generic info about any ENS domain.
+// genericInfo prints generic info about any ENS domain at the given block,
+// or the latest block if nil.
 // It returns true if the domain exists, otherwise false.
-func genericInfo(name string) bool {
-	registry, err := ens.NewRegistry(c.Client())
+func genericInfo(name string, blockNumber *big.Int) bool {
+	nameHash, err := ens.NameHash(name)
+	cli.ErrCheck(err, quiet, "Failed to obtain name hash of ENS domain")
+	registryAddress, err := ens.RegistryContractAddress(c.Client())
 	cli.ErrCheck(err, quiet, "Failed to obtain registry contract")
-	controllerAddress, err := registry.Owner(ensDomain)
-	cli.ErrCheck(err, quiet, "Failed to obtain controller")
+	registryAbi, err := abi.JSON(strings.NewReader(registry.ContractABI))
+	cli.ErrCheck(err, quiet, "Failed to parse registry ABI")
+
+	ctx, cancel := localContext()
+	defer cancel()
+	registryCalls := []*util.ABICall{
+		{Target: registryAddress, ABI: &registryAbi, Method: "owner", Args: []interface{}{nameHash}},
+		{Target: registryAddress, ABI: &registryAbi, Method: "resolver", Args: []interface{}{nameHash}},
+	}
+	err = util.MulticallABI(ctx, c.Client(), common.Address{}, blockNumber, registryCalls)
+	stateErrCheck(err, "Failed to call registry")
+
+	cli.ErrCheck(registryCalls[0].Err, quiet, "Failed to obtain controller")
+	controllerAddress := registryCalls[0].Outputs[0].(common.Address)
 	if controllerAddress == ens.UnknownAddress {
 		fmt.Println("Owner not set")
 		return false
@@ -158,11 +197,11 @@ func genericInfo(name string) bool {
 	}
 
 	// Resolver.
-	resolverAddress, err := registry.ResolverAddress(name)
-	if err != nil || resolverAddress == ens.UnknownAddress {
+	if registryCalls[1].Err != nil || registryCalls[1].Outputs[0].(common.Address) == ens.UnknownAddress {
 		fmt.Println("Resolver not configured")
 		return true
 	}
+	resolverAddress := registryCalls[1].Outputs[0].(common.Address)
 	resolverName, _ := c.ReverseResolve(resolverAddress)
 	if resolverName == "" {
 		fmt.Printf("Resolver is %s\n", resolverAddress.Hex())
@@ -170,22 +209,32 @@ func genericInfo(name string) bool {
 		fmt.Printf("Resolver is %s (%s)\n", resolverName, resolverAddress.Hex())
 	}
 
+	resolverAbi, err := abi.JSON(strings.NewReader(resolver.ContractABI))
+	cli.ErrCheck(err, quiet, "Failed to parse resolver ABI")
+	resolverCalls := []*util.ABICall{
+		{Target: resolverAddress, ABI: &resolverAbi, Method: "addr", Args: []interface{}{nameHash}},
+		{Target: resolverAddress, ABI: &resolverAbi, Method: "contenthash", Args: []interface{}{nameHash}},
+	}
+	err = util.MulticallABI(ctx, c.Client(), common.Address{}, blockNumber, resolverCalls)
+	stateErrCheck(err, "Failed to call resolver")
+
 	// Address.
-	address, err := c.Resolve(name)
-	if err == nil && address != ens.UnknownAddress {
-		fmt.Printf("Domain resolves to %s\n", address.Hex())
-		// Reverse resolution.
-		reverseDomain, err := c.ReverseResolve(address)
-		if err == nil && reverseDomain != "" {
-			fmt.Printf("Address resolves to %s\n", reverseDomain)
+	if resolverCalls[0].Err == nil {
+		address := resolverCalls[0].Outputs[0].(common.Address)
+		if address != ens.UnknownAddress {
+			fmt.Printf("Domain resolves to %s\n", address.Hex())
+			// Reverse resolution.
+			reverseDomain, err := c.ReverseResolve(address)
+			if err == nil && reverseDomain != "" {
+				fmt.Printf("Address resolves to %s\n", reverseDomain)
+			}
 		}
 	}
 
 	// Content hash.
-	resolver, err := ens.NewResolverAt(c.Client(), name, resolverAddress)
-	if err == nil {
-		bytes, err := resolver.Contenthash()
-		if err == nil && len(bytes) > 0 {
+	if resolverCalls[1].Err == nil {
+		bytes := resolverCalls[1].Outputs[0].([]byte)
+		if len(bytes) > 0 {
 			contentHash, err := ens.ContenthashToString(bytes)
 			if err == nil {
 				fmt.Printf("Content hash is %v\n", contentHash)
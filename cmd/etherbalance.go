@@ -17,9 +17,7 @@ import (
 	"fmt"
 	"math/big"
 	"os"
-	"strings"
 
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cobra"
 	"github.com/wealdtech/ethereal/v2/cli"
 	string2eth "github.com/wealdtech/go-string2eth"
@@ -27,7 +25,6 @@ import (
 
 var (
 	etherBalanceAddress string
-	etherBalanceBlock   string
 	etherBalanceWei     bool
 )
 
@@ -39,33 +36,20 @@ var etherBalanceCmd = &cobra.Command{
 
     ethereal ether balance --address=0x5FfC014343cd971B7eb70732021E26C35B744cc4
 
+The balance at an earlier point can be obtained with --block or --at-time, for example:
+
+    ethereal ether balance --address=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --at-time=2024-01-01T00:00:00Z
+
 In quiet mode this will return 0 if the balance is greater than 0, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(etherBalanceAddress != "", quiet, "--address is required")
 		address, err := c.Resolve(etherBalanceAddress)
 		cli.ErrCheck(err, quiet, "Failed to obtain address")
 
-		var blockNumber *big.Int
-		if etherBalanceBlock != "" {
-			if blockInfoNumberRegexp.MatchString(etherBalanceBlock) {
-				var succeeded bool
-				blockNumber, succeeded = big.NewInt(0).SetString(etherBalanceBlock, 10)
-				cli.Assert(succeeded, quiet, fmt.Sprintf("Failed to parse block number %s", etherBalanceBlock))
-			} else {
-				blockHash := common.HexToHash(etherBalanceBlock)
-				ctx, cancel := localContext()
-				defer cancel()
-				block, err := c.Client().BlockByHash(ctx, blockHash)
-				cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain block %s", etherBalanceBlock))
-				blockNumber = block.Number()
-			}
-		}
-
 		ctx, cancel := localContext()
 		defer cancel()
-		balance, err := c.Client().BalanceAt(ctx, address, blockNumber)
-		cli.Assert(err == nil || !strings.HasPrefix(err.Error(), "missing trie node"), quiet, "Connection does not have information on that block, please change the connection parameter to point to a full synced node")
-		cli.ErrCheck(err, quiet, "Failed to obtain balance")
+		balance, err := c.Client().BalanceAt(ctx, address, stateBlockNumber(ctx))
+		stateErrCheck(err, "Failed to obtain balance")
 
 		if balance.Cmp(big.NewInt(0)) == 0 {
 			outputIf(!quiet, "0")
@@ -87,5 +71,5 @@ func init() {
 	etherCmd.AddCommand(etherBalanceCmd)
 	etherBalanceCmd.Flags().BoolVar(&etherBalanceWei, "wei", false, "Display output in number of Wei")
 	etherBalanceCmd.Flags().StringVar(&etherBalanceAddress, "address", "", "Address to show Ether balance")
-	etherBalanceCmd.Flags().StringVar(&etherBalanceBlock, "block", "", "block hash or number at which to show Ether balance (must be run against an archive node)")
+	addStateFlags(etherBalanceCmd)
 }
@@ -0,0 +1,80 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util/slip39"
+)
+
+var (
+	hdCombineShares          string
+	hdCombineSharePassphrase string
+)
+
+// hdCombineCmd represents the hd combine command.
+var hdCombineCmd = &cobra.Command{
+	Use:   "combine",
+	Short: "Combine SLIP-39 shares in to a seed",
+	Long: `Combine SLIP-39 Shamir shares to recover a BIP-39 seed phrase.  For example:
+
+    ethereal hd combine --shares="academic acid ... ; academic always ..."
+
+The shares can be supplied either as a semicolon-separated list or as the path to a file containing one share per line.
+
+In quiet mode this will return 0 if the seed was successfully recovered, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(hdCombineShares != "", quiet, "--shares is required")
+
+		var shares []string
+		if data, err := os.ReadFile(hdCombineShares); err == nil {
+			shares = strings.Split(string(data), "\n")
+		} else {
+			shares = strings.Split(hdCombineShares, ";")
+		}
+		mnemonics := make([]string, 0, len(shares))
+		for _, share := range shares {
+			share = strings.Join(strings.Fields(share), " ")
+			if share != "" {
+				mnemonics = append(mnemonics, share)
+			}
+		}
+		cli.Assert(len(mnemonics) > 0, quiet, "No shares supplied")
+
+		entropy, err := slip39.CombineMnemonics(mnemonics, []byte(hdCombineSharePassphrase))
+		cli.ErrCheck(err, quiet, "Failed to combine shares")
+		mnemonic, err := bip39.NewMnemonic(entropy)
+		cli.ErrCheck(err, quiet, "Failed to generate seed phrase")
+
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+
+		fmt.Printf("Seed:\t%s\n", mnemonic)
+		os.Exit(exitSuccess)
+	},
+}
+
+func init() {
+	offlineCmds["hd:combine"] = true
+	hdCmd.AddCommand(hdCombineCmd)
+	hdCombineCmd.Flags().StringVar(&hdCombineShares, "shares", "", "semicolon-separated list of shares, or path to a file containing one share per line")
+	hdCombineCmd.Flags().StringVar(&hdCombineSharePassphrase, "share-passphrase", "", "passphrase used to encrypt the shares")
+}
@@ -0,0 +1,86 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+)
+
+var (
+	hdCreateWords  int
+	hdCreateSecret string
+	hdCreatePath   string
+)
+
+// hdCreateCmd represents the hd create command.
+var hdCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a new seed",
+	Long: `Create a new BIP-39 seed phrase.  For example:
+
+    ethereal hd create --words=24
+
+If a secret is supplied it will be used along with the seed phrase to generate the Ethereum address for the supplied path, which is displayed for verification purposes.
+
+In quiet mode this will return 0 if the seed was successfully created, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var entropyBits int
+		switch hdCreateWords {
+		case 12:
+			entropyBits = 128
+		case 24:
+			entropyBits = 256
+		default:
+			cli.Err(quiet, "--words must be 12 or 24")
+		}
+
+		entropy, err := bip39.NewEntropy(entropyBits)
+		cli.ErrCheck(err, quiet, "Failed to generate entropy")
+		mnemonic, err := bip39.NewMnemonic(entropy)
+		cli.ErrCheck(err, quiet, "Failed to generate seed phrase")
+
+		path, err := util.ParseHDPath(hdCreatePath)
+		cli.ErrCheck(err, quiet, "Invalid path")
+		seed, err := bip39.NewSeedWithErrorChecking(mnemonic, hdCreateSecret)
+		cli.ErrCheck(err, quiet, "Failed to obtain seed from mnemonic")
+		key, err := util.HDPrivateKey(seed, path)
+		cli.ErrCheck(err, quiet, "Failed to obtain private key")
+
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+
+		fmt.Printf("Seed:\t\t\t%s\n", mnemonic)
+		if verbose || hdCreateSecret != "" {
+			fmt.Printf("Path:\t\t\t%s\n", hdCreatePath)
+			fmt.Printf("Ethereum address:\t%s\n", crypto.PubkeyToAddress(key.PublicKey).Hex())
+		}
+		os.Exit(exitSuccess)
+	},
+}
+
+func init() {
+	offlineCmds["hd:create"] = true
+	hdCmd.AddCommand(hdCreateCmd)
+	hdCreateCmd.Flags().IntVar(&hdCreateWords, "words", 24, "number of words in the seed phrase (12 or 24)")
+	hdCreateCmd.Flags().StringVar(&hdCreateSecret, "secret", "", "optional secret to add to seed")
+	hdCreateCmd.Flags().StringVar(&hdCreatePath, "path", "m/44'/60'/0'/0/0", "path for the verification address")
+}
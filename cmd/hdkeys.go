@@ -17,15 +17,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
-	"strconv"
-	"strings"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/spf13/cobra"
-	bip32 "github.com/tyler-smith/go-bip32"
 	bip39 "github.com/tyler-smith/go-bip39"
 	"github.com/wealdtech/ethereal/v2/cli"
-	"golang.org/x/text/unicode/norm"
+	"github.com/wealdtech/ethereal/v2/util"
 )
 
 var (
@@ -46,35 +43,14 @@ In quiet mode this will return 0 if the keys were successfully obtained, otherwi
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(hdKeysMnemonic != "", quiet, "seed is required")
 
-		components := strings.Split(hdKeysPath, "/")
-		cli.Assert(len(components) > 3, quiet, "Invalid path")
-		path := make([]uint32, len(components)-1)
-		for i := 0; i < len(components)-1; i++ {
-			if strings.HasSuffix(components[i+1], "'") {
-				unhardened, err := strconv.ParseInt(components[i+1][:len(components[i+1])-1], 10, 0)
-				cli.ErrCheck(err, quiet, fmt.Sprintf("Invalid path component %v", components[i+1]))
-				path[i] = 0x80000000 + uint32(unhardened)
-			} else {
-				unhardened, err := strconv.ParseInt(components[i+1], 10, 0)
-				cli.ErrCheck(err, quiet, fmt.Sprintf("Invalid path component %v", components[i+1]))
-				path[i] = uint32(unhardened)
-			}
-		}
+		path, err := util.ParseHDPath(hdKeysPath)
+		cli.ErrCheck(err, quiet, "Invalid path")
 
-		seed, err := bip39.NewSeedWithErrorChecking(expandMnemonic(hdKeysMnemonic), hdKeysSecret)
+		seed, err := bip39.NewSeedWithErrorChecking(util.ExpandMnemonic(hdKeysMnemonic), hdKeysSecret)
 		cli.ErrCheck(err, quiet, "Failed to obtain seed from mnemonic")
 
-		masterKey, err := bip32.NewMasterKey(seed)
-		cli.ErrCheck(err, quiet, "Failed to obtain master key from seed")
-
-		childKey := masterKey
-		for i := range path {
-			childKey, err = childKey.NewChildKey(path[i])
-			cli.ErrCheck(err, quiet, fmt.Sprintf("failed to derive child key from path component %d (%x)", i, path[i]))
-		}
-
-		key, err := crypto.ToECDSA(childKey.Key)
-		cli.ErrCheck(err, quiet, "Failed to obtain private key from master key")
+		key, err := util.HDPrivateKey(seed, path)
+		cli.ErrCheck(err, quiet, "Failed to obtain private key")
 
 		outputIf(!quiet, fmt.Sprintf("Private key:\t\t0x%032x", key.D))
 		outputIf(!quiet, fmt.Sprintf("Public key:\t\t0x%s", hex.EncodeToString(crypto.FromECDSAPub(&key.PublicKey))))
@@ -84,35 +60,6 @@ In quiet mode this will return 0 if the keys were successfully obtained, otherwi
 	},
 }
 
-// expandMnmenonic expands mnemonics from their 4-letter versions.
-func expandMnemonic(input string) string {
-	wordList := bip39.GetWordList()
-	truncatedWords := make(map[string]string, len(wordList))
-	for _, word := range wordList {
-		if len(word) > 4 {
-			truncatedWords[firstFour(word)] = word
-		}
-	}
-	mnemonicWords := strings.Split(input, " ")
-	for i := range mnemonicWords {
-		if fullWord, exists := truncatedWords[norm.NFKC.String(mnemonicWords[i])]; exists {
-			mnemonicWords[i] = fullWord
-		}
-	}
-	return strings.Join(mnemonicWords, " ")
-}
-
-// firstFour provides the first four letters for a potentially longer word.
-func firstFour(s string) string {
-	// Use NFKC here for composition, to avoid accents counting as their own characters.
-	s = norm.NFKC.String(s)
-	r := []rune(s)
-	if len(r) > 4 {
-		return string(r[:4])
-	}
-	return s
-}
-
 func init() {
 	offlineCmds["hd:keys"] = true
 	hdCmd.AddCommand(hdKeysCmd)
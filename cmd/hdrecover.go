@@ -0,0 +1,99 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+)
+
+var (
+	hdRecoverMnemonic string
+	hdRecoverSecret   string
+	hdRecoverAddress  string
+	hdRecoverPaths    string
+	hdRecoverSwaps    bool
+	hdRecoverWorkers  int
+)
+
+// hdRecoverCmd represents the hd recover command.
+var hdRecoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Recover a partially-known seed",
+	Long: `Recover a partially-known seed given an address that it generates.  For example:
+
+    ethereal hd recover --seed="yellow ? yellow yellow|year yellow yellow yellow yellow yellow yellow yellow yellow" --address=0xA27DF20E6579aC472481F0Ea918165d24bFb713b
+
+Unknown words are given as '?', and uncertain words as a list of candidates separated by '|'.  Words may be supplied in their 4-letter abbreviated form.  If --swaps is supplied then seeds with any two words exchanged are also checked.
+
+Every combination of words with a valid checksum is checked against each of the supplied paths, so each unknown word increases the time taken by a factor of 2048.
+
+In quiet mode this will return 0 if the seed was recovered, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(hdRecoverMnemonic != "", quiet, "--seed is required")
+		cli.Assert(hdRecoverAddress != "", quiet, "--address is required")
+		cli.Assert(common.IsHexAddress(hdRecoverAddress), quiet, "Invalid address")
+		address := common.HexToAddress(hdRecoverAddress)
+
+		template, err := util.ParseMnemonicTemplate(hdRecoverMnemonic)
+		cli.ErrCheck(err, quiet, "Invalid seed")
+
+		pathStrs := strings.Split(hdRecoverPaths, ",")
+		paths := make([][]uint32, len(pathStrs))
+		for i := range pathStrs {
+			pathStrs[i] = strings.TrimSpace(pathStrs[i])
+			paths[i], err = util.ParseHDPath(pathStrs[i])
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Invalid path %s", pathStrs[i]))
+		}
+
+		combinations, err := template.Combinations(hdRecoverSwaps)
+		cli.ErrCheck(err, quiet, "Search space is too large")
+		outputIf(verbose, fmt.Sprintf("Checking %d combinations with %d workers", combinations, hdRecoverWorkers))
+
+		started := time.Now()
+		res, err := util.RecoverMnemonic(context.Background(), template, hdRecoverSwaps, hdRecoverSecret, paths, address, hdRecoverWorkers)
+		cli.ErrCheck(err, quiet, "Failed to recover seed")
+		outputIf(verbose, fmt.Sprintf("Search took %v", time.Since(started).Round(time.Millisecond)))
+		cli.Assert(res != nil, quiet, "No matching seed found")
+
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+
+		fmt.Printf("Seed:\t\t\t%s\n", res.Mnemonic)
+		fmt.Printf("Path:\t\t\t%s\n", pathStrs[res.PathIndex])
+		fmt.Printf("Ethereum address:\t%s\n", address.Hex())
+		os.Exit(exitSuccess)
+	},
+}
+
+func init() {
+	offlineCmds["hd:recover"] = true
+	hdCmd.AddCommand(hdRecoverCmd)
+	hdRecoverCmd.Flags().StringVar(&hdRecoverMnemonic, "seed", "", "partially-known BIP-39 seed phrase, with '?' for unknown words and '|' between candidate words")
+	hdRecoverCmd.Flags().StringVar(&hdRecoverSecret, "secret", "", "optional secret to add to seed")
+	hdRecoverCmd.Flags().StringVar(&hdRecoverAddress, "address", "", "address generated by the seed")
+	hdRecoverCmd.Flags().StringVar(&hdRecoverPaths, "paths", "m/44'/60'/0'/0/0", "comma-separated list of paths to check for the address")
+	hdRecoverCmd.Flags().BoolVar(&hdRecoverSwaps, "swaps", false, "also check seeds with any two words exchanged")
+	hdRecoverCmd.Flags().IntVar(&hdRecoverWorkers, "workers", runtime.NumCPU(), "number of parallel workers")
+}
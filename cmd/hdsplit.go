@@ -0,0 +1,129 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+	"github.com/wealdtech/ethereal/v2/util/slip39"
+)
+
+var (
+	hdSplitMnemonic          string
+	hdSplitThreshold         int
+	hdSplitCount             int
+	hdSplitGroups            string
+	hdSplitGroupThreshold    int
+	hdSplitSharePassphrase   string
+	hdSplitExtendable        bool
+	hdSplitIterationExponent int
+)
+
+// hdSplitCmd represents the hd split command.
+var hdSplitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Split a seed in to SLIP-39 shares",
+	Long: `Split a BIP-39 seed phrase in to SLIP-39 Shamir shares.  For example:
+
+    ethereal hd split --seed="yellow yellow ... yellow" --threshold=2 --count=3
+
+Multi-level sharing is available by supplying groups of threshold/count pairs, along with the number of groups required to recover the seed.  For example:
+
+    ethereal hd split --seed="yellow yellow ... yellow" --groups="1/1,2/3,3/5" --group-threshold=2
+
+The shares contain the entropy of the seed phrase, so the seed phrase is recovered with 'ethereal hd combine'.  Any secret used alongside the seed phrase is not part of the shares and must be stored separately.
+
+In quiet mode this will return 0 if the shares were successfully created, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(hdSplitMnemonic != "", quiet, "--seed is required")
+
+		entropy, err := bip39.EntropyFromMnemonic(util.ExpandMnemonic(hdSplitMnemonic))
+		cli.ErrCheck(err, quiet, "Invalid seed")
+
+		groupThreshold := hdSplitGroupThreshold
+		var groups []*slip39.Group
+		if hdSplitGroups != "" {
+			groups, err = parseSLIP39Groups(hdSplitGroups)
+			cli.ErrCheck(err, quiet, "Invalid groups")
+		} else {
+			cli.Assert(hdSplitThreshold > 0, quiet, "--threshold is required")
+			cli.Assert(hdSplitCount > 0, quiet, "--count is required")
+			groups = []*slip39.Group{{MemberThreshold: hdSplitThreshold, MemberCount: hdSplitCount}}
+			groupThreshold = 1
+		}
+
+		shares, err := slip39.GenerateMnemonics(groupThreshold, groups, entropy, []byte(hdSplitSharePassphrase), hdSplitExtendable, hdSplitIterationExponent)
+		cli.ErrCheck(err, quiet, "Failed to split seed")
+
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+
+		if len(groups) > 1 {
+			fmt.Printf("%d of %d groups required to recover the seed\n", groupThreshold, len(groups))
+		}
+		for i, groupShares := range shares {
+			if len(groups) > 1 {
+				fmt.Printf("Group %d (%d of %d shares required):\n", i+1, groups[i].MemberThreshold, groups[i].MemberCount)
+			} else {
+				fmt.Printf("%d of %d shares required to recover the seed\n", groups[i].MemberThreshold, groups[i].MemberCount)
+			}
+			for j, share := range groupShares {
+				fmt.Printf("Share %d:\t%s\n", j+1, share)
+			}
+		}
+		os.Exit(exitSuccess)
+	},
+}
+
+// parseSLIP39Groups parses a comma-separated list of threshold/count pairs.
+func parseSLIP39Groups(input string) ([]*slip39.Group, error) {
+	res := make([]*slip39.Group, 0)
+	for _, groupStr := range strings.Split(input, ",") {
+		bits := strings.Split(strings.TrimSpace(groupStr), "/")
+		if len(bits) != 2 {
+			return nil, fmt.Errorf("group %q must be of the form threshold/count", groupStr)
+		}
+		threshold, err := strconv.Atoi(bits[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold in group %q", groupStr)
+		}
+		count, err := strconv.Atoi(bits[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid count in group %q", groupStr)
+		}
+		res = append(res, &slip39.Group{MemberThreshold: threshold, MemberCount: count})
+	}
+	return res, nil
+}
+
+func init() {
+	offlineCmds["hd:split"] = true
+	hdCmd.AddCommand(hdSplitCmd)
+	hdSplitCmd.Flags().StringVar(&hdSplitMnemonic, "seed", "", "12- or 24-word BIP-39 seed phrase")
+	hdSplitCmd.Flags().IntVar(&hdSplitThreshold, "threshold", 0, "number of shares required to recover the seed")
+	hdSplitCmd.Flags().IntVar(&hdSplitCount, "count", 0, "number of shares to create")
+	hdSplitCmd.Flags().StringVar(&hdSplitGroups, "groups", "", "comma-separated list of threshold/count groups (e.g. 1/1,2/3); overrides threshold and count")
+	hdSplitCmd.Flags().IntVar(&hdSplitGroupThreshold, "group-threshold", 1, "number of groups required to recover the seed")
+	hdSplitCmd.Flags().StringVar(&hdSplitSharePassphrase, "share-passphrase", "", "optional passphrase to encrypt the shares")
+	hdSplitCmd.Flags().BoolVar(&hdSplitExtendable, "extendable", true, "create shares that allow additional shares to be created for the same seed")
+	hdSplitCmd.Flags().IntVar(&hdSplitIterationExponent, "iteration-exponent", 1, "exponent for the number of PBKDF2 iterations used when encrypting the shares")
+}
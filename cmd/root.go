@@ -14,19 +14,24 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"crypto/ecdsa"
 	"encoding/hex"
 	"fmt"
 	"math/big"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -36,6 +41,7 @@ import (
 	"github.com/wealdtech/ethereal/v2/conn"
 	"github.com/wealdtech/ethereal/v2/util"
 	string2eth "github.com/wealdtech/go-string2eth"
+	"golang.org/x/term"
 )
 
 var (
@@ -49,6 +55,10 @@ var (
 // c is the connection to the execution node.
 var c *conn.Conn
 
+// onlineConn is the connection to the execution node for commands that run
+// offline but need the node for some inputs, created on demand.
+var onlineConn *conn.Conn
+
 // signer is the signer for the execution node.
 var signer types.Signer
 
@@ -100,12 +110,25 @@ func persistentPreRun(cmd *cobra.Command, _ []string) {
 	if cmd.Flags().Lookup("passphrase") != nil {
 		cli.ErrCheck(viper.BindPFlag("passphrase", cmd.Flags().Lookup("passphrase")), quiet, "failed to bind flag")
 	}
+	for _, flag := range []string{"passphrase-file", "passphrase-env", "privatekey-file"} {
+		if cmd.Flags().Lookup(flag) != nil {
+			cli.ErrCheck(viper.BindPFlag(flag, cmd.Flags().Lookup(flag)), quiet, "failed to bind flag")
+		}
+	}
 	if cmd.Flags().Lookup("address") != nil {
 		cli.ErrCheck(viper.BindPFlag("address", cmd.Flags().Lookup("address")), quiet, "failed to bind flag")
 	}
 	if cmd.Flags().Lookup("privatekey") != nil {
 		cli.ErrCheck(viper.BindPFlag("privatekey", cmd.Flags().Lookup("privatekey")), quiet, "failed to bind flag")
 	}
+	if cmd.Flags().Lookup("seed-file") != nil {
+		// Seed flags are only bound for commands that sign with them, to avoid clashing with the hd commands.
+		cli.ErrCheck(viper.BindPFlag("seed", cmd.Flags().Lookup("seed")), quiet, "failed to bind flag")
+		cli.ErrCheck(viper.BindPFlag("seed-file", cmd.Flags().Lookup("seed-file")), quiet, "failed to bind flag")
+		cli.ErrCheck(viper.BindPFlag("seed-secret", cmd.Flags().Lookup("seed-secret")), quiet, "failed to bind flag")
+		cli.ErrCheck(viper.BindPFlag("seed-path", cmd.Flags().Lookup("path")), quiet, "failed to bind flag")
+		cli.ErrCheck(viper.BindPFlag("seed-index", cmd.Flags().Lookup("index")), quiet, "failed to bind flag")
+	}
 	if cmd.Flags().Lookup("value") != nil {
 		cli.ErrCheck(viper.BindPFlag("value", cmd.Flags().Lookup("value")), quiet, "failed to bind flag")
 	}
@@ -187,6 +210,46 @@ func connect(ctx context.Context) error {
 	return nil
 }
 
+// onlineConnection returns a connection to the execution node.  Commands that
+// can be run offline use it when an input requires the node, for example a
+// name to resolve.  It returns an error if --offline was supplied.
+func onlineConnection(ctx context.Context) (*conn.Conn, error) {
+	if !offline {
+		return c, nil
+	}
+	if viper.GetBool("offline") {
+		return nil, errors.New("a connection to an Ethereum node is required, but --offline was supplied")
+	}
+	if onlineConn == nil {
+		address, err := connectionAddress(ctx)
+		if err != nil {
+			return nil, err
+		}
+		onlineConn, err = conn.New(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return onlineConn, nil
+}
+
+// resolveName resolves a name or address.  Commands that can be run
+// offline only connect to the execution node if the input is a name.
+func resolveName(input string) (common.Address, error) {
+	if !offline || common.IsHexAddress(input) {
+		return c.Resolve(input)
+	}
+	ctx, cancel := localContext()
+	defer cancel()
+	connection, err := onlineConnection(ctx)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return connection.Resolve(input)
+}
+
 // connectionAddress provides the address of an execution client.
 func connectionAddress(_ context.Context) (string, error) {
 	if viper.GetString("connection") != "" {
@@ -382,8 +445,8 @@ func initConfig() {
 
 // Add flags for commands that carry out transactions.
 func addTransactionFlags(cmd *cobra.Command, explanation string) {
-	cmd.Flags().String("passphrase", "", fmt.Sprintf("passphrase for %s", explanation))
-	cmd.Flags().String("privatekey", "", fmt.Sprintf("private key for %s", explanation))
+	addSecretFlags(cmd, explanation)
+	addSeedFlags(cmd, explanation)
 	cmd.Flags().String("max-fee-per-gas", "200Gwei", "Maximum fee per gas for transaction e.g. 15Gwei, 0.000000015ether")
 	cmd.Flags().String("priority-fee-per-gas", "1.5Gwei", "Priority fee per gas for transaction e.g. 1gwei")
 	cmd.Flags().String("value", "", "Ether to send with the transaction")
@@ -395,22 +458,107 @@ func addTransactionFlags(cmd *cobra.Command, explanation string) {
 	cmd.Flags().Duration("limit", 0, "maximum time to wait for transaction to complete before failing (default forever)")
 }
 
+// addSecretFlags adds the flags to supply the passphrase or private key for signing.
+func addSecretFlags(cmd *cobra.Command, explanation string) {
+	cmd.Flags().String("passphrase", "", fmt.Sprintf("passphrase for %s; \"-\" to prompt", explanation))
+	cmd.Flags().String("passphrase-file", "", fmt.Sprintf("file containing the passphrase for %s", explanation))
+	cmd.Flags().String("passphrase-env", "", fmt.Sprintf("name of the environment variable containing the passphrase for %s", explanation))
+	cmd.Flags().String("privatekey", "", fmt.Sprintf("private key for %s; \"-\" to prompt", explanation))
+	cmd.Flags().String("privatekey-file", "", fmt.Sprintf("file containing the private key for %s", explanation))
+}
+
+// addSeedFlags adds the flags to sign with a key derived from a seed phrase.
+func addSeedFlags(cmd *cobra.Command, explanation string) {
+	cmd.Flags().String("seed", "", fmt.Sprintf("BIP-39 seed phrase from which to derive the key for %s", explanation))
+	cmd.Flags().String("seed-file", "", fmt.Sprintf("file containing the BIP-39 seed phrase from which to derive the key for %s", explanation))
+	cmd.Flags().String("seed-secret", "", "optional BIP-39 secret (passphrase) added to the seed phrase")
+	cmd.Flags().String("path", "", "path from which to derive the key from the seed phrase (e.g. m/44'/60'/0'/0/0)")
+	cmd.Flags().Uint32("index", 0, "index of the key derived from the seed phrase, using the path m/44'/60'/0'/0/<index>; ignored if path is supplied")
+}
+
+// obtainSigningKey obtains the private key for a signer from the supplied passphrase, private key or seed.
+// If the signer is supplied then the key must be for the signer.
+func obtainSigningKey(signer common.Address) (*ecdsa.PrivateKey, error) {
+	passphrase, err := cli.Passphrase()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain passphrase")
+	}
+	privateKey, err := cli.PrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain private key")
+	}
+
+	switch {
+	case passphrase != "":
+		key, err := util.PrivateKeyForAccount(c.ChainID(), signer, passphrase)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid account or passphrase")
+		}
+		return key, nil
+	case privateKey != "":
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid private key")
+		}
+		if err := checkKeySigner(key, signer); err != nil {
+			return nil, err
+		}
+		return key, nil
+	default:
+		key, err := util.SeedKey()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain key from seed")
+		}
+		if key == nil {
+			return nil, errors.New("no passphrase, private key or seed; cannot sign")
+		}
+		if err := checkKeySigner(key, signer); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+}
+
+// checkKeySigner checks that a key is for the signer, if supplied.
+func checkKeySigner(key *ecdsa.PrivateKey, signer common.Address) error {
+	if signer == (common.Address{}) {
+		return nil
+	}
+	if keyAddress := crypto.PubkeyToAddress(key.PublicKey); keyAddress != signer {
+		return fmt.Errorf("key is for %s, not %s", keyAddress.Hex(), signer.Hex())
+	}
+
+	return nil
+}
+
 func generateTxOpts(sender common.Address) (*bind.TransactOpts, error) {
 	// Signer depends on what information is available to us.
 	var signer bind.SignerFn
-	if viper.GetString("passphrase") != "" {
+	passphrase, err := cli.Passphrase()
+	cli.ErrCheck(err, quiet, "Failed to obtain passphrase")
+	privateKey, err := cli.PrivateKey()
+	cli.ErrCheck(err, quiet, "Failed to obtain private key")
+	if passphrase != "" {
 		wallet, account, err := cli.ObtainWalletAndAccount(c.ChainID(), sender)
 		if err != nil {
 			return nil, err
 		}
-		signer = util.AccountSigner(c.ChainID(), &wallet, account, viper.GetString("passphrase"))
-	} else if viper.GetString("privatekey") != "" {
-		key, err := crypto.HexToECDSA(strings.TrimPrefix(viper.GetString("privatekey"), "0x"))
+		signer = util.AccountSigner(c.ChainID(), &wallet, account, passphrase)
+	} else if privateKey != "" {
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
 		cli.ErrCheck(err, quiet, "Invalid private key")
+		cli.ErrCheck(checkKeySigner(key, sender), quiet, "Private key does not match sender")
 		signer = util.KeySigner(c.ChainID(), key)
+	} else {
+		key, err := util.SeedKey()
+		cli.ErrCheck(err, quiet, "Failed to obtain key from seed")
+		if key != nil {
+			cli.ErrCheck(checkKeySigner(key, sender), quiet, "Seed key does not match sender")
+			signer = util.KeySigner(c.ChainID(), key)
+		}
 	}
 	if signer == nil {
-		return nil, fmt.Errorf("no signer; please supply either passphrase or private key")
+		return nil, fmt.Errorf("no signer; please supply either passphrase, private key or seed")
 	}
 
 	var value *big.Int
@@ -454,10 +602,40 @@ func outputIf(condition bool, msg string) {
 	}
 }
 
+// obtainConfirmation asks the user to type the given response to confirm an
+// action, exiting if it is not supplied.  flag is the flag that confirms the
+// action non-interactively.
+func obtainConfirmation(prompt string, response string, flag string) {
+	cli.Assert(term.IsTerminal(int(os.Stdin.Fd())), quiet, fmt.Sprintf("--%s is required to %s non-interactively", flag, prompt))
+	fmt.Fprintf(os.Stderr, "Type '%s' to %s: ", response, prompt)
+	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	cli.ErrCheck(err, quiet, "Failed to obtain confirmation")
+	cli.Assert(strings.TrimSpace(input) == response, quiet, "Not confirmed")
+}
+
 func localContext() (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), viper.GetDuration("timeout"))
 }
 
+// interruptContext returns a context that is cancelled when the process is interrupted.
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// newWatcher returns a watcher on the connection, which polls at the given
+// interval if the connection does not support subscriptions.
+func newWatcher(pollInterval time.Duration) *util.Watcher {
+	return util.NewWatcher(func(ctx context.Context) (*rpc.Client, error) {
+		address, err := connectionAddress(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return rpc.DialContext(ctx, address)
+	}, pollInterval, func(err error) {
+		cli.Warn(quiet, fmt.Sprintf("Connection failed, reconnecting: %v", err))
+	})
+}
+
 func calculateFees() (*big.Int, *big.Int, error) {
 	baseFeePerGas, err := c.CurrentBaseFee(context.Background())
 	if err != nil {
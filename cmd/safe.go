@@ -0,0 +1,117 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/util/contracts"
+	"github.com/wealdtech/ethereal/v2/util/safe"
+	"github.com/wealdtech/ethereal/v2/util/txdata"
+	ens "github.com/wealdtech/go-ens/v3"
+	string2eth "github.com/wealdtech/go-string2eth"
+)
+
+var safeStr string
+
+// safeCmd represents the safe command.
+var safeCmd = &cobra.Command{
+	Use:   "safe",
+	Short: "Manage Safe multisig transactions",
+	Long:  `Obtain information about Safe multisig contracts, and propose, sign and execute their transactions.`,
+}
+
+func init() {
+	RootCmd.AddCommand(safeCmd)
+}
+
+func safeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&safeStr, "safe", "", "Name or address of the Safe")
+}
+
+// safeContract obtains the Safe contract given its name or address.
+func safeContract(input string) (common.Address, *contracts.Safe, error) {
+	address, err := c.Resolve(input)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	contract, err := contracts.NewSafe(address, c.Client())
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return address, contract, nil
+}
+
+// readSafeTransaction reads a proposed Safe transaction from a file.
+func readSafeTransaction(path string) (*safe.Transaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tx := &safe.Transaction{}
+	if err := json.Unmarshal(data, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// writeSafeJSON writes Safe data as JSON to a file, or to stdout if no file is supplied.
+func writeSafeJSON(path string, item interface{}) error {
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// outputSafeTransaction outputs the details of a Safe transaction, with each line prefixed.
+// Names and data are only decoded when online.
+func outputSafeTransaction(prefix string, tx *safe.Transaction) {
+	if c.Client() == nil {
+		fmt.Printf("%sTo:\t\t\t%v\n", prefix, tx.To.Hex())
+	} else {
+		fmt.Printf("%sTo:\t\t\t%v\n", prefix, ens.Format(c.Client(), tx.To))
+	}
+	fmt.Printf("%sValue:\t\t\t%v\n", prefix, string2eth.WeiToString(tx.Value, true))
+	if tx.Operation == safe.OperationDelegateCall {
+		fmt.Printf("%sOperation:\t\tDelegate call\n", prefix)
+	} else {
+		fmt.Printf("%sOperation:\t\tCall\n", prefix)
+	}
+	switch {
+	case len(tx.Data) == 0:
+	case c.Client() == nil:
+		fmt.Printf("%sData:\t\t\t%#x\n", prefix, tx.Data)
+	default:
+		txdata.InitFunctionMap()
+		txdata.UseSignatureDBLoader(signatureDB)
+		fmt.Printf("%sData:\t\t\t%v\n", prefix, txdata.DataToString(c.Client(), tx.Data))
+	}
+	if verbose {
+		fmt.Printf("%sSafe transaction gas:\t%v\n", prefix, tx.SafeTxGas)
+		fmt.Printf("%sBase gas:\t\t%v\n", prefix, tx.BaseGas)
+		fmt.Printf("%sGas price:\t\t%v\n", prefix, tx.GasPrice)
+		fmt.Printf("%sGas token:\t\t%v\n", prefix, tx.GasToken.Hex())
+		fmt.Printf("%sRefund receiver:\t%v\n", prefix, tx.RefundReceiver.Hex())
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util/safe"
+)
+
+var safeDecodeData string
+
+// safeDecodeCmd represents the safe decode command.
+var safeDecodeCmd = &cobra.Command{
+	Use:   "decode",
+	Short: "Decode a Safe transaction",
+	Long: `Decode the data of a call to a Safe's execTransaction function.  For example:
+
+    ethereal safe decode --data=0x6a761202...
+
+In quiet mode this will return 0 if the data is a valid Safe transaction, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(safeDecodeData != "", quiet, "--data is required")
+		data, err := hex.DecodeString(strings.TrimPrefix(safeDecodeData, "0x"))
+		cli.ErrCheck(err, quiet, "Failed to parse data")
+		cli.Assert(safe.IsExecTransaction(data), quiet, "Data is not a Safe transaction")
+		tx, signatures, err := safe.DecodeExecTransaction(data)
+		cli.ErrCheck(err, quiet, "Failed to decode Safe transaction")
+
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+
+		outputSafeTransaction("", tx)
+		parts := safe.SplitSignatures(signatures)
+		fmt.Printf("Signatures:\t\t%d\n", len(parts))
+		if verbose {
+			for i, part := range parts {
+				switch v := part[64]; {
+				case v == 0:
+					fmt.Printf("\t%d:\tContract signature from %s\n", i, common.BytesToAddress(part[:32]).Hex())
+				case v == 1:
+					fmt.Printf("\t%d:\tApproved hash from %s\n", i, common.BytesToAddress(part[:32]).Hex())
+				case v > 30:
+					fmt.Printf("\t%d:\tMessage signature %#x\n", i, part)
+				default:
+					fmt.Printf("\t%d:\tSignature %#x\n", i, part)
+				}
+			}
+		}
+	},
+}
+
+func init() {
+	safeCmd.AddCommand(safeDecodeCmd)
+	safeDecodeCmd.Flags().StringVar(&safeDecodeData, "data", "", "Data of the execTransaction call (as a hex string)")
+}
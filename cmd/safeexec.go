@@ -0,0 +1,160 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/conn"
+	"github.com/wealdtech/ethereal/v2/util/safe"
+)
+
+var (
+	safeExecProposal    string
+	safeExecSignatures  string
+	safeExecFromAddress string
+)
+
+// safeExecCmd represents the safe exec command.
+var safeExecCmd = &cobra.Command{
+	Use:   "exec",
+	Short: "Execute a Safe transaction",
+	Long: `Execute a proposed Safe transaction given sufficient owner signatures.  For example:
+
+    ethereal safe exec --proposal=proposal.json --signatures=signature-1.json,signature-2.json --from=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --passphrase=secret
+
+If the address executing the transaction is an owner of the Safe then it does not need to supply a signature of its own.  Owners that have approved the transaction on-chain are also included automatically.
+
+This will return an exit status of 0 if the transaction is successfully submitted (and mined if --wait is supplied), 1 if the transaction is not successfully submitted, and 2 if the transaction is successfully submitted but not mined within the supplied time limit.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(safeExecFromAddress != "", quiet, "--from is required")
+		fromAddress, err := c.Resolve(safeExecFromAddress)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve from address %s", safeExecFromAddress))
+
+		cli.Assert(safeExecProposal != "", quiet, "--proposal is required")
+		tx, err := readSafeTransaction(safeExecProposal)
+		cli.ErrCheck(err, quiet, "Failed to read proposal")
+		hash, err := tx.Hash()
+		cli.ErrCheck(err, quiet, "Failed to calculate Safe transaction hash")
+		cli.Assert(tx.ChainID.Cmp(c.ChainID()) == 0, quiet, fmt.Sprintf("Proposal is for chain %v but connected to chain %v", tx.ChainID, c.ChainID()))
+
+		_, contract, err := safeContract(tx.Safe.Hex())
+		cli.ErrCheck(err, quiet, "Failed to obtain Safe contract")
+		nonce, err := contract.Nonce(nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain nonce")
+		cli.Assert(nonce.Cmp(tx.Nonce) == 0, quiet, fmt.Sprintf("Proposal is for nonce %v but Safe is at nonce %v", tx.Nonce, nonce))
+		owners, err := contract.GetOwners(nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain owners")
+		threshold, err := contract.GetThreshold(nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain threshold")
+		isOwner := make(map[common.Address]bool, len(owners))
+		for _, owner := range owners {
+			isOwner[owner] = true
+		}
+
+		signatures := make([]*safe.Signature, 0)
+		signed := make(map[common.Address]bool)
+		if safeExecSignatures != "" {
+			for _, path := range strings.Split(safeExecSignatures, ",") {
+				data, err := os.ReadFile(strings.TrimSpace(path))
+				cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to read signature %s", path))
+				signature := &safe.Signature{}
+				cli.ErrCheck(json.Unmarshal(data, signature), quiet, fmt.Sprintf("Invalid signature %s", path))
+				cli.Assert(signature.SafeTxHash == hash, quiet, fmt.Sprintf("Signature %s is for a different transaction", path))
+				cli.Assert(isOwner[signature.Signer], quiet, fmt.Sprintf("Signature %s is from %s, which is not an owner", path, signature.Signer.Hex()))
+				if signed[signature.Signer] {
+					outputIf(verbose, fmt.Sprintf("Ignoring duplicate signature from %s", signature.Signer.Hex()))
+					continue
+				}
+				signatures = append(signatures, signature)
+				signed[signature.Signer] = true
+			}
+		}
+
+		// Add owners that do not require a signature.
+		for _, owner := range owners {
+			if signed[owner] {
+				continue
+			}
+			approved := owner == fromAddress
+			if !approved {
+				approval, err := contract.ApprovedHashes(nil, owner, hash)
+				cli.ErrCheck(err, quiet, "Failed to obtain on-chain approvals")
+				approved = approval.Sign() != 0
+			}
+			if approved {
+				outputIf(verbose, fmt.Sprintf("Including approval from %s", owner.Hex()))
+				signatures = append(signatures, safe.ApprovedHashSignature(hash, owner))
+				signed[owner] = true
+			}
+		}
+		cli.Assert(threshold.Cmp(common.Big0) > 0 && int64(len(signatures)) >= threshold.Int64(), quiet, fmt.Sprintf("Safe requires %v signatures but only %d available", threshold, len(signatures)))
+
+		data, err := safe.ExecTransactionData(tx, signatures)
+		cli.ErrCheck(err, quiet, "Failed to create execTransaction data")
+		outputIf(verbose, fmt.Sprintf("Data is %x", data))
+
+		var gasLimit *uint64
+		limit := uint64(viper.GetInt64("gaslimit"))
+		if limit > 0 {
+			gasLimit = &limit
+		}
+
+		signedTx, err := c.CreateSignedTransaction(context.Background(), &conn.TransactionData{
+			From:     fromAddress,
+			To:       &tx.Safe,
+			Value:    big.NewInt(0),
+			GasLimit: gasLimit,
+			Data:     data,
+		})
+		cli.ErrCheck(err, quiet, "Failed to create Safe transaction")
+
+		if offline {
+			if !quiet {
+				buf := new(bytes.Buffer)
+				cli.ErrCheck(signedTx.EncodeRLP(buf), quiet, "failed to encode transaction")
+				fmt.Printf("0x%s\n", hex.EncodeToString(buf.Bytes()))
+			}
+			os.Exit(exitSuccess)
+		}
+		err = c.SendTransaction(context.Background(), signedTx)
+		cli.ErrCheck(err, quiet, "Failed to send transaction")
+		handleSubmittedTransaction(signedTx, log.Fields{
+			"group":   "safe",
+			"command": "exec",
+			"safe":    tx.Safe.Hex(),
+			"safeTx":  hash.Hex(),
+		}, false)
+	},
+}
+
+func init() {
+	safeCmd.AddCommand(safeExecCmd)
+	safeExecCmd.Flags().StringVar(&safeExecProposal, "proposal", "", "File containing the proposal, as created by 'safe propose'")
+	safeExecCmd.Flags().StringVar(&safeExecSignatures, "signatures", "", "Comma-separated list of files containing signatures, as created by 'safe sign'")
+	safeExecCmd.Flags().StringVar(&safeExecFromAddress, "from", "", "Address from which to execute the transaction")
+	addTransactionFlags(safeExecCmd, "the address from which to execute the transaction")
+}
@@ -0,0 +1,91 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	ens "github.com/wealdtech/go-ens/v3"
+)
+
+// safeModulesSentinel is the start and end marker of a Safe's linked list of modules.
+var safeModulesSentinel = common.HexToAddress("0x01")
+
+// safeInfoCmd represents the safe info command.
+var safeInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Obtain information about a Safe",
+	Long: `Obtain information about a Safe multisig contract.  For example:
+
+    ethereal safe info --safe=0x5FfC014343cd971B7eb70732021E26C35B744cc4
+
+In quiet mode this will return 0 if the Safe exists, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(safeStr != "", quiet, "--safe is required")
+		address, contract, err := safeContract(safeStr)
+		cli.ErrCheck(err, quiet, "Failed to obtain Safe contract")
+
+		version, err := contract.VERSION(nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain Safe version; is this a Safe?")
+		owners, err := contract.GetOwners(nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain owners")
+		threshold, err := contract.GetThreshold(nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain threshold")
+		nonce, err := contract.Nonce(nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain nonce")
+
+		modules := make([]common.Address, 0)
+		start := safeModulesSentinel
+		for {
+			page, err := contract.GetModulesPaginated(nil, start, big.NewInt(50))
+			cli.ErrCheck(err, quiet, "Failed to obtain modules")
+			modules = append(modules, page.Array...)
+			if page.Next == safeModulesSentinel || page.Next == (common.Address{}) || len(page.Array) == 0 {
+				break
+			}
+			start = page.Next
+		}
+
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+
+		if verbose {
+			fmt.Printf("Address:\t%s\n", address.Hex())
+		}
+		fmt.Printf("Version:\t%s\n", version)
+		fmt.Printf("Threshold:\t%v of %d\n", threshold, len(owners))
+		fmt.Printf("Owners:\n")
+		for _, owner := range owners {
+			fmt.Printf("\t%s\n", ens.Format(c.Client(), owner))
+		}
+		fmt.Printf("Nonce:\t\t%v\n", nonce)
+		if len(modules) > 0 {
+			fmt.Printf("Modules:\n")
+			for _, module := range modules {
+				fmt.Printf("\t%s\n", ens.Format(c.Client(), module))
+			}
+		}
+	},
+}
+
+func init() {
+	safeCmd.AddCommand(safeInfoCmd)
+	safeFlags(safeInfoCmd)
+}
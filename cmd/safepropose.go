@@ -0,0 +1,151 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util/funcparser"
+	"github.com/wealdtech/ethereal/v2/util/safe"
+	string2eth "github.com/wealdtech/go-string2eth"
+)
+
+var (
+	safeProposeToAddress string
+	safeProposeAmount    string
+	safeProposeData      string
+	safeProposeCall      string
+	safeProposeOperation string
+	safeProposeNonce     string
+	safeProposeSafeTxGas uint64
+	safeProposeOutput    string
+)
+
+// safeProposeCmd represents the safe propose command.
+var safeProposeCmd = &cobra.Command{
+	Use:   "propose",
+	Short: "Propose a Safe transaction",
+	Long: `Propose a transaction to be executed by a Safe.  For example:
+
+    ethereal safe propose --safe=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --to=0x2ab7150Bba7D5F181b3aF5623e52b15bB1054845 --amount=1ether --output=proposal.json
+
+Contract methods can be called in the same way as 'contract send', for example:
+
+    ethereal safe propose --safe=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --contract=0xd26114cd6EE289AccF82350c8d8487fedB8A0C07 --abi=./erc20.abi --call="transfer(0x2ab7150Bba7D5F181b3aF5623e52b15bB1054845, 10)" --output=proposal.json
+
+The proposal, including the Safe transaction hash to be signed by the owners, is written to the output file.  Owners sign the proposal with 'ethereal safe sign', and the transaction is executed with 'ethereal safe exec'.
+
+In quiet mode this will return 0 if the proposal is created, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(safeStr != "", quiet, "--safe is required")
+		safeAddress, contract, err := safeContract(safeStr)
+		cli.ErrCheck(err, quiet, "Failed to obtain Safe contract")
+
+		tx := &safe.Transaction{
+			Safe:      safeAddress,
+			ChainID:   c.ChainID(),
+			Value:     big.NewInt(0),
+			SafeTxGas: new(big.Int).SetUint64(safeProposeSafeTxGas),
+			BaseGas:   big.NewInt(0),
+			GasPrice:  big.NewInt(0),
+		}
+
+		tx.Version, err = contract.VERSION(nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain Safe version; is this a Safe?")
+
+		toStr := safeProposeToAddress
+		if toStr == "" {
+			toStr = contractStr
+		}
+		cli.Assert(toStr != "", quiet, "--to or --contract is required")
+		tx.To, err = c.Resolve(toStr)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve to address %s", toStr))
+
+		if safeProposeAmount != "" {
+			tx.Value, err = string2eth.StringToWei(safeProposeAmount)
+			cli.ErrCheck(err, quiet, "Invalid amount")
+		}
+
+		switch {
+		case safeProposeCall != "":
+			cli.Assert(safeProposeData == "", quiet, "only one of --call and --data can be supplied")
+			contract := parseContract("")
+			method, methodArgs, err := funcparser.ParseCall(c.Client(), contract, safeProposeCall)
+			cli.ErrCheck(err, quiet, "Failed to parse call")
+			tx.Data, err = contract.Abi.Pack(method.Name, methodArgs...)
+			cli.ErrCheck(err, quiet, "Failed to convert arguments")
+		case safeProposeData != "":
+			tx.Data, err = hex.DecodeString(strings.TrimPrefix(safeProposeData, "0x"))
+			cli.ErrCheck(err, quiet, "Failed to parse data")
+		}
+
+		switch strings.ToLower(safeProposeOperation) {
+		case "call":
+			tx.Operation = safe.OperationCall
+		case "delegatecall":
+			tx.Operation = safe.OperationDelegateCall
+		default:
+			cli.Err(quiet, "--operation must be call or delegatecall")
+		}
+
+		if safeProposeNonce != "" {
+			var ok bool
+			tx.Nonce, ok = new(big.Int).SetString(safeProposeNonce, 10)
+			cli.Assert(ok, quiet, "Invalid nonce")
+		} else {
+			tx.Nonce, err = contract.Nonce(nil)
+			cli.ErrCheck(err, quiet, "Failed to obtain nonce")
+		}
+
+		hash, err := tx.Hash()
+		cli.ErrCheck(err, quiet, "Failed to calculate Safe transaction hash")
+		// Cross-check our hash with that generated by the Safe itself.
+		safeHash, err := contract.GetTransactionHash(nil, tx.To, tx.Value, tx.Data, tx.Operation, tx.SafeTxGas, tx.BaseGas, tx.GasPrice, tx.GasToken, tx.RefundReceiver, tx.Nonce)
+		cli.ErrCheck(err, quiet, "Failed to obtain Safe transaction hash from Safe")
+		cli.Assert(hash == safeHash, quiet, fmt.Sprintf("Calculated Safe transaction hash %s does not match that from the Safe %#x", hash.Hex(), safeHash))
+
+		if verbose {
+			outputSafeTransaction("", tx)
+			fmt.Printf("Nonce:\t\t\t%v\n", tx.Nonce)
+			fmt.Printf("Safe transaction hash:\t%s\n", hash.Hex())
+		}
+
+		if quiet && safeProposeOutput == "" {
+			os.Exit(exitSuccess)
+		}
+		err = writeSafeJSON(safeProposeOutput, tx)
+		cli.ErrCheck(err, quiet, "Failed to write proposal")
+		os.Exit(exitSuccess)
+	},
+}
+
+func init() {
+	safeCmd.AddCommand(safeProposeCmd)
+	safeFlags(safeProposeCmd)
+	contractFlags(safeProposeCmd)
+	safeProposeCmd.Flags().StringVar(&safeProposeToAddress, "to", "", "Address to which the Safe sends the transaction (defaults to --contract)")
+	safeProposeCmd.Flags().StringVar(&safeProposeAmount, "amount", "", "Amount of Ether for the Safe to send with the transaction")
+	safeProposeCmd.Flags().StringVar(&safeProposeData, "data", "", "data for the transaction (as a hex string)")
+	safeProposeCmd.Flags().StringVar(&safeProposeCall, "call", "", "Contract function to call")
+	safeProposeCmd.Flags().StringVar(&safeProposeOperation, "operation", "call", "Operation for the Safe to carry out (call or delegatecall)")
+	safeProposeCmd.Flags().StringVar(&safeProposeNonce, "safe-nonce", "", "Safe nonce for the transaction (defaults to the current Safe nonce)")
+	safeProposeCmd.Flags().Uint64Var(&safeProposeSafeTxGas, "safe-tx-gas", 0, "Gas available to the Safe transaction; 0 for all available gas")
+	safeProposeCmd.Flags().StringVar(&safeProposeOutput, "output", "", "File to which to write the proposal (defaults to stdout)")
+}
@@ -0,0 +1,80 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util/safe"
+)
+
+var (
+	safeSignProposal string
+	safeSignSigner   string
+	safeSignOutput   string
+)
+
+// safeSignCmd represents the safe sign command.
+var safeSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign a Safe transaction",
+	Long: `Sign a proposed Safe transaction as an owner of the Safe.  For example:
+
+    ethereal safe sign --proposal=proposal.json --signer=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --passphrase=secret --output=signature-1.json
+
+The signature is written to the output file, to be passed to 'ethereal safe exec' along with those of the other owners.
+
+In quiet mode this will return 0 if the proposal is signed, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(safeSignProposal != "", quiet, "--proposal is required")
+		tx, err := readSafeTransaction(safeSignProposal)
+		cli.ErrCheck(err, quiet, "Failed to read proposal")
+		hash, err := tx.Hash()
+		cli.ErrCheck(err, quiet, "Failed to calculate Safe transaction hash")
+
+		key, err := obtainSigningKey(common.HexToAddress(safeSignSigner))
+		cli.ErrCheck(err, quiet, "Failed to obtain signing key")
+
+		if verbose {
+			fmt.Printf("Safe:\t\t\t%s\n", tx.Safe.Hex())
+			outputSafeTransaction("", tx)
+			fmt.Printf("Nonce:\t\t\t%v\n", tx.Nonce)
+			fmt.Printf("Safe transaction hash:\t%s\n", hash.Hex())
+		}
+
+		signature, err := safe.Sign(hash, key)
+		cli.ErrCheck(err, quiet, "Failed to sign Safe transaction")
+
+		if quiet && safeSignOutput == "" {
+			os.Exit(exitSuccess)
+		}
+		err = writeSafeJSON(safeSignOutput, signature)
+		cli.ErrCheck(err, quiet, "Failed to write signature")
+		os.Exit(exitSuccess)
+	},
+}
+
+func init() {
+	offlineCmds["safe:sign"] = true
+	safeCmd.AddCommand(safeSignCmd)
+	safeSignCmd.Flags().StringVar(&safeSignProposal, "proposal", "", "File containing the proposal, as created by 'safe propose'")
+	safeSignCmd.Flags().StringVar(&safeSignSigner, "signer", "", "Address of the owner signing the proposal")
+	safeSignCmd.Flags().StringVar(&safeSignOutput, "output", "", "File to which to write the signature (defaults to stdout)")
+	addSecretFlags(safeSignCmd, "signing the proposal")
+	addSeedFlags(safeSignCmd, "signing the proposal")
+}
@@ -20,17 +20,21 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/spf13/cobra"
 	"github.com/wealdtech/ethereal/v2/cli"
 	"github.com/wealdtech/ethereal/v2/util/funcparser"
+	"github.com/wealdtech/ethereal/v2/util/txdata"
 )
 
 var (
-	signatureDataStr string
-	signatureTypes   string
-	signatureNoHash  bool
-	signaturePacked  bool
+	signatureDataStr   string
+	signatureTypes     string
+	signatureNoHash    bool
+	signaturePacked    bool
+	signatureMode      string
+	signatureValidator string
 )
 
 // signatureCmd represents the signature command.
@@ -38,10 +42,27 @@ var signatureCmd = &cobra.Command{
 	Use:     "signature",
 	Aliases: []string{"sig"},
 	Short:   "Manage signatures",
-	Long:    `Sign and verify information.`,
+	Long:    `Sign and verify information, and look up function and event signatures.`,
 }
 
+// generateDataHash generates the hash to be signed, according to the signing mode.
 func generateDataHash() []byte {
+	switch signatureMode {
+	case "personal":
+		return personalDataHash()
+	case "intended-validator":
+		return intendedValidatorDataHash()
+	case "raw-digest":
+		return rawDigestDataHash()
+	default:
+		cli.Err(quiet, fmt.Sprintf("Unknown mode %s; must be one of personal, intended-validator or raw-digest", signatureMode))
+		return nil
+	}
+}
+
+// generateData generates the data to be signed from the data and types supplied,
+// hashing it if required.
+func generateData() []byte {
 	var data []byte
 	if signatureTypes == "" {
 		// No types; might be a hex string or a non-hex string.
@@ -96,6 +117,15 @@ func generateDataHash() []byte {
 		data = crypto.Keccak256(data)
 		outputIf(verbose, fmt.Sprintf("Hashed data is %x", data))
 	}
+
+	return data
+}
+
+// personalDataHash generates the hash of the data as per EIP-191 version 0x45,
+// as used by personal_sign.
+func personalDataHash() []byte {
+	cli.Assert(signatureValidator == "", quiet, "--validator is only used with intended-validator mode")
+	data := generateData()
 	buffer := make([]byte, 0)
 	buffer = append(buffer, []byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(data)))...)
 	buffer = append(buffer, data...)
@@ -103,6 +133,31 @@ func generateDataHash() []byte {
 	return crypto.Keccak256(buffer)
 }
 
+// intendedValidatorDataHash generates the hash of the data as per EIP-191
+// version 0x00, with an intended validator.
+func intendedValidatorDataHash() []byte {
+	cli.Assert(signatureValidator != "", quiet, "--validator is required for intended-validator mode")
+	cli.Assert(common.IsHexAddress(signatureValidator), quiet, "--validator must be an address")
+	validator := common.HexToAddress(signatureValidator)
+	data := generateData()
+	buffer := []byte{0x19, 0x00}
+	buffer = append(buffer, validator.Bytes()...)
+	buffer = append(buffer, data...)
+	outputIf(verbose, fmt.Sprintf("Data to sign is %x", buffer))
+	return crypto.Keccak256(buffer)
+}
+
+// rawDigestDataHash uses the data directly as the hash.
+func rawDigestDataHash() []byte {
+	cli.Assert(signatureValidator == "", quiet, "--validator is only used with intended-validator mode")
+	cli.Assert(signatureTypes == "", quiet, "--types cannot be used with raw-digest mode")
+	digest, err := hex.DecodeString(strings.TrimPrefix(signatureDataStr, "0x"))
+	cli.ErrCheck(err, quiet, "raw-digest mode requires data as a hex string")
+	cli.Assert(len(digest) == 32, quiet, "raw-digest mode requires data of 32 bytes")
+	outputIf(verbose, fmt.Sprintf("Data to sign is %x", digest))
+	return digest
+}
+
 func argumentsAndValues(items string, types string) (abi.Arguments, []interface{}) {
 	parser := csv.NewReader(strings.NewReader(items))
 	dataItems, err := parser.Read()
@@ -131,6 +186,16 @@ func argumentsAndValues(items string, types string) (abi.Arguments, []interface{
 	return arguments, vals
 }
 
+// signatureDB opens the local signature database.
+func signatureDB() *txdata.SignatureDB {
+	path, err := txdata.DefaultSignatureDBPath()
+	cli.ErrCheck(err, quiet, "Failed to locate the signature database")
+	db, err := txdata.OpenSignatureDB(path)
+	cli.ErrCheck(err, quiet, "Failed to open the signature database")
+
+	return db
+}
+
 func init() {
 	RootCmd.AddCommand(signatureCmd)
 }
@@ -140,4 +205,6 @@ func signatureFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&signatureTypes, "types", "", "Comma-separated list of data types")
 	cmd.Flags().BoolVar(&signatureNoHash, "nohash", false, "do not hash the message prior to signing")
 	cmd.Flags().BoolVar(&signaturePacked, "packed", false, "use Solidity packed encoding")
+	cmd.Flags().StringVar(&signatureMode, "mode", "personal", "signing mode: personal, intended-validator or raw-digest")
+	cmd.Flags().StringVar(&signatureValidator, "validator", "", "address of the intended validator, for intended-validator mode")
 }
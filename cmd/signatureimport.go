@@ -0,0 +1,69 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+)
+
+var signatureImportFiles []string
+
+// signatureImportCmd represents the signature import command.
+var signatureImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import function and event signatures",
+	Long: `Import function and event signatures in to the local signature database.  For example:
+
+    ethereal signature import --file=signatures.txt --file=out/Token.sol/Token.json
+
+Files can contain:
+  - text, with one signature per line, optionally preceded by its selector or topic
+  - a JSON dump from a signature directory such as 4byte.directory
+  - a contract ABI
+  - a Foundry or Hardhat artifact, or the output of solc --standard-json or --combined-json
+
+Where more than one signature has the same selector or topic they are ranked, with signatures from ABIs and artifacts ranked above those from signature directories.  Importing the same file again does not change the ranking of the signatures already imported from it.
+
+In quiet mode this will return 0 if the signatures are imported, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(len(signatureImportFiles) > 0, quiet, "--file is required")
+
+		db := signatureDB()
+		for _, file := range signatureImportFiles {
+			data, err := os.ReadFile(file)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to read %s", file))
+			source, err := filepath.Abs(file)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain path of %s", file))
+			result, err := db.Import(source, data)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to import signatures from %s", file))
+			outputIf(verbose, fmt.Sprintf("Imported %d function and %d event signatures from %s", result.Functions, result.Events, file))
+			if result.Skipped > 0 {
+				cli.Warn(quiet, fmt.Sprintf("Skipped %d invalid signatures in %s", result.Skipped, file))
+			}
+		}
+		cli.ErrCheck(db.Save(), quiet, "Failed to save the signature database")
+		os.Exit(exitSuccess)
+	},
+}
+
+func init() {
+	offlineCmds["signature:import"] = true
+	signatureCmd.AddCommand(signatureImportCmd)
+	signatureImportCmd.Flags().StringArrayVar(&signatureImportFiles, "file", nil, "File from which to import signatures (can be supplied multiple times)")
+}
@@ -0,0 +1,118 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util/txdata"
+)
+
+var (
+	signatureLookupSelector string
+	signatureLookupTopic    string
+	signatureLookupName     string
+)
+
+// signatureLookupCmd represents the signature lookup command.
+var signatureLookupCmd = &cobra.Command{
+	Use:   "lookup",
+	Short: "Look up function and event signatures",
+	Long: `Look up function signatures by selector, event signatures by topic, or either by name.  For example:
+
+    ethereal signature lookup --selector=0xa9059cbb
+
+    ethereal signature lookup --name=transfer
+
+Signatures are looked up in the local signature database, populated with 'signature import', and the built-in signatures.  If more than one signature matches a selector or topic they are listed with the most likely first.
+
+In quiet mode this will return 0 if any signatures are found, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		supplied := 0
+		for _, flag := range []string{signatureLookupSelector, signatureLookupTopic, signatureLookupName} {
+			if flag != "" {
+				supplied++
+			}
+		}
+		cli.Assert(supplied == 1, quiet, "one of --selector, --topic or --name is required")
+
+		txdata.InitFunctionMap()
+		txdata.UseSignatureDB(signatureDB())
+
+		found := false
+		switch {
+		case signatureLookupSelector != "":
+			data, err := hex.DecodeString(strings.TrimPrefix(signatureLookupSelector, "0x"))
+			cli.ErrCheck(err, quiet, "Invalid selector")
+			cli.Assert(len(data) == 4, quiet, "Selector must be 4 bytes")
+			var selector [4]byte
+			copy(selector[:], data)
+			found = signatureLookupOutput(txdata.FunctionSignatures(selector))
+		case signatureLookupTopic != "":
+			data, err := hex.DecodeString(strings.TrimPrefix(signatureLookupTopic, "0x"))
+			cli.ErrCheck(err, quiet, "Invalid topic")
+			cli.Assert(len(data) == common.HashLength, quiet, "Topic must be 32 bytes")
+			found = signatureLookupOutput(txdata.EventSignatures(common.BytesToHash(data)))
+		default:
+			for _, signature := range txdata.FunctionsByName(signatureLookupName) {
+				found = true
+				outputIf(!quiet, fmt.Sprintf("Function %#x %s", crypto.Keccak256([]byte(signature))[:4], signature))
+			}
+			for _, signature := range txdata.EventsByName(signatureLookupName) {
+				found = true
+				outputIf(!quiet, fmt.Sprintf("Event %s %s", crypto.Keccak256Hash([]byte(signature)).Hex(), signature))
+			}
+		}
+
+		if !found {
+			outputIf(!quiet, "No signatures found")
+			os.Exit(exitFailure)
+		}
+		os.Exit(exitSuccess)
+	},
+}
+
+// signatureLookupOutput outputs candidate signatures, returning true if there
+// are any.
+func signatureLookupOutput(signatures []*txdata.Signature) bool {
+	for _, signature := range signatures {
+		switch {
+		case signature.Blacklisted():
+			outputIf(!quiet, fmt.Sprintf("%s (known collision)", signature.Text))
+		case verbose && signature.ID != 0:
+			fmt.Printf("%s (score %d, directory ID %d)\n", signature.Text, signature.Score, signature.ID)
+		case verbose:
+			fmt.Printf("%s (score %d)\n", signature.Text, signature.Score)
+		default:
+			outputIf(!quiet, signature.Text)
+		}
+	}
+
+	return len(signatures) > 0
+}
+
+func init() {
+	offlineCmds["signature:lookup"] = true
+	signatureCmd.AddCommand(signatureLookupCmd)
+	signatureLookupCmd.Flags().StringVar(&signatureLookupSelector, "selector", "", "Function selector to look up")
+	signatureLookupCmd.Flags().StringVar(&signatureLookupTopic, "topic", "", "Event topic to look up")
+	signatureLookupCmd.Flags().StringVar(&signatureLookupName, "name", "", "Name of function or event to look up")
+}
@@ -14,22 +14,18 @@
 package cmd
 
 import (
-	"crypto/ecdsa"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/spf13/cobra"
 	"github.com/wealdtech/ethereal/v2/cli"
-	"github.com/wealdtech/ethereal/v2/util"
 )
 
 var (
-	signatureSignSigner     string
-	signatureSignPrivateKey string
-	signatureSignPassphrase string
+	signatureSignSigner         string
+	signatureSignAllowRawDigest bool
 )
 
 // signatureSignCmd represents the signature sign command.
@@ -59,28 +55,30 @@ provided below:
     signing message of "\\x19Ethereum Signed Message:\n" followed by the
 	number of bytes in the data and finally the data itself, for example
     "\\x19Ethereum Signed Message:\n11Hello world"
-  - the message is signed with the provided account or private key
+  - the message is signed with the provided account, private key or seed
+
+The above describes the default 'personal' mode.  Other modes can be selected
+with --mode:
+  - 'intended-validator' creates the message as per EIP-191 version 0x00, that is
+    "\\x19\\x00" followed by the address supplied with --validator and finally
+    the (potentially hashed) data
+  - 'raw-digest' signs the data, which must be a 32-byte hex string, directly.
+    This is dangerous, as the digest could be that of a transaction or any other
+    message, so confirmation is requested before signing unless
+    --allow-raw-digest is supplied
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(signatureDataStr != "", quiet, "--data is required")
 
 		dataHash := generateDataHash()
+		if signatureMode == "raw-digest" {
+			confirmRawDigest()
+		}
 
 		// Sign the hash.
-		var signature []byte
-		var key *ecdsa.PrivateKey
-		var err error
-		if signatureSignPassphrase != "" {
-			signer := common.HexToAddress(signatureSignSigner)
-			key, err = util.PrivateKeyForAccount(c.ChainID(), signer, signatureSignPassphrase)
-			cli.ErrCheck(err, quiet, "Invalid account or passphrse")
-		} else if signatureSignPrivateKey != "" {
-			key, err = crypto.HexToECDSA(strings.TrimPrefix(signatureSignPrivateKey, "0x"))
-			cli.ErrCheck(err, quiet, "Invalid private key")
-		} else {
-			cli.Err(quiet, "no passphrase or private key; cannot sign")
-		}
-		signature, err = crypto.Sign(dataHash, key)
+		key, err := obtainSigningKey(common.HexToAddress(signatureSignSigner))
+		cli.ErrCheck(err, quiet, "Failed to obtain signing key")
+		signature, err := crypto.Sign(dataHash, key)
 		cli.ErrCheck(err, quiet, "Failed to sign data")
 
 		if quiet {
@@ -96,6 +94,22 @@ func init() {
 	signatureCmd.AddCommand(signatureSignCmd)
 	signatureFlags(signatureSignCmd)
 	signatureSignCmd.Flags().StringVar(&signatureSignSigner, "signer", "", "Address of the account to sign the data")
-	signatureSignCmd.Flags().StringVar(&signatureSignPassphrase, "passphrase", "", "Passphrase of the account to sign the data")
-	signatureSignCmd.Flags().StringVar(&signatureSignPrivateKey, "privatekey", "", "Private key to sign the data")
+	signatureSignCmd.Flags().BoolVar(&signatureSignAllowRawDigest, "allow-raw-digest", false, "Sign a raw digest without confirmation")
+	addSecretFlags(signatureSignCmd, "signing the data")
+	addSeedFlags(signatureSignCmd, "signing the data")
+}
+
+// confirmRawDigest warns about signing a raw digest, and obtains confirmation
+// if it has not already been supplied.
+func confirmRawDigest() {
+	if !quiet {
+		fmt.Fprintln(os.Stderr, `WARNING: raw-digest mode signs the supplied digest directly.  The digest could be
+that of a transaction, a token permit or any other message, and signing it could
+give away control of your account or funds.  Only continue if you know exactly
+what the digest represents.`)
+	}
+	if signatureSignAllowRawDigest {
+		return
+	}
+	obtainConfirmation("sign the digest", "sign", "allow-raw-digest")
 }
@@ -35,6 +35,8 @@ var signatureSignerCmd = &cobra.Command{
 
     ethereal signature signer --data="false,2,0x5FfC014343cd971B7eb70732021E26C35B744cc4" --types="bool,uint256,address" --signature=0xcefd09e935b867a231086f41d98644655081a6e4e87c43e05fbbf621dfda69ea305c64fcf73907e09ce242c8ab8bcb953c4b45dd78262d8e34b22a8e4309734f00
 
+The data is turned in to a message with the same rules as 'signature sign', including the signing mode selected with --mode.
+
 In quiet mode this will return 0 if the signature provides a valid signer, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(signatureDataStr != "", quiet, "--data is required")
@@ -53,7 +55,11 @@ In quiet mode this will return 0 if the signature provides a valid signer, other
 			os.Exit(exitSuccess)
 		}
 
-		fmt.Printf("%s\n", ens.Format(c.Client(), address))
+		if c.Client() == nil {
+			fmt.Printf("%s\n", address.Hex())
+		} else {
+			fmt.Printf("%s\n", ens.Format(c.Client(), address))
+		}
 	},
 }
 
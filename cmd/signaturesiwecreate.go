@@ -0,0 +1,141 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util/siwe"
+)
+
+var (
+	signatureSiweCreateDomain    string
+	signatureSiweCreateScheme    string
+	signatureSiweCreateAddress   string
+	signatureSiweCreateSigner    string
+	signatureSiweCreateURI       string
+	signatureSiweCreateStatement string
+	signatureSiweCreateNonce     string
+	signatureSiweCreateExpiry    time.Duration
+	signatureSiweCreateNotBefore string
+	signatureSiweCreateRequestID string
+	signatureSiweCreateResources string
+	signatureSiweCreateOutput    string
+)
+
+// signatureSiweCreateCmd represents the signature siwe create command.
+var signatureSiweCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create and sign a Sign-In with Ethereum message",
+	Long: `Create a Sign-In with Ethereum (EIP-4361) message and sign it.  For example:
+
+    ethereal signature siwe create --domain=service.example.com --uri=https://service.example.com/login --signer=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --expiry=1h --passphrase=secret
+
+The message is for the chain of the connected node.  If --address is supplied the message is for that address, for example a smart account, otherwise it is for the signer.  A random nonce is generated if --nonce is not supplied.
+
+The message is output followed by the signature.  If --output is supplied the message is written to the file and only the signature is output.
+
+In quiet mode this will return 0 if the message is created and signed, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(signatureSiweCreateDomain != "", quiet, "--domain is required")
+		cli.Assert(signatureSiweCreateURI != "", quiet, "--uri is required")
+
+		key, err := obtainSigningKey(common.HexToAddress(signatureSiweCreateSigner))
+		cli.ErrCheck(err, quiet, "Failed to obtain signing key")
+		signer := crypto.PubkeyToAddress(key.PublicKey)
+
+		address := signer
+		if signatureSiweCreateAddress != "" {
+			address, err = c.Resolve(signatureSiweCreateAddress)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve address %s", signatureSiweCreateAddress))
+		}
+
+		nonce := signatureSiweCreateNonce
+		if nonce == "" {
+			nonce, err = siwe.GenerateNonce()
+			cli.ErrCheck(err, quiet, "Failed to generate nonce")
+		}
+
+		msg := &siwe.Message{
+			Scheme:    signatureSiweCreateScheme,
+			Domain:    signatureSiweCreateDomain,
+			Address:   address,
+			Statement: signatureSiweCreateStatement,
+			URI:       signatureSiweCreateURI,
+			Version:   siwe.Version,
+			ChainID:   c.ChainID(),
+			Nonce:     nonce,
+			IssuedAt:  time.Now().UTC().Truncate(time.Second),
+			RequestID: signatureSiweCreateRequestID,
+		}
+		if signatureSiweCreateExpiry > 0 {
+			expirationTime := msg.IssuedAt.Add(signatureSiweCreateExpiry)
+			msg.ExpirationTime = &expirationTime
+		}
+		if signatureSiweCreateNotBefore != "" {
+			notBefore, err := time.Parse(time.RFC3339, signatureSiweCreateNotBefore)
+			cli.ErrCheck(err, quiet, "Invalid not before time")
+			msg.NotBefore = &notBefore
+		}
+		if signatureSiweCreateResources != "" {
+			for _, resource := range strings.Split(signatureSiweCreateResources, ",") {
+				msg.Resources = append(msg.Resources, strings.TrimSpace(resource))
+			}
+		}
+		cli.ErrCheck(msg.Validate(), quiet, "Invalid message")
+
+		text := msg.String()
+		signature, err := siwe.Sign(text, key)
+		cli.ErrCheck(err, quiet, "Failed to sign message")
+
+		if signatureSiweCreateOutput != "" {
+			err = os.WriteFile(signatureSiweCreateOutput, []byte(text+"\n"), 0o600)
+			cli.ErrCheck(err, quiet, "Failed to write message")
+		}
+
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+
+		if signatureSiweCreateOutput == "" {
+			fmt.Printf("%s\n\n", text)
+		}
+		fmt.Printf("%#x\n", signature)
+	},
+}
+
+func init() {
+	signatureSiweCmd.AddCommand(signatureSiweCreateCmd)
+	signatureSiweCreateCmd.Flags().StringVar(&signatureSiweCreateDomain, "domain", "", "Domain requesting the sign-in")
+	signatureSiweCreateCmd.Flags().StringVar(&signatureSiweCreateScheme, "scheme", "", "Scheme of the domain requesting the sign-in (e.g. https)")
+	signatureSiweCreateCmd.Flags().StringVar(&signatureSiweCreateAddress, "address", "", "Address signing in (defaults to the signer)")
+	signatureSiweCreateCmd.Flags().StringVar(&signatureSiweCreateSigner, "signer", "", "Address of the account to sign the message")
+	signatureSiweCreateCmd.Flags().StringVar(&signatureSiweCreateURI, "uri", "", "URI of the resource that is the subject of the sign-in")
+	signatureSiweCreateCmd.Flags().StringVar(&signatureSiweCreateStatement, "statement", "", "Statement for the user to accept")
+	signatureSiweCreateCmd.Flags().StringVar(&signatureSiweCreateNonce, "nonce", "", "Nonce for the message (defaults to a random nonce)")
+	signatureSiweCreateCmd.Flags().DurationVar(&signatureSiweCreateExpiry, "expiry", 0, "Time for which the message is valid (e.g. 1h; default no expiry)")
+	signatureSiweCreateCmd.Flags().StringVar(&signatureSiweCreateNotBefore, "not-before", "", "Time before which the message is not valid, in RFC 3339 format")
+	signatureSiweCreateCmd.Flags().StringVar(&signatureSiweCreateRequestID, "request-id", "", "Request ID for the message")
+	signatureSiweCreateCmd.Flags().StringVar(&signatureSiweCreateResources, "resources", "", "Comma-separated list of resource URIs for the message")
+	signatureSiweCreateCmd.Flags().StringVar(&signatureSiweCreateOutput, "output", "", "File to which to write the message")
+	addSecretFlags(signatureSiweCreateCmd, "signing the message")
+	addSeedFlags(signatureSiweCreateCmd, "signing the message")
+}
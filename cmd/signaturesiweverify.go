@@ -0,0 +1,107 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util/siwe"
+)
+
+var (
+	signatureSiweVerifyMessage   string
+	signatureSiweVerifySignature string
+	signatureSiweVerifyDomain    string
+	signatureSiweVerifyAddress   string
+	signatureSiweVerifyNonce     string
+)
+
+// signatureSiweVerifyCmd represents the signature siwe verify command.
+var signatureSiweVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify a Sign-In with Ethereum message",
+	Long: `Verify a signed Sign-In with Ethereum (EIP-4361) message.  For example:
+
+    ethereal signature siwe verify --message=message.txt --signature=0x... --domain=service.example.com --nonce=Ha8Pq2vX7kLm3NzR
+
+The message can be supplied directly or as the path to a file containing it.  The fields of the message are validated, as are its issued at, not before and expiration times.  If --domain, --address or --nonce are supplied the message must match them.
+
+If the address in the message is a contract, such as a Safe or smart account, the signature is verified with ERC-1271 or ERC-6492.  Contract signatures require a connection to an Ethereum node; with --offline only standard signatures are verified, and the chain ID is not checked.
+
+In quiet mode this will return 0 if the message and signature are valid, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		cli.Assert(signatureSiweVerifyMessage != "", quiet, "--message is required")
+		cli.Assert(signatureSiweVerifySignature != "", quiet, "--signature is required")
+
+		text := signatureSiweVerifyMessage
+		if !strings.Contains(text, " wants you to sign in with your Ethereum account:") {
+			// Assume it's a path to the message.
+			data, err := os.ReadFile(text)
+			cli.ErrCheck(err, quiet, "Failed to read message")
+			text = strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		}
+		msg, err := siwe.ParseMessage(text)
+		cli.ErrCheck(err, quiet, "Invalid message")
+
+		signature, err := hex.DecodeString(strings.TrimPrefix(signatureSiweVerifySignature, "0x"))
+		cli.ErrCheck(err, quiet, "Invalid signature")
+
+		if signatureSiweVerifyDomain != "" {
+			cli.Assert(msg.Domain == signatureSiweVerifyDomain, quiet, fmt.Sprintf("Message is for domain %s", msg.Domain))
+		}
+		if signatureSiweVerifyAddress != "" {
+			address, err := c.Resolve(signatureSiweVerifyAddress)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve address %s", signatureSiweVerifyAddress))
+			cli.Assert(msg.Address == address, quiet, fmt.Sprintf("Message is for address %s", msg.Address.Hex()))
+		}
+		if signatureSiweVerifyNonce != "" {
+			cli.Assert(msg.Nonce == signatureSiweVerifyNonce, quiet, "Message nonce does not match")
+		}
+		cli.ErrCheck(msg.ValidateTime(time.Now()), quiet, "Message is not valid at this time")
+
+		var caller bind.ContractCaller
+		if !offline {
+			cli.Assert(msg.ChainID.Cmp(c.ChainID()) == 0, quiet, fmt.Sprintf("Message is for chain %v but connected to chain %v", msg.ChainID, c.ChainID()))
+			caller = c.Client()
+		}
+		err = siwe.VerifySignature(ctx, caller, msg.Address, text, signature)
+		if err != nil {
+			outputIf(verbose, err.Error())
+			outputIf(!quiet, "Not verified")
+			os.Exit(exitFailure)
+		}
+
+		outputIf(!quiet, "Verified")
+		os.Exit(exitSuccess)
+	},
+}
+
+func init() {
+	signatureSiweCmd.AddCommand(signatureSiweVerifyCmd)
+	signatureSiweVerifyCmd.Flags().StringVar(&signatureSiweVerifyMessage, "message", "", "Message, or path to file containing the message")
+	signatureSiweVerifyCmd.Flags().StringVar(&signatureSiweVerifySignature, "signature", "", "Hex string signature of the message")
+	signatureSiweVerifyCmd.Flags().StringVar(&signatureSiweVerifyDomain, "domain", "", "Domain that the message must be for")
+	signatureSiweVerifyCmd.Flags().StringVar(&signatureSiweVerifyAddress, "address", "", "Address that the message must be for")
+	signatureSiweVerifyCmd.Flags().StringVar(&signatureSiweVerifyNonce, "nonce", "", "Nonce that the message must contain")
+}
@@ -15,6 +15,7 @@ package cmd
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"encoding/hex"
 	"os"
 	"strings"
@@ -22,7 +23,9 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
 )
 
 var (
@@ -38,6 +41,10 @@ var signatureVerifyCmd = &cobra.Command{
 
     ethereal data verify --data="false,2,0x5FfC014343cd971B7eb70732021E26C35B744cc4" --types="bool,uint256,address" --signature=0xcefd09e935b867a231086f41d98644655081a6e4e87c43e05fbbf621dfda69ea305c64fcf73907e09ce242c8ab8bcb953c4b45dd78262d8e34b22a8e4309734f00 --signer=0x0x5FfC014343cd971B7eb70732021E26C35B744cc4
 
+If the signer is a contract, such as a Safe or smart account, the signature is checked with the contract's ERC-1271 isValidSignature() function.  ERC-6492 signatures from contracts that have yet to be deployed are also supported.  Standard signatures are verified without a connection to an Ethereum node; contract signatures require a connection, and cannot be verified with --offline.
+
+The data is turned in to a message with the same rules as 'signature sign', including the signing mode selected with --mode.
+
 In quiet mode this will return 0 if the signature is valid, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(signatureDataStr != "", quiet, "--data is required")
@@ -48,17 +55,52 @@ In quiet mode this will return 0 if the signature is valid, otherwise 1.`,
 		signature, err := hex.DecodeString(strings.TrimPrefix(signatureVerifySignature, "0x"))
 		cli.ErrCheck(err, quiet, "Invalid signature")
 
-		key, err := crypto.SigToPub(dataHash, signature)
-		cli.ErrCheck(err, quiet, "Failed to signer signature")
-		// nolint:staticcheck
-		signer := crypto.PubkeyToAddress(*key)
-
 		verifySigner := common.HexToAddress(signatureVerifySigner)
 
-		if bytes.Equal(signer.Bytes(), verifySigner.Bytes()) {
-			outputIf(!quiet, "Verified")
-			os.Exit(exitSuccess)
+		// Standard signatures can be verified without a connection.
+		var recovered *common.Address
+		var recoverErr error
+		if !util.IsERC6492Signature(signature) {
+			var key *ecdsa.PublicKey
+			key, recoverErr = crypto.SigToPub(dataHash, signature)
+			if recoverErr == nil {
+				// nolint:staticcheck
+				signer := crypto.PubkeyToAddress(*key)
+				recovered = &signer
+				if bytes.Equal(signer.Bytes(), verifySigner.Bytes()) {
+					outputIf(!quiet, "Verified")
+					os.Exit(exitSuccess)
+				}
+			}
+		}
+
+		// The signature may be from a contract, which requires a connection.
+		if viper.GetBool("offline") {
+			cli.Assert(recovered != nil, quiet, "Contract signatures cannot be verified with --offline")
+			outputIf(verbose, "Signature is not from the signer's key; contract signatures cannot be verified with --offline")
+			outputIf(!quiet, "Not verified")
+			os.Exit(exitFailure)
+		}
+		ctx, cancel := localContext()
+		defer cancel()
+		connection, err := onlineConnection(ctx)
+		cli.ErrCheck(err, quiet, "Failed to connect to Ethereum node")
+
+		code, err := connection.Client().CodeAt(ctx, verifySigner, nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain signer code")
+		if len(code) > 0 || util.IsERC6492Signature(signature) {
+			outputIf(verbose, "Signer is a contract")
+			valid, err := util.VerifyContractSignature(ctx, connection.Client(), verifySigner, dataHash, signature)
+			cli.ErrCheck(err, quiet, "Failed to verify contract signature")
+			if valid {
+				outputIf(!quiet, "Verified")
+				os.Exit(exitSuccess)
+			}
+			outputIf(!quiet, "Not verified")
+			os.Exit(exitFailure)
 		}
+		cli.ErrCheck(recoverErr, quiet, "Failed to signer signature")
+
 		outputIf(!quiet, "Not verified")
 		os.Exit(exitFailure)
 	},
@@ -0,0 +1,121 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+)
+
+var (
+	stateBlock      string
+	stateAtTime     string
+	blockHashRegexp = regexp.MustCompile("^0x[0-9a-fA-F]{64}$")
+)
+
+// blockTags are the named blocks accepted in place of a block number or hash.
+var blockTags = map[string]rpc.BlockNumber{
+	"latest":    rpc.LatestBlockNumber,
+	"safe":      rpc.SafeBlockNumber,
+	"finalized": rpc.FinalizedBlockNumber,
+	"pending":   rpc.PendingBlockNumber,
+}
+
+// addStateFlags adds the flags to select the block at which state is read.
+func addStateFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&stateBlock, "block", "", "Block at which to read state: number, hash, latest, safe, finalized or pending (historical state requires an archive node)")
+	cmd.Flags().StringVar(&stateAtTime, "at-time", "", "Time at which to read state, in RFC3339 format (uses the last block at or before the time)")
+}
+
+// stateBlockNumber returns the block number at which to read state as
+// selected by the state flags, or nil for the latest block.  Named blocks
+// other than the latest are returned as their negative RPC numbers.
+func stateBlockNumber(ctx context.Context) *big.Int {
+	cli.Assert(stateBlock == "" || stateAtTime == "", quiet, "only one of --block and --at-time can be supplied")
+
+	if stateAtTime != "" {
+		timestamp, err := time.Parse(time.RFC3339, stateAtTime)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Invalid time %s; should be RFC3339, for example 2024-01-02T15:04:05Z", stateAtTime))
+		header, err := util.BlockAtTime(ctx, c.Client(), timestamp)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain block at %s", stateAtTime))
+		outputIf(verbose, fmt.Sprintf("Block at %s is %d", stateAtTime, header.Number.Uint64()))
+
+		return header.Number
+	}
+
+	blockNumber, err := parseBlockID(ctx, stateBlock)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Invalid block %s", stateBlock))
+
+	return blockNumber
+}
+
+// parseBlockID parses a block number, hash or name, returning nil for the
+// latest block.  Hashes are converted to their block number.
+func parseBlockID(ctx context.Context, input string) (*big.Int, error) {
+	if input == "" || input == "latest" {
+		return nil, nil
+	}
+	if tag, exists := blockTags[input]; exists {
+		return big.NewInt(tag.Int64()), nil
+	}
+	if blockInfoNumberRegexp.MatchString(input) {
+		blockNumber, succeeded := new(big.Int).SetString(input, 10)
+		if !succeeded {
+			return nil, fmt.Errorf("failed to parse block number %s", input)
+		}
+		return blockNumber, nil
+	}
+	if !blockHashRegexp.MatchString(input) {
+		return nil, errors.New("block should be a number, a hash or one of latest, safe, finalized or pending")
+	}
+	header, err := c.Client().HeaderByHash(ctx, common.HexToHash(input))
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain block %s: %w", input, err)
+	}
+
+	return header.Number, nil
+}
+
+// blockNumberOf returns the number of a block given its number, hash or name,
+// defaulting to the latest block.
+func blockNumberOf(ctx context.Context, input string) uint64 {
+	blockNumber, err := parseBlockID(ctx, input)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Invalid block %s", input))
+	if blockNumber != nil && blockNumber.Sign() >= 0 {
+		return blockNumber.Uint64()
+	}
+	header, err := c.Client().HeaderByNumber(ctx, blockNumber)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to obtain block %s", input))
+
+	return header.Number.Uint64()
+}
+
+// stateErrCheck checks an error from reading state, explaining if the
+// connection does not hold state for the requested block.
+func stateErrCheck(err error, msg string) {
+	if util.IsMissingStateError(err) {
+		cli.Err(quiet, "Connection does not hold state for that block; historical state requires an archive node")
+	}
+	cli.ErrCheck(err, quiet, msg)
+}
@@ -18,6 +18,7 @@ import (
 	"math/big"
 	"os"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/spf13/cobra"
 	"github.com/wealdtech/ethereal/v2/cli"
 	"github.com/wealdtech/ethereal/v2/util"
@@ -37,6 +38,8 @@ var tokenAllowanceCmd = &cobra.Command{
 
     ethereal token allowance --token=omg --holder=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --spender=0x52f1A3027d3aA514F17E454C93ae1F79b3B12d5d
 
+The allowance at an earlier point can be obtained with --block or --at-time.
+
 In quiet mode this will return 0 if the allowance is greater than 0, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(tokenAllowanceHolderAddress != "", quiet, "--holder is required")
@@ -51,11 +54,18 @@ In quiet mode this will return 0 if the allowance is greater than 0, otherwise 1
 		token, err := tokenContract(tokenStr)
 		cli.ErrCheck(err, quiet, "Failed to obtain token contract")
 
-		decimals, err := token.Decimals(nil)
-		cli.ErrCheck(err, quiet, "Failed to obtain token decimals")
+		ctx, cancel := localContext()
+		defer cancel()
+		opts := &bind.CallOpts{
+			Context:     ctx,
+			BlockNumber: stateBlockNumber(ctx),
+		}
+
+		decimals, err := token.Decimals(opts)
+		stateErrCheck(err, "Failed to obtain token decimals")
 
-		allowance, err := token.Allowance(nil, holderAddress, spenderAddress)
-		cli.ErrCheck(err, quiet, "Failed to obtain allowance")
+		allowance, err := token.Allowance(opts, holderAddress, spenderAddress)
+		stateErrCheck(err, "Failed to obtain allowance")
 
 		if quiet {
 			if allowance.Cmp(big.NewInt(0)) == 0 {
@@ -75,6 +85,7 @@ In quiet mode this will return 0 if the allowance is greater than 0, otherwise 1
 func init() {
 	tokenCmd.AddCommand(tokenAllowanceCmd)
 	tokenFlags(tokenAllowanceCmd)
+	addStateFlags(tokenAllowanceCmd)
 	tokenAllowanceCmd.Flags().BoolVar(&tokenAllowanceRaw, "raw", false, "Display raw output (no decimals)")
 	tokenAllowanceCmd.Flags().StringVar(&tokenAllowanceHolderAddress, "holder", "", "Address that holds tokens")
 	tokenAllowanceCmd.Flags().StringVar(&tokenAllowanceSpenderAddress, "spender", "", "Address that can spend tokens")
@@ -18,6 +18,7 @@ import (
 	"math/big"
 	"os"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/spf13/cobra"
 	"github.com/wealdtech/ethereal/v2/cli"
 	"github.com/wealdtech/ethereal/v2/util"
@@ -36,6 +37,8 @@ var tokenBalanceCmd = &cobra.Command{
 
     ethereal token balance --token=omg --holder=0x5FfC014343cd971B7eb70732021E26C35B744cc4
 
+The balance at an earlier point can be obtained with --block or --at-time.
+
 In quiet mode this will return 0 if the balance is greater than 0, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(tokenBalanceHolderAddress != "", quiet, "--holder is required")
@@ -46,11 +49,18 @@ In quiet mode this will return 0 if the balance is greater than 0, otherwise 1.`
 		token, err := tokenContract(tokenStr)
 		cli.ErrCheck(err, quiet, "Failed to obtain token contract")
 
-		decimals, err := token.Decimals(nil)
-		cli.ErrCheck(err, quiet, "Failed to obtain token decimals")
+		ctx, cancel := localContext()
+		defer cancel()
+		opts := &bind.CallOpts{
+			Context:     ctx,
+			BlockNumber: stateBlockNumber(ctx),
+		}
+
+		decimals, err := token.Decimals(opts)
+		stateErrCheck(err, "Failed to obtain token decimals")
 
-		balance, err := token.BalanceOf(nil, address)
-		cli.ErrCheck(err, quiet, "Failed to obtain token balance")
+		balance, err := token.BalanceOf(opts, address)
+		stateErrCheck(err, "Failed to obtain token balance")
 
 		if quiet {
 			if balance.Cmp(big.NewInt(0)) == 0 {
@@ -72,4 +82,5 @@ func init() {
 	tokenCmd.AddCommand(tokenBalanceCmd)
 	tokenBalanceCmd.Flags().BoolVar(&tokenBalanceRaw, "raw", false, "Display raw output (no decimals)")
 	tokenBalanceCmd.Flags().StringVar(&tokenBalanceHolderAddress, "holder", "", "Holder of tokens")
+	addStateFlags(tokenBalanceCmd)
 }
@@ -15,11 +15,14 @@ package cmd
 
 import (
 	"fmt"
+	"math/big"
 	"os"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cobra"
 	"github.com/wealdtech/ethereal/v2/cli"
 	"github.com/wealdtech/ethereal/v2/util"
+	"github.com/wealdtech/ethereal/v2/util/contracts"
 	ens "github.com/wealdtech/go-ens/v3"
 )
 
@@ -31,46 +34,55 @@ var tokenInfoCmd = &cobra.Command{
 
     ethereal token info --token=omg
 
+Information at an earlier point can be obtained with --block or --at-time.
+
 In quiet mode this will return 0 if the token exists, otherwise 1.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cli.Assert(tokenStr != "", quiet, "--token is required")
-		token, err := tokenContract(tokenStr)
+		address, err := tokenContractAddress(tokenStr)
 		cli.ErrCheck(err, quiet, "Failed to obtain token contract")
 
 		if quiet {
 			os.Exit(exitSuccess)
 		}
 
-		name, err := token.Name(nil)
-		if err == nil {
-			fmt.Printf("Name:\t\t%s\n", name)
+		tokenAbi, err := contracts.ERC20MetaData.GetAbi()
+		cli.ErrCheck(err, quiet, "Failed to parse token ABI")
+		calls := make([]*util.ABICall, 0, 4)
+		for _, method := range []string{"name", "symbol", "decimals", "totalSupply"} {
+			calls = append(calls, &util.ABICall{Target: address, ABI: tokenAbi, Method: method})
+		}
+		ctx, cancel := localContext()
+		defer cancel()
+		err = util.MulticallABI(ctx, c.Client(), common.Address{}, stateBlockNumber(ctx), calls)
+		stateErrCheck(err, "Failed to obtain token information")
+
+		if calls[0].Err == nil {
+			fmt.Printf("Name:\t\t%s\n", calls[0].Outputs[0].(string))
 		}
 
 		if verbose {
-			address, err := tokenContractAddress(tokenStr)
-			if err == nil {
-				fmt.Printf("Address:\t%s\n", ens.Format(c.Client(), address))
-			}
+			fmt.Printf("Address:\t%s\n", ens.Format(c.Client(), address))
 		}
 
-		symbol, err := token.Symbol(nil)
-		if err == nil {
-			fmt.Printf("Symbol:\t\t%s\n", symbol)
+		if calls[1].Err == nil {
+			fmt.Printf("Symbol:\t\t%s\n", calls[1].Outputs[0].(string))
 		}
 
-		decimals, err := token.Decimals(nil)
-		if err == nil {
+		var decimals uint8
+		if calls[2].Err == nil {
+			decimals = calls[2].Outputs[0].(uint8)
 			fmt.Printf("Decimals:\t%d\n", decimals)
 		}
 
-		totalSupply, err := token.TotalSupply(nil)
-		if err == nil {
-			fmt.Printf("Total supply:\t%s\n", util.TokenValueToString(totalSupply, decimals, true))
+		if calls[3].Err == nil {
+			fmt.Printf("Total supply:\t%s\n", util.TokenValueToString(calls[3].Outputs[0].(*big.Int), decimals, true))
 		}
 	},
 }
 
 func init() {
 	tokenFlags(tokenInfoCmd)
+	addStateFlags(tokenInfoCmd)
 	tokenCmd.AddCommand(tokenInfoCmd)
 }
@@ -0,0 +1,65 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util/txdata"
+)
+
+var transactionDecodeData string
+
+// transactionDecodeCmd represents the transaction decode command.
+var transactionDecodeCmd = &cobra.Command{
+	Use:   "decode",
+	Short: "Decode transaction data",
+	Long: `Decode transaction data, including calls nested within it.  For example:
+
+    ethereal transaction decode --data=0xa9059cbb0000000000000000000000007755b69903bcbcc419260dbb65772412e0c4ad2b0000000000000000000000000000000000000000000000d3a1a2c9d1e0d5a000
+
+Calls nested within the data, for example in multicall(), aggregate3(), Safe execTransaction() and multiSend(), timelock schedule() and Uniswap universal router execute() calls, are also decoded and shown indented below the call that holds them.
+
+Functions are decoded using the local signature database and the built-in signatures.
+
+In quiet mode this will return 0 if the data is decoded, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(transactionDecodeData != "", quiet, "--data is required")
+		data, err := hex.DecodeString(strings.TrimPrefix(transactionDecodeData, "0x"))
+		cli.ErrCheck(err, quiet, "Failed to parse data")
+
+		txdata.InitFunctionMap()
+		txdata.UseSignatureDBLoader(signatureDB)
+		call, err := txdata.DecodeCall(data)
+		cli.ErrCheck(err, quiet, "Failed to decode data")
+
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+		for _, line := range call.Tree(nil) {
+			fmt.Println(line)
+		}
+	},
+}
+
+func init() {
+	offlineCmds["transaction:decode"] = true
+	transactionCmd.AddCommand(transactionDecodeCmd)
+	transactionDecodeCmd.Flags().StringVar(&transactionDecodeData, "data", "", "Transaction data to decode")
+}
@@ -22,11 +22,14 @@ import (
 	"os"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/spf13/cobra"
 	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+	"github.com/wealdtech/ethereal/v2/util/safe"
 	"github.com/wealdtech/ethereal/v2/util/txdata"
 	ens "github.com/wealdtech/go-ens/v3"
 	string2eth "github.com/wealdtech/go-string2eth"
@@ -36,6 +39,8 @@ var (
 	transactionInfoRaw        bool
 	transactionInfoJSON       bool
 	transactionInfoSignatures string
+	// transactionInfoABIs caches the stored ABIs of the contracts seen.
+	transactionInfoABIs = make(map[common.Address]*abi.ABI)
 )
 
 // transactionInfoCmd represents the transaction info command.
@@ -99,9 +104,13 @@ In quiet mode this will return 0 if the transaction exists, otherwise 1.`,
 
 		txdata.InitFunctionMap()
 		if transactionInfoSignatures != "" {
+			db := signatureDB()
 			for _, signature := range strings.Split(transactionInfoSignatures, ";") {
-				txdata.AddFunctionSignature(signature)
+				cli.ErrCheck(db.AddFunction(signature, txdata.UserScore, 0, ""), quiet, "Invalid signature")
 			}
+			txdata.UseSignatureDB(db)
+		} else {
+			txdata.UseSignatureDBLoader(signatureDB)
 		}
 
 		var receipt *types.Receipt
@@ -212,7 +221,23 @@ In quiet mode this will return 0 if the transaction exists, otherwise 1.`,
 		fmt.Printf("Value:\t\t\t%v\n", string2eth.WeiToString(tx.Value(), true))
 
 		if tx.To() != nil && len(tx.Data()) > 0 {
-			fmt.Printf("Data:\t\t\t%v\n", txdata.DataToString(c.Client(), tx.Data()))
+			decoded := transactionInfoStoredData(*tx.To(), tx.Data())
+			if decoded == "" {
+				if call, err := txdata.DecodeCall(tx.Data()); err == nil {
+					// Show nested calls indented below the data.
+					decoded = strings.Join(call.Tree(c.Client()), "\n\t\t\t")
+				}
+			}
+			if decoded == "" {
+				decoded = txdata.DataToString(c.Client(), tx.Data())
+			}
+			fmt.Printf("Data:\t\t\t%v\n", decoded)
+			if safe.IsExecTransaction(tx.Data()) {
+				if safeTx, _, err := safe.DecodeExecTransaction(tx.Data()); err == nil {
+					fmt.Printf("Safe transaction:\n")
+					outputSafeTransaction("\t", safeTx)
+				}
+			}
 		}
 
 		if verbose && receipt != nil && len(receipt.Logs) > 0 {
@@ -221,7 +246,10 @@ In quiet mode this will return 0 if the transaction exists, otherwise 1.`,
 				fmt.Printf("\t%d:\n", i)
 				fmt.Printf("\t\tFrom:\t%v\n", ens.Format(c.Client(), log.Address))
 				// Try to obtain decoded log.
-				decoded := txdata.EventToString(c.Client(), log)
+				decoded := transactionInfoStoredEvent(log)
+				if decoded == "" {
+					decoded = txdata.EventToString(c.Client(), log)
+				}
 				if decoded != "" {
 					fmt.Printf("\t\tEvent:\t%s\n", decoded)
 				} else {
@@ -250,3 +278,96 @@ func init() {
 	transactionInfoCmd.Flags().BoolVar(&transactionInfoJSON, "json", false, "Output the transaction as json")
 	transactionInfoCmd.Flags().StringVar(&transactionInfoSignatures, "signatures", "", "Semicolon-separated list of custom transaction signatures (e.g. myFunc(address,bytes32);myFunc2(bool)")
 }
+
+// transactionInfoStoredABI returns the stored ABI for a contract, if any.
+func transactionInfoStoredABI(address common.Address) *abi.ABI {
+	if storedAbi, exists := transactionInfoABIs[address]; exists {
+		return storedAbi
+	}
+	storedAbi := transactionInfoStoreABI(address)
+	if storedAbi == nil {
+		// The contract may be a proxy for an implementation with a stored ABI.
+		ctx, cancel := localContext()
+		proxy, err := util.DetectProxy(ctx, c.Client(), address, nil)
+		cancel()
+		if err == nil && proxy != nil {
+			outputIf(verbose, fmt.Sprintf("%s is an %s proxy for %s", address.Hex(), proxy.Type, proxy.Implementation.Hex()))
+			storedAbi = transactionInfoStoreABI(proxy.Implementation)
+		}
+	}
+	transactionInfoABIs[address] = storedAbi
+
+	return storedAbi
+}
+
+// transactionInfoStoreABI returns the ABI held in the ABI store for the
+// address, if any.  An unreadable ABI is ignored.
+func transactionInfoStoreABI(address common.Address) *abi.ABI {
+	storedAbi, err := contractABIStore().ABI(c.ChainID(), address)
+	if err != nil {
+		outputIf(verbose, fmt.Sprintf("Ignoring stored ABI for %s: %v", address.Hex(), err))
+		return nil
+	}
+
+	return storedAbi
+}
+
+// transactionInfoStoredData decodes transaction data using the stored ABI of
+// the contract, returning an empty string if it cannot be decoded.
+func transactionInfoStoredData(address common.Address, data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	storedAbi := transactionInfoStoredABI(address)
+	if storedAbi == nil {
+		return ""
+	}
+	method, err := storedAbi.MethodById(data[:4])
+	if err != nil {
+		return ""
+	}
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return ""
+	}
+
+	res := make([]string, len(values))
+	for i, value := range values {
+		res[i], err = contractValueToString(method.Inputs[i].Type, value)
+		if err != nil {
+			res[i] = err.Error()
+		}
+	}
+
+	return fmt.Sprintf("%s(%s)", method.Name, strings.Join(res, ","))
+}
+
+// transactionInfoStoredEvent decodes a log using the stored ABI of the
+// contract that emitted it, returning an empty string if it cannot be decoded.
+func transactionInfoStoredEvent(log *types.Log) string {
+	if len(log.Topics) == 0 {
+		return ""
+	}
+	storedAbi := transactionInfoStoredABI(log.Address)
+	if storedAbi == nil {
+		return ""
+	}
+	event, err := storedAbi.EventByID(log.Topics[0])
+	if err != nil {
+		return ""
+	}
+	args, err := util.DecodeEvent(event, log)
+	if err != nil {
+		return ""
+	}
+
+	res := make([]string, len(args))
+	for i, arg := range args {
+		res[i], err = contractValueToString(arg.Type, arg.Value)
+		if err != nil {
+			res[i] = err.Error()
+		}
+	}
+
+	return fmt.Sprintf("%s(%s)", event.Name, strings.Join(res, ","))
+}
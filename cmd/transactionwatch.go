@@ -0,0 +1,209 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+	string2eth "github.com/wealdtech/go-string2eth"
+)
+
+var (
+	transactionWatchFrom         string
+	transactionWatchTo           string
+	transactionWatchPending      bool
+	transactionWatchFormat       string
+	transactionWatchPollInterval time.Duration
+)
+
+// transactionWatchCmd represents the transaction watch command.
+var transactionWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch transactions to or from an address",
+	Long: `Watch transactions to or from an address as they enter the mempool and are mined, until interrupted.  For example:
+
+    ethereal transaction watch --to=0x5FfC014343cd971B7eb70732021E26C35B744cc4
+
+If both --from and --to are supplied then transactions must match both.  Pending transactions are only seen if the node shares its mempool; --pending=false watches mined transactions alone.
+
+If the connection supports subscriptions then they are used, otherwise the node is polled for changes every --poll-interval.  Failed connections are retried until interrupted, and transactions mined while disconnected are reported on reconnection.
+
+Output is in text by default, or can be JSON lines with --format=jsonl.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(!offline, quiet, "Cannot watch transactions offline")
+		cli.Assert(transactionWatchFrom != "" || transactionWatchTo != "", quiet, "--from or --to is required")
+		cli.Assert(transactionWatchFormat == "text" || transactionWatchFormat == "jsonl", quiet, fmt.Sprintf("Unknown format %s; should be text or jsonl", transactionWatchFormat))
+		var from *common.Address
+		if transactionWatchFrom != "" {
+			address, err := c.Resolve(transactionWatchFrom)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve from address %s", transactionWatchFrom))
+			from = &address
+		}
+		var to *common.Address
+		if transactionWatchTo != "" {
+			address, err := c.Resolve(transactionWatchTo)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve to address %s", transactionWatchTo))
+			to = &address
+		}
+
+		ctx, cancel := interruptContext()
+		defer cancel()
+
+		watcher := newWatcher(transactionWatchPollInterval)
+		w := &transactionWatchWriter{from: from, to: to}
+		errs := make(chan error, 2)
+		var wg sync.WaitGroup
+		if transactionWatchPending {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- watcher.WatchPendingTransactions(ctx, w.pending)
+				cancel()
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- watcher.WatchBlocks(ctx, w.mined)
+			cancel()
+		}()
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			cli.ErrCheck(err, quiet, "Failed to watch transactions")
+		}
+	},
+}
+
+// transactionWatchWriter writes transactions that match the watched addresses.
+type transactionWatchWriter struct {
+	mu   sync.Mutex
+	from *common.Address
+	to   *common.Address
+}
+
+// pending writes a pending transaction if it matches.
+func (w *transactionWatchWriter) pending(ctx context.Context, client *ethclient.Client, hash common.Hash) error {
+	tx, isPending, err := client.TransactionByHash(ctx, hash)
+	if err != nil || !isPending {
+		// Transaction has already gone from the mempool.
+		return nil
+	}
+
+	return w.write(tx, nil)
+}
+
+// mined writes the matching transactions in a block.  If the block cannot be
+// obtained the watcher reconnects and tries again.
+func (w *transactionWatchWriter) mined(ctx context.Context, client *ethclient.Client, hash common.Hash) error {
+	block, err := client.BlockByHash(ctx, hash)
+	if err != nil {
+		return &util.WatchRetryError{Err: fmt.Errorf("failed to obtain block %s: %w", hash.Hex(), err)}
+	}
+	for _, tx := range block.Transactions() {
+		if err := w.write(tx, block); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write writes a transaction if it matches, as mined if the block is supplied.
+func (w *transactionWatchWriter) write(tx *types.Transaction, block *types.Block) error {
+	if w.to != nil && (tx.To() == nil || *tx.To() != *w.to) {
+		return nil
+	}
+	sender, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil
+	}
+	if w.from != nil && sender != *w.from {
+		return nil
+	}
+	if quiet {
+		return nil
+	}
+
+	status := "pending"
+	if block != nil {
+		status = "mined"
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if transactionWatchFormat == "jsonl" {
+		res := &transactionWatchJSON{
+			Status: status,
+			Hash:   tx.Hash().Hex(),
+			From:   sender.Hex(),
+			Value:  tx.Value().String(),
+			Nonce:  tx.Nonce(),
+		}
+		if tx.To() != nil {
+			res.To = tx.To().Hex()
+		}
+		if block != nil {
+			res.Block = block.NumberU64()
+		}
+		data, err := json.Marshal(res)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	recipient := "contract creation"
+	if tx.To() != nil {
+		recipient = tx.To().Hex()
+	}
+	location := "Pending"
+	if block != nil {
+		location = fmt.Sprintf("Block %d", block.NumberU64())
+	}
+	fmt.Printf("%s: transaction %s from %s to %s, nonce %d, value %s\n", location, tx.Hash().Hex(), sender.Hex(), recipient, tx.Nonce(), string2eth.WeiToString(tx.Value(), true))
+
+	return nil
+}
+
+// transactionWatchJSON is the JSON representation of a watched transaction.
+type transactionWatchJSON struct {
+	Status string `json:"status"`
+	Hash   string `json:"hash"`
+	From   string `json:"from"`
+	To     string `json:"to,omitempty"`
+	Value  string `json:"value"`
+	Nonce  uint64 `json:"nonce"`
+	Block  uint64 `json:"block,omitempty"`
+}
+
+func init() {
+	transactionCmd.AddCommand(transactionWatchCmd)
+	transactionWatchCmd.Flags().StringVar(&transactionWatchFrom, "from", "", "Address from which transactions are sent")
+	transactionWatchCmd.Flags().StringVar(&transactionWatchTo, "to", "", "Address to which transactions are sent")
+	transactionWatchCmd.Flags().BoolVar(&transactionWatchPending, "pending", true, "Watch pending transactions as well as mined transactions")
+	transactionWatchCmd.Flags().StringVar(&transactionWatchFormat, "format", "text", "Output format (text or jsonl)")
+	transactionWatchCmd.Flags().DurationVar(&transactionWatchPollInterval, "poll-interval", 4*time.Second, "Interval at which to poll for transactions if the connection does not support subscriptions")
+}
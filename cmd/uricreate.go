@@ -0,0 +1,112 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
+	"github.com/wealdtech/ethereal/v2/util/erc681"
+	string2eth "github.com/wealdtech/go-string2eth"
+)
+
+var (
+	uriCreateTo       string
+	uriCreateAmount   string
+	uriCreateDecimals string
+	uriCreateNoQR     bool
+)
+
+// uriCreateCmd represents the uri create command.
+var uriCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a payment request URI",
+	Long: `Create an ERC-681 payment request URI, and display it as a QR code.  For example:
+
+    ethereal uri create --to=0x52f1A3027d3aA514F17E454C93ae1F79b3B12d5d --amount=1.5ether
+
+If --token is supplied the request is for a transfer of that token, and --amount is in units of the token.  If offline, --decimals is required for token transfers.  If --amount is not supplied the request is for an unspecified amount of Ether.
+
+The URI is for the chain of the connected node.  --noqr will output just the URI.
+
+In quiet mode this will return 0 if the URI is created, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(uriCreateTo != "", quiet, "--to is required")
+		toAddress, err := c.Resolve(uriCreateTo)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve address %s", uriCreateTo))
+
+		req := &erc681.Request{
+			ChainID: c.ChainID(),
+		}
+		if tokenStr == "" {
+			req.Target = toAddress.Hex()
+			if uriCreateAmount != "" {
+				req.Value, err = string2eth.StringToWei(uriCreateAmount)
+				cli.ErrCheck(err, quiet, "Invalid amount")
+			}
+		} else {
+			cli.Assert(uriCreateAmount != "", quiet, "--amount is required for token transfers")
+			tokenAddress, err := tokenContractAddress(tokenStr)
+			cli.ErrCheck(err, quiet, "Failed to obtain token contract")
+
+			var decimals uint8
+			if offline {
+				cli.Assert(uriCreateDecimals != "", quiet, "--decimals is required if offline")
+				tmpDecimals, err := strconv.ParseUint(uriCreateDecimals, 10, 8)
+				cli.ErrCheck(err, quiet, "Invalid decimals")
+				decimals = uint8(tmpDecimals)
+			} else {
+				token, err := tokenContract(tokenAddress.Hex())
+				cli.ErrCheck(err, quiet, "Failed to obtain token contract")
+				decimals, err = token.Decimals(nil)
+				cli.ErrCheck(err, quiet, "Failed to obtain token decimals")
+			}
+			amount, err := util.StringToTokenValue(uriCreateAmount, decimals)
+			cli.ErrCheck(err, quiet, "Invalid amount")
+
+			req.Target = tokenAddress.Hex()
+			req.Function = "transfer"
+			req.Params = []*erc681.Param{
+				{Type: "address", Value: toAddress.Hex()},
+				{Type: "uint256", Value: erc681.FormatNumber(amount)},
+			}
+		}
+		uri := req.String()
+
+		if quiet {
+			os.Exit(exitSuccess)
+		}
+
+		fmt.Println(uri)
+		if !uriCreateNoQR {
+			qr, err := qrcode.New(uri, qrcode.Medium)
+			cli.ErrCheck(err, quiet, "Failed to generate QR code")
+			fmt.Printf("\n%s", qr.ToSmallString(false))
+		}
+	},
+}
+
+func init() {
+	uriCmd.AddCommand(uriCreateCmd)
+	tokenFlags(uriCreateCmd)
+	uriCreateCmd.Flags().StringVar(&uriCreateTo, "to", "", "Address to which to make the payment")
+	uriCreateCmd.Flags().StringVar(&uriCreateAmount, "amount", "", "Amount of the payment")
+	uriCreateCmd.Flags().StringVar(&uriCreateDecimals, "decimals", "", "Number of decimals for a token transfer (only required if offline)")
+	uriCreateCmd.Flags().BoolVar(&uriCreateNoQR, "noqr", false, "Do not output the QR code")
+}
@@ -0,0 +1,294 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/conn"
+	"github.com/wealdtech/ethereal/v2/util"
+	"github.com/wealdtech/ethereal/v2/util/erc681"
+	string2eth "github.com/wealdtech/go-string2eth"
+)
+
+var (
+	uriParseURI      string
+	uriParseFrom     string
+	uriParseDecimals string
+	uriParseAmount   string
+	uriParseExecute  bool
+	uriParseYes      bool
+)
+
+// uriParseCmd represents the uri parse command.
+var uriParseCmd = &cobra.Command{
+	Use:   "parse",
+	Short: "Parse a payment request URI",
+	Long: `Parse an ERC-681 payment request URI and show the equivalent ethereal command.  For example:
+
+    ethereal uri parse --uri="ethereum:0x52f1A3027d3aA514F17E454C93ae1F79b3B12d5d@1?value=1.5e18"
+
+Requests for Ether and for token transfers are supported.  If the URI contains a chain ID it must match that of the connected node.  If offline, --decimals is required to parse token transfers.
+
+If --execute is supplied then the transfer is made from the address supplied in --from, after confirmation.  --yes will skip the confirmation.  If the URI for an Ether transfer does not contain a value then --amount must be supplied to make the transfer.  A gas price in the URI is used as the maximum fee per gas of the transaction, in which case --max-fee-per-gas cannot be supplied.
+
+This will return an exit status of 0 if the URI is parsed (and the transaction is successfully submitted, and mined if --wait is supplied, if --execute is supplied), 1 if the URI is invalid or the transaction is not successfully submitted, and 2 if the transaction is successfully submitted but not mined within the supplied time limit.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cli.Assert(uriParseURI != "", quiet, "--uri is required")
+		req, err := erc681.Parse(uriParseURI)
+		cli.ErrCheck(err, quiet, "Invalid URI")
+
+		if req.ChainID != nil && !offline {
+			cli.Assert(req.ChainID.Cmp(c.ChainID()) == 0, quiet, fmt.Sprintf("URI is for chain %v but connected to chain %v", req.ChainID, c.ChainID()))
+		}
+
+		if req.GasPrice != nil {
+			cli.Assert(!cmd.Flags().Changed("max-fee-per-gas"), quiet, "--max-fee-per-gas cannot be supplied if the URI contains a gas price")
+			// The gas price of the request is the most that should be paid per gas.
+			viper.Set("max-fee-per-gas", string2eth.WeiToString(req.GasPrice, true))
+		}
+
+		var fromAddress common.Address
+		if uriParseExecute {
+			cli.Assert(uriParseFrom != "", quiet, "--from is required with --execute")
+			fromAddress, err = c.Resolve(uriParseFrom)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve from address %s", uriParseFrom))
+		}
+
+		switch req.Function {
+		case "":
+			uriParseEtherTransfer(req, fromAddress)
+		case "transfer":
+			uriParseTokenTransfer(req, fromAddress)
+		default:
+			cli.Err(quiet, fmt.Sprintf("Unsupported function %s", req.Function))
+		}
+	},
+}
+
+func uriParseEtherTransfer(req *erc681.Request, fromAddress common.Address) {
+	toAddress, err := c.Resolve(req.Target)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve address %s", req.Target))
+	amount := req.Value
+	if uriParseAmount != "" {
+		cli.Assert(amount == nil, quiet, "--amount cannot be supplied when the URI contains a value")
+		amount, err = string2eth.StringToWei(uriParseAmount)
+		cli.ErrCheck(err, quiet, "Invalid amount")
+	}
+	cli.Assert(amount != nil || !uriParseExecute, quiet, "URI does not contain a value; --amount is required with --execute")
+
+	args := []string{
+		fmt.Sprintf("--to=%s", toAddress.Hex()),
+	}
+	if amount != nil {
+		args = append(args, fmt.Sprintf("--amount=%q", string2eth.WeiToString(amount, true)))
+	}
+	if !quiet {
+		outputURIRequest(req)
+		fmt.Printf("Type:\t\tEther transfer\n")
+		fmt.Printf("Recipient:\t%s\n", toAddress.Hex())
+		if amount == nil {
+			fmt.Printf("Amount:\t\tnot specified\n")
+		} else {
+			fmt.Printf("Amount:\t\t%s\n", string2eth.WeiToString(amount, true))
+		}
+		fmt.Printf("Command:\t%s\n", uriParseCommand("ether transfer", args, req))
+	}
+	if !uriParseExecute {
+		os.Exit(exitSuccess)
+	}
+
+	var gasLimit *uint64
+	limit := uint64(viper.GetInt64("gaslimit"))
+	if req.GasLimit != nil {
+		limit = req.GasLimit.Uint64()
+	}
+	if limit > 0 {
+		gasLimit = &limit
+	}
+
+	txData := &conn.TransactionData{
+		From:     fromAddress,
+		To:       &toAddress,
+		Value:    amount,
+		GasLimit: gasLimit,
+	}
+	tx, err := c.CreateTransaction(context.Background(), txData)
+	cli.ErrCheck(err, quiet, "Failed to create transaction")
+
+	if !offline {
+		ctx, cancel := localContext()
+		defer cancel()
+		balance, err := c.Client().BalanceAt(ctx, fromAddress, nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain balance of address from which to send funds")
+		// Cost is the amount plus the maximum fee for the gas.
+		cli.Assert(balance.Cmp(tx.Cost()) >= 0, quiet, fmt.Sprintf("Balance of %s insufficient for transfer and fees of %s", string2eth.WeiToString(balance, true), string2eth.WeiToString(tx.Cost(), true)))
+	}
+	confirmURIExecution()
+
+	signedTx, err := c.SignTransaction(context.Background(), fromAddress, tx)
+	cli.ErrCheck(err, quiet, "Failed to sign transaction")
+	_, err = c.NextNonce(context.Background(), fromAddress)
+	cli.ErrCheck(err, quiet, "Failed to increment nonce")
+
+	if !offline {
+		err = c.SendTransaction(context.Background(), signedTx)
+		cli.ErrCheck(err, quiet, "Failed to send transaction")
+	}
+	handleURITransaction(signedTx, log.Fields{
+		"group":   "uri",
+		"command": "parse",
+	})
+}
+
+func uriParseTokenTransfer(req *erc681.Request, fromAddress common.Address) {
+	cli.Assert(req.Value == nil || req.Value.Sign() == 0, quiet, "Token transfer cannot include a value")
+	recipient, amount, err := req.TokenTransfer()
+	cli.ErrCheck(err, quiet, "Invalid token transfer")
+	toAddress, err := c.Resolve(recipient)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve address %s", recipient))
+
+	tokenAddress, err := tokenContractAddress(req.Target)
+	cli.ErrCheck(err, quiet, "Failed to obtain token contract")
+	token, err := tokenContract(tokenAddress.Hex())
+	cli.ErrCheck(err, quiet, "Failed to obtain token contract")
+
+	var decimals uint8
+	if offline {
+		cli.Assert(uriParseDecimals != "", quiet, "--decimals is required if offline")
+		tmpDecimals, err := strconv.ParseUint(uriParseDecimals, 10, 8)
+		cli.ErrCheck(err, quiet, "Invalid decimals")
+		decimals = uint8(tmpDecimals)
+	} else {
+		decimals, err = token.Decimals(nil)
+		cli.ErrCheck(err, quiet, "Failed to obtain token decimals")
+	}
+
+	args := []string{
+		fmt.Sprintf("--token=%s", tokenAddress.Hex()),
+		fmt.Sprintf("--to=%s", toAddress.Hex()),
+		fmt.Sprintf("--amount=%s", util.TokenValueToString(amount, decimals, false)),
+	}
+	if !quiet {
+		outputURIRequest(req)
+		fmt.Printf("Type:\t\tToken transfer\n")
+		fmt.Printf("Token:\t\t%s\n", tokenAddress.Hex())
+		fmt.Printf("Recipient:\t%s\n", toAddress.Hex())
+		fmt.Printf("Amount:\t\t%s\n", util.TokenValueToString(amount, decimals, false))
+		fmt.Printf("Command:\t%s\n", uriParseCommand("token transfer", args, req))
+	}
+	if !uriParseExecute {
+		os.Exit(exitSuccess)
+	}
+
+	if offline {
+		cli.Assert(req.GasLimit != nil || viper.GetInt64("gaslimit") > 0, quiet, "--gaslimit is required if offline")
+	} else {
+		balance, err := token.BalanceOf(nil, fromAddress)
+		cli.ErrCheck(err, quiet, "Failed to obtain balance of address from which to send funds")
+		cli.Assert(balance.Cmp(amount) >= 0, quiet, fmt.Sprintf("Balance of %s insufficient for transfer", util.TokenValueToString(balance, decimals, false)))
+	}
+	confirmURIExecution()
+
+	opts, err := generateTxOpts(fromAddress)
+	cli.ErrCheck(err, quiet, "Failed to generate transaction options")
+	if req.GasLimit != nil {
+		opts.GasLimit = req.GasLimit.Uint64()
+	}
+
+	signedTx, err := token.Transfer(opts, toAddress, amount)
+	cli.ErrCheck(err, quiet, "Failed to create transaction")
+
+	handleURITransaction(signedTx, log.Fields{
+		"group":          "uri",
+		"command":        "parse",
+		"token":          tokenAddress.Hex(),
+		"tokenholder":    fromAddress.Hex(),
+		"tokenrecipient": toAddress.Hex(),
+		"tokenamount":    amount.String(),
+	})
+}
+
+// outputURIRequest outputs the general parts of a request.
+func outputURIRequest(req *erc681.Request) {
+	if req.ChainID != nil {
+		fmt.Printf("Chain ID:\t%v\n", req.ChainID)
+	}
+	if req.GasLimit != nil {
+		fmt.Printf("Gas limit:\t%v\n", req.GasLimit)
+	}
+	if req.GasPrice != nil {
+		fmt.Printf("Gas price:\t%s\n", string2eth.WeiToString(req.GasPrice, true))
+	}
+}
+
+// uriParseCommand returns the ethereal command equivalent to a request.
+func uriParseCommand(command string, args []string, req *erc681.Request) string {
+	if uriParseFrom != "" {
+		args = append([]string{fmt.Sprintf("--from=%s", uriParseFrom)}, args...)
+	}
+	if req.GasLimit != nil {
+		args = append(args, fmt.Sprintf("--gaslimit=%v", req.GasLimit))
+	}
+	if req.GasPrice != nil {
+		args = append(args, fmt.Sprintf("--max-fee-per-gas=%q", string2eth.WeiToString(req.GasPrice, true)))
+	}
+
+	return fmt.Sprintf("ethereal %s %s", command, strings.Join(args, " "))
+}
+
+// confirmURIExecution obtains confirmation to execute the request if it has
+// not already been supplied.
+func confirmURIExecution() {
+	if uriParseYes {
+		return
+	}
+	obtainConfirmation("execute the transfer", "yes", "yes")
+}
+
+func handleURITransaction(signedTx *types.Transaction, fields log.Fields) {
+	if offline {
+		if !quiet {
+			buf := new(bytes.Buffer)
+			cli.ErrCheck(signedTx.EncodeRLP(buf), quiet, "failed to encode transaction")
+			fmt.Printf("0x%s\n", hex.EncodeToString(buf.Bytes()))
+		}
+		os.Exit(exitSuccess)
+	}
+
+	handleSubmittedTransaction(signedTx, fields, true)
+}
+
+func init() {
+	uriCmd.AddCommand(uriParseCmd)
+	uriParseCmd.Flags().StringVar(&uriParseURI, "uri", "", "ERC-681 payment request URI")
+	uriParseCmd.Flags().StringVar(&uriParseFrom, "from", "", "Address from which to make the transfer")
+	uriParseCmd.Flags().StringVar(&uriParseAmount, "amount", "", "Amount of Ether to transfer, if the URI does not contain a value")
+	uriParseCmd.Flags().StringVar(&uriParseDecimals, "decimals", "", "Number of decimals for a token transfer (only required if offline)")
+	uriParseCmd.Flags().BoolVar(&uriParseExecute, "execute", false, "Make the transfer")
+	uriParseCmd.Flags().BoolVar(&uriParseYes, "yes", false, "Make the transfer without confirmation")
+	addTransactionFlags(uriParseCmd, "the address from which to make the transfer")
+}
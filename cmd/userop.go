@@ -0,0 +1,55 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethereal/v2/util/userop"
+)
+
+var userOpEntryPointStr string
+
+// userOpCmd represents the userop command.
+var userOpCmd = &cobra.Command{
+	Use:   "userop",
+	Short: "Manage ERC-4337 user operations",
+	Long:  `Create, submit and obtain information about ERC-4337 user operations for smart accounts.`,
+}
+
+func init() {
+	RootCmd.AddCommand(userOpCmd)
+	userOpCmd.PersistentFlags().String("bundler", "", "URL of the ERC-4337 bundler")
+	if err := viper.BindPFlag("bundler", userOpCmd.PersistentFlags().Lookup("bundler")); err != nil {
+		panic(err)
+	}
+	userOpCmd.PersistentFlags().StringVar(&userOpEntryPointStr, "entrypoint", userop.EntryPointV07.Hex(), "Address of the ERC-4337 entry point")
+}
+
+// userOpBundler connects to the bundler and obtains the entry point for user operations.
+func userOpBundler(ctx context.Context) (*userop.Bundler, common.Address, error) {
+	entryPoint, err := c.Resolve(userOpEntryPointStr)
+	if err != nil {
+		return nil, common.Address{}, errors.Wrap(err, "failed to resolve entry point")
+	}
+	bundler, err := userop.NewBundler(ctx, viper.GetString("bundler"), entryPoint)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	return bundler, entryPoint, nil
+}
@@ -0,0 +1,81 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/wealdtech/ethereal/v2/cli"
+	ens "github.com/wealdtech/go-ens/v3"
+	string2eth "github.com/wealdtech/go-string2eth"
+)
+
+var userOpReceiptHash string
+
+// userOpReceiptCmd represents the userop receipt command.
+var userOpReceiptCmd = &cobra.Command{
+	Use:   "receipt",
+	Short: "Obtain the receipt for a user operation",
+	Long: `Obtain the receipt for a user operation from a bundler.  For example:
+
+    ethereal userop receipt --hash=0x8b1e4b5a1c7d3a0e9f6c2d4b7a8e5f3c1d9b0a2e4f6c8d0b1a3e5f7c9d2b4a6e --bundler=http://localhost:4337/
+
+In quiet mode this will return 0 if the user operation has been included and succeeded, otherwise 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		cli.Assert(userOpReceiptHash != "", quiet, "--hash is required")
+		cli.Assert(len(common.FromHex(userOpReceiptHash)) == common.HashLength, quiet, "Invalid user operation hash")
+		hash := common.HexToHash(userOpReceiptHash)
+
+		bundler, _, err := userOpBundler(ctx)
+		cli.ErrCheck(err, quiet, "Failed to connect to bundler")
+		defer bundler.Close()
+
+		receipt, err := bundler.Receipt(ctx, hash)
+		cli.ErrCheck(err, quiet, "Failed to obtain user operation receipt")
+		cli.Assert(receipt != nil, quiet, "User operation has not been included")
+
+		if quiet {
+			if receipt.Success {
+				os.Exit(exitSuccess)
+			}
+			os.Exit(exitFailure)
+		}
+
+		fmt.Printf("Sender:\t\t\t%v\n", ens.Format(c.Client(), receipt.Sender))
+		fmt.Printf("Nonce:\t\t\t%v\n", receipt.Nonce)
+		if receipt.Success {
+			fmt.Printf("Result:\t\t\tSucceeded\n")
+		} else {
+			fmt.Printf("Result:\t\t\tFailed\n")
+			if receipt.Reason != "" {
+				fmt.Printf("Reason:\t\t\t%s\n", receipt.Reason)
+			}
+		}
+		fmt.Printf("Transaction:\t\t%s\n", receipt.TransactionHash.Hex())
+		fmt.Printf("Block:\t\t\t%d\n", receipt.BlockNumber)
+		fmt.Printf("Gas used:\t\t%v\n", receipt.ActualGasUsed)
+		fmt.Printf("Cost:\t\t\t%s\n", string2eth.WeiToString(receipt.ActualGasCost, true))
+	},
+}
+
+func init() {
+	userOpCmd.AddCommand(userOpReceiptCmd)
+	userOpReceiptCmd.Flags().StringVar(&userOpReceiptHash, "hash", "", "Hash of the user operation")
+}
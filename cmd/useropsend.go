@@ -0,0 +1,248 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util/contracts"
+	"github.com/wealdtech/ethereal/v2/util/funcparser"
+	"github.com/wealdtech/ethereal/v2/util/userop"
+	string2eth "github.com/wealdtech/go-string2eth"
+)
+
+var (
+	userOpSendAccount       string
+	userOpSendSigner        string
+	userOpSendToAddress     string
+	userOpSendAmount        string
+	userOpSendData          string
+	userOpSendCall          string
+	userOpSendCallData      string
+	userOpSendNonceKey      string
+	userOpSendFactory       string
+	userOpSendFactoryData   string
+	userOpSendPaymaster     string
+	userOpSendPaymasterData string
+)
+
+// userOpSendCmd represents the userop send command.
+var userOpSendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Send a user operation",
+	Long: `Build, sign and send a user operation for a smart account to a bundler.  For example:
+
+    ethereal userop send --account=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --signer=0x2ab7150Bba7D5F181b3aF5623e52b15bB1054845 --to=0xd26114cd6EE289AccF82350c8d8487fedB8A0C07 --amount=0.1ether --bundler=http://localhost:4337/ --passphrase=secret
+
+Contract methods can be called in the same way as 'contract send', for example:
+
+    ethereal userop send --account=0x5FfC014343cd971B7eb70732021E26C35B744cc4 --signer=0x2ab7150Bba7D5F181b3aF5623e52b15bB1054845 --contract=0xd26114cd6EE289AccF82350c8d8487fedB8A0C07 --abi=./erc20.abi --call="transfer(0x2ab7150Bba7D5F181b3aF5623e52b15bB1054845, 10)" --bundler=http://localhost:4337/ --passphrase=secret
+
+The call is made through the account's execute(address,uint256,bytes) function.  Accounts with a different interface can be supplied with the full call data with --calldata.
+
+This will return an exit status of 0 if the user operation is successfully submitted (and included if --wait is supplied), 1 if the user operation is not successfully submitted or fails, and 2 if the user operation is successfully submitted but not included within the supplied time limit.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		cli.Assert(userOpSendAccount != "", quiet, "--account is required")
+		account, err := c.Resolve(userOpSendAccount)
+		cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve account %s", userOpSendAccount))
+
+		callData := userOpCallData()
+
+		bundler, entryPointAddress, err := userOpBundler(ctx)
+		cli.ErrCheck(err, quiet, "Failed to connect to bundler")
+		defer bundler.Close()
+		entryPoint, err := contracts.NewEntryPoint(entryPointAddress, c.Client())
+		cli.ErrCheck(err, quiet, "Failed to obtain entry point contract")
+
+		nonceKey := big.NewInt(0)
+		if userOpSendNonceKey != "" {
+			var ok bool
+			nonceKey, ok = new(big.Int).SetString(userOpSendNonceKey, 10)
+			cli.Assert(ok, quiet, "Invalid nonce key")
+		}
+		nonce, err := entryPoint.GetNonce(nil, account, nonceKey)
+		cli.ErrCheck(err, quiet, "Failed to obtain nonce from entry point")
+
+		maxFeePerGas, maxPriorityFeePerGas, err := c.CalculateFees()
+		cli.ErrCheck(err, quiet, "Failed to calculate fees")
+
+		op := &userop.UserOperation{
+			Sender:               account,
+			Nonce:                nonce,
+			CallData:             callData,
+			MaxFeePerGas:         maxFeePerGas,
+			MaxPriorityFeePerGas: maxPriorityFeePerGas,
+		}
+		if userOpSendFactory != "" {
+			factory, err := c.Resolve(userOpSendFactory)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve factory %s", userOpSendFactory))
+			op.Factory = &factory
+			op.FactoryData, err = hex.DecodeString(strings.TrimPrefix(userOpSendFactoryData, "0x"))
+			cli.ErrCheck(err, quiet, "Failed to parse factory data")
+		}
+		if userOpSendPaymaster != "" {
+			paymaster, err := c.Resolve(userOpSendPaymaster)
+			cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve paymaster %s", userOpSendPaymaster))
+			op.Paymaster = &paymaster
+			op.PaymasterData, err = hex.DecodeString(strings.TrimPrefix(userOpSendPaymasterData, "0x"))
+			cli.ErrCheck(err, quiet, "Failed to parse paymaster data")
+		}
+
+		// Gas is estimated with a dummy signature, as the signature covers the gas limits.
+		op.SetDummySignature()
+		estimate, err := bundler.EstimateGas(ctx, op)
+		cli.ErrCheck(err, quiet, "Failed to estimate gas")
+		op.PreVerificationGas = estimate.PreVerificationGas
+		op.VerificationGasLimit = estimate.VerificationGasLimit
+		op.CallGasLimit = estimate.CallGasLimit
+		if op.Paymaster != nil {
+			op.PaymasterVerificationGasLimit = estimate.PaymasterVerificationGasLimit
+			op.PaymasterPostOpGasLimit = estimate.PaymasterPostOpGasLimit
+		}
+
+		key, err := obtainSigningKey(common.HexToAddress(userOpSendSigner))
+		cli.ErrCheck(err, quiet, "Failed to obtain signing key")
+		cli.ErrCheck(op.Sign(entryPointAddress, c.ChainID(), key), quiet, "Failed to sign user operation")
+
+		hash, err := op.Hash(entryPointAddress, c.ChainID())
+		cli.ErrCheck(err, quiet, "Failed to calculate user operation hash")
+		// Cross-check our hash with that generated by the entry point itself.
+		packed, err := op.Pack()
+		cli.ErrCheck(err, quiet, "Failed to pack user operation")
+		entryPointHash, err := entryPoint.GetUserOpHash(nil, *packed)
+		cli.ErrCheck(err, quiet, "Failed to obtain user operation hash from entry point")
+		cli.Assert(hash == entryPointHash, quiet, fmt.Sprintf("Calculated user operation hash %s does not match that from the entry point %#x", hash.Hex(), entryPointHash))
+
+		if verbose {
+			fmt.Printf("Sender:\t\t\t\t%s\n", op.Sender.Hex())
+			fmt.Printf("Nonce:\t\t\t\t%v\n", op.Nonce)
+			fmt.Printf("Call data:\t\t\t%#x\n", op.CallData)
+			fmt.Printf("Call gas limit:\t\t\t%v\n", op.CallGasLimit)
+			fmt.Printf("Verification gas limit:\t\t%v\n", op.VerificationGasLimit)
+			fmt.Printf("Pre-verification gas:\t\t%v\n", op.PreVerificationGas)
+			fmt.Printf("Max fee per gas:\t\t%v\n", string2eth.WeiToGWeiString(op.MaxFeePerGas))
+			fmt.Printf("Max priority fee per gas:\t%v\n", string2eth.WeiToGWeiString(op.MaxPriorityFeePerGas))
+		}
+
+		sentHash, err := bundler.Send(ctx, op)
+		cli.ErrCheck(err, quiet, "Failed to send user operation")
+		cli.Assert(sentHash == hash, quiet, fmt.Sprintf("Bundler returned user operation hash %s but expected %s", sentHash.Hex(), hash.Hex()))
+
+		setupLogging()
+		log.WithFields(log.Fields{
+			"group":      "userop",
+			"command":    "send",
+			"networkid":  c.ChainID(),
+			"useropid":   hash.Hex(),
+			"entrypoint": entryPointAddress.Hex(),
+			"sender":     op.Sender.Hex(),
+			"nonce":      op.Nonce.String(),
+			"calldata":   hex.EncodeToString(op.CallData),
+		}).Info("user operation submitted")
+
+		if !viper.GetBool("wait") {
+			outputIf(!quiet, hash.Hex())
+			os.Exit(exitSuccess)
+		}
+		receipt, err := bundler.WaitForReceipt(ctx, hash, 5*time.Second, viper.GetDuration("limit"))
+		cli.ErrCheck(err, quiet, "Failed to obtain user operation receipt")
+		if receipt == nil {
+			outputIf(!quiet, fmt.Sprintf("%s submitted but not included", hash.Hex()))
+			os.Exit(exitNotMined)
+		}
+		if !receipt.Success {
+			cli.Err(quiet, fmt.Sprintf("%s included in transaction %s but failed", hash.Hex(), receipt.TransactionHash.Hex()))
+		}
+		outputIf(!quiet, fmt.Sprintf("%s included in transaction %s", hash.Hex(), receipt.TransactionHash.Hex()))
+		os.Exit(exitSuccess)
+	},
+}
+
+// userOpCallData obtains the call data for the account from the supplied flags.
+func userOpCallData() []byte {
+	if userOpSendCallData != "" {
+		cli.Assert(userOpSendToAddress == "" && contractStr == "" && userOpSendAmount == "" && userOpSendData == "" && userOpSendCall == "", quiet, "--calldata cannot be supplied with other call flags")
+		callData, err := hex.DecodeString(strings.TrimPrefix(userOpSendCallData, "0x"))
+		cli.ErrCheck(err, quiet, "Failed to parse call data")
+		return callData
+	}
+
+	toStr := userOpSendToAddress
+	if toStr == "" {
+		toStr = contractStr
+	}
+	cli.Assert(toStr != "", quiet, "--to, --contract or --calldata is required")
+	to, err := c.Resolve(toStr)
+	cli.ErrCheck(err, quiet, fmt.Sprintf("Failed to resolve to address %s", toStr))
+
+	amount := big.NewInt(0)
+	if userOpSendAmount != "" {
+		amount, err = string2eth.StringToWei(userOpSendAmount)
+		cli.ErrCheck(err, quiet, "Invalid amount")
+	}
+
+	var data []byte
+	switch {
+	case userOpSendCall != "":
+		cli.Assert(userOpSendData == "", quiet, "only one of --call and --data can be supplied")
+		contract := parseContract("")
+		method, methodArgs, err := funcparser.ParseCall(c.Client(), contract, userOpSendCall)
+		cli.ErrCheck(err, quiet, "Failed to parse call")
+		data, err = contract.Abi.Pack(method.Name, methodArgs...)
+		cli.ErrCheck(err, quiet, "Failed to convert arguments")
+	case userOpSendData != "":
+		data, err = hex.DecodeString(strings.TrimPrefix(userOpSendData, "0x"))
+		cli.ErrCheck(err, quiet, "Failed to parse data")
+	}
+
+	callData, err := userop.ExecuteCallData(to, amount, data)
+	cli.ErrCheck(err, quiet, "Failed to create call data")
+	return callData
+}
+
+func init() {
+	userOpCmd.AddCommand(userOpSendCmd)
+	contractFlags(userOpSendCmd)
+	userOpSendCmd.Flags().StringVar(&userOpSendAccount, "account", "", "Address of the smart account sending the user operation")
+	userOpSendCmd.Flags().StringVar(&userOpSendSigner, "signer", "", "Address of the owner signing the user operation")
+	userOpSendCmd.Flags().StringVar(&userOpSendToAddress, "to", "", "Address to which the account sends the call (defaults to --contract)")
+	userOpSendCmd.Flags().StringVar(&userOpSendAmount, "amount", "", "Amount of Ether for the account to send with the call")
+	userOpSendCmd.Flags().StringVar(&userOpSendData, "data", "", "data for the call (as a hex string)")
+	userOpSendCmd.Flags().StringVar(&userOpSendCall, "call", "", "Contract function to call")
+	userOpSendCmd.Flags().StringVar(&userOpSendCallData, "calldata", "", "Full call data for the account (as a hex string), if not calling execute(address,uint256,bytes)")
+	userOpSendCmd.Flags().StringVar(&userOpSendNonceKey, "nonce-key", "", "Key of the nonce sequence to use (defaults to 0)")
+	userOpSendCmd.Flags().StringVar(&userOpSendFactory, "factory", "", "Factory with which to deploy the account, if it does not yet exist")
+	userOpSendCmd.Flags().StringVar(&userOpSendFactoryData, "factory-data", "", "Data for the factory (as a hex string)")
+	userOpSendCmd.Flags().StringVar(&userOpSendPaymaster, "paymaster", "", "Paymaster to sponsor the user operation")
+	userOpSendCmd.Flags().StringVar(&userOpSendPaymasterData, "paymaster-data", "", "Data for the paymaster (as a hex string)")
+	addSecretFlags(userOpSendCmd, "the signer")
+	addSeedFlags(userOpSendCmd, "the signer")
+	userOpSendCmd.Flags().String("max-fee-per-gas", "200Gwei", "Maximum fee per gas for the user operation e.g. 15Gwei, 0.000000015ether")
+	userOpSendCmd.Flags().String("priority-fee-per-gas", "1.5Gwei", "Priority fee per gas for the user operation e.g. 1gwei")
+	userOpSendCmd.Flags().Bool("wait", false, "wait for the user operation to be included before returning")
+	userOpSendCmd.Flags().Duration("limit", 0, "maximum time to wait for the user operation to be included before failing (default forever)")
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 	"github.com/wealdtech/ethereal/v2/cli"
+	"github.com/wealdtech/ethereal/v2/util"
 )
 
 // SignTransaction signs the given transaction, returning a signed transaction.
@@ -33,19 +34,28 @@ func (c *Conn) SignTransaction(_ context.Context,
 	*types.Transaction,
 	error,
 ) {
+	passphrase, err := cli.Passphrase()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain passphrase")
+	}
+	privateKey, err := cli.PrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain private key")
+	}
+
 	var signedTx *types.Transaction
 	switch {
-	case viper.GetString("passphrase") != "":
+	case passphrase != "":
 		wallet, account, err := cli.ObtainWalletAndAccount(c.ChainID(), signer)
 		if err != nil {
 			return nil, err
 		}
-		signedTx, err = wallet.SignTxWithPassphrase(*account, viper.GetString("passphrase"), tx, c.ChainID())
+		signedTx, err = wallet.SignTxWithPassphrase(*account, passphrase, tx, c.ChainID())
 		if err != nil {
 			return nil, err
 		}
-	case viper.GetString("privatekey") != "":
-		key, err := crypto.HexToECDSA(strings.TrimPrefix(viper.GetString("privatekey"), "0x"))
+	case privateKey != "":
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
 		if err != nil {
 			return nil, errors.Wrap(err, "invalid private key")
 		}
@@ -57,8 +67,21 @@ func (c *Conn) SignTransaction(_ context.Context,
 		if err != nil {
 			return nil, err
 		}
+	case viper.GetString("seed") != "" || viper.GetString("seed-file") != "":
+		key, err := util.SeedKey()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain key from seed")
+		}
+		keyAddr := crypto.PubkeyToAddress(key.PublicKey)
+		if signer != keyAddr {
+			return nil, errors.New("not authorized to sign this account")
+		}
+		signedTx, err = types.SignTx(tx, types.NewLondonSigner(c.ChainID()), key)
+		if err != nil {
+			return nil, err
+		}
 	default:
-		return nil, errors.New("no passphrase or private key; cannot sign")
+		return nil, errors.New("no passphrase, private key or seed; cannot sign")
 	}
 	return signedTx, nil
 }
@@ -0,0 +1,126 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+// ABIStore is a local store of contract ABIs, keyed by chain ID and contract address.
+// Each ABI is held as a JSON file at <dir>/<chain ID>/<address>.json, with the
+// address in lower case.
+type ABIStore struct {
+	dir string
+}
+
+// NewABIStore creates an ABI store in the given directory.
+func NewABIStore(dir string) *ABIStore {
+	return &ABIStore{dir: dir}
+}
+
+// DefaultABIStoreDir returns the default directory of the ABI store, which is
+// $HOME/.ethereal/abis.
+func DefaultABIStoreDir() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain home directory")
+	}
+
+	return filepath.Join(home, ".ethereal", "abis"), nil
+}
+
+// ABI returns the stored ABI for a contract, or nil if there is no stored ABI.
+func (s *ABIStore) ABI(chainID *big.Int, address common.Address) (*abi.ABI, error) {
+	f, err := os.Open(s.path(chainID, address))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to open stored ABI")
+	}
+	defer f.Close()
+
+	contractAbi, err := abi.JSON(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid stored ABI for %s", address.Hex())
+	}
+
+	return &contractAbi, nil
+}
+
+// Put stores the ABI for a contract, replacing any existing ABI.
+func (s *ABIStore) Put(chainID *big.Int, address common.Address, data []byte) error {
+	if _, err := abi.JSON(bytes.NewReader(data)); err != nil {
+		return errors.Wrap(err, "invalid ABI")
+	}
+
+	path := s.path(chainID, address)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrap(err, "failed to create ABI store")
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return errors.Wrap(err, "failed to write ABI")
+	}
+
+	return nil
+}
+
+// List returns the addresses of the contracts with stored ABIs on a chain.
+func (s *ABIStore) List(chainID *big.Int) ([]common.Address, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, chainID.String()))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []common.Address{}, nil
+		}
+		return nil, errors.Wrap(err, "failed to read ABI store")
+	}
+
+	addresses := make([]common.Address, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if entry.IsDir() || name == entry.Name() || !common.IsHexAddress(name) {
+			continue
+		}
+		addresses = append(addresses, common.HexToAddress(name))
+	}
+
+	return addresses, nil
+}
+
+// Remove removes the stored ABI for a contract.
+func (s *ABIStore) Remove(chainID *big.Int, address common.Address) error {
+	if err := os.Remove(s.path(chainID, address)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no ABI stored for %s", address.Hex())
+		}
+		return errors.Wrap(err, "failed to remove stored ABI")
+	}
+
+	return nil
+}
+
+// path returns the path of the ABI for a contract.
+func (s *ABIStore) path(chainID *big.Int, address common.Address) string {
+	return filepath.Join(s.dir, chainID.String(), strings.ToLower(address.Hex())+".json")
+}
@@ -0,0 +1,77 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestABIStoreABI(t *testing.T) {
+	dir := t.TempDir()
+	address := common.HexToAddress("0x5FfC014343cd971B7eb70732021E26C35B744cc4")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "1"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1", "0x5ffc014343cd971b7eb70732021e26c35b744cc4.json"),
+		[]byte(`[{"type":"function","name":"totalSupply","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"}]`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1", "0x0000000000000000000000000000000000000001.json"), []byte(`{`), 0o600))
+
+	store := NewABIStore(dir)
+
+	contractAbi, err := store.ABI(big.NewInt(1), address)
+	require.NoError(t, err)
+	require.Contains(t, contractAbi.Methods, "totalSupply")
+
+	contractAbi, err = store.ABI(big.NewInt(5), address)
+	require.NoError(t, err)
+	require.Nil(t, contractAbi)
+
+	_, err = store.ABI(big.NewInt(1), common.HexToAddress("0x01"))
+	require.EqualError(t, err, "invalid stored ABI for 0x0000000000000000000000000000000000000001: unexpected EOF")
+}
+
+func TestABIStorePutListRemove(t *testing.T) {
+	store := NewABIStore(filepath.Join(t.TempDir(), "abis"))
+	chainID := big.NewInt(1)
+	address1 := common.HexToAddress("0x5FfC014343cd971B7eb70732021E26C35B744cc4")
+	address2 := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	data := []byte(`[{"type":"function","name":"totalSupply","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"}]`)
+
+	addresses, err := store.List(chainID)
+	require.NoError(t, err)
+	require.Empty(t, addresses)
+
+	require.EqualError(t, store.Put(chainID, address1, []byte(`{`)), "invalid ABI: unexpected EOF")
+	require.NoError(t, store.Put(chainID, address1, data))
+	require.NoError(t, store.Put(chainID, address2, data))
+	require.NoError(t, store.Put(big.NewInt(5), address2, data))
+
+	contractAbi, err := store.ABI(chainID, address1)
+	require.NoError(t, err)
+	require.Contains(t, contractAbi.Methods, "totalSupply")
+
+	addresses, err = store.List(chainID)
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{address2, address1}, addresses)
+
+	require.NoError(t, store.Remove(chainID, address1))
+	require.EqualError(t, store.Remove(chainID, address1), "no ABI stored for 0x5FfC014343cd971B7eb70732021E26C35B744cc4")
+	addresses, err = store.List(chainID)
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{address2}, addresses)
+}
@@ -0,0 +1,325 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseContractJSON parses JSON for a specific contract.  The JSON can be any of:
+//   - the output of solc --combined-json
+//   - the output of solc --standard-json
+//   - a Foundry artifact, as found in out/<file>/<name>.json
+//   - a Hardhat artifact, as found in artifacts/<path>/<file>/<name>.json
+//
+// The name can be prefixed with the source file to disambiguate contracts
+// with the same name, for example "contracts/Token.sol:Token".
+func ParseContractJSON(input string, name string) (*Contract, error) {
+	data, err := readJSONInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	if contracts, exists := fields["contracts"]; exists {
+		var files map[string]json.RawMessage
+		if err := json.Unmarshal(contracts, &files); err != nil {
+			return nil, errors.Wrap(err, "failed to decode contracts")
+		}
+		for key := range files {
+			if strings.Contains(key, ":") {
+				// Keys of combined JSON are <file>:<name>.
+				return parseCombinedJSONContracts(contracts, name)
+			}
+		}
+		return parseStandardJSONContracts(contracts, name)
+	}
+
+	if _, exists := fields["bytecode"]; exists {
+		return parseArtifact(data, name)
+	}
+
+	return nil, errors.New("JSON is not a recognised contract format")
+}
+
+// ContractNames returns the fully-qualified names of the contracts in the
+// JSON, for example "contracts/Token.sol:Token".  The input is in any of the
+// formats accepted by ParseContractJSON.
+func ContractNames(input string) ([]string, error) {
+	data, err := readJSONInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	if contracts, exists := fields["contracts"]; exists {
+		var files map[string]json.RawMessage
+		if err := json.Unmarshal(contracts, &files); err != nil {
+			return nil, errors.Wrap(err, "failed to decode contracts")
+		}
+		for key, value := range files {
+			if strings.Contains(key, ":") {
+				// Keys of combined JSON are <file>:<name>.
+				names = append(names, key)
+				continue
+			}
+			var fileContracts map[string]json.RawMessage
+			if err := json.Unmarshal(value, &fileContracts); err != nil {
+				return nil, errors.Wrap(err, "failed to decode contracts")
+			}
+			for name := range fileContracts {
+				names = append(names, fmt.Sprintf("%s:%s", key, name))
+			}
+		}
+		sort.Strings(names)
+
+		return names, nil
+	}
+
+	if _, exists := fields["bytecode"]; exists {
+		var artifact artifactJSON
+		if err := json.Unmarshal(data, &artifact); err != nil {
+			return nil, errors.Wrap(err, "failed to decode artifact")
+		}
+		if artifact.SourceName != "" && artifact.ContractName != "" {
+			names = append(names, fmt.Sprintf("%s:%s", artifact.SourceName, artifact.ContractName))
+		}
+		if len(artifact.Metadata) > 0 {
+			var metadata artifactMetadataJSON
+			if err := unmarshalEmbeddedJSON(artifact.Metadata, &metadata); err == nil {
+				for file, name := range metadata.Settings.CompilationTarget {
+					names = append(names, fmt.Sprintf("%s:%s", file, name))
+				}
+			}
+		}
+
+		return names, nil
+	}
+
+	return nil, errors.New("JSON is not a recognised contract format")
+}
+
+// bytecodeJSON is the bytecode of a contract in standard JSON and Foundry artifacts.
+type bytecodeJSON struct {
+	Object         string         `json:"object"`
+	LinkReferences LinkReferences `json:"linkReferences"`
+}
+
+type standardJSONContract struct {
+	Abi json.RawMessage `json:"abi"`
+	Evm struct {
+		Bytecode          bytecodeJSON      `json:"bytecode"`
+		DeployedBytecode  bytecodeJSON      `json:"deployedBytecode"`
+		MethodIdentifiers map[string]string `json:"methodIdentifiers"`
+	} `json:"evm"`
+	StorageLayout *StorageLayout `json:"storageLayout"`
+}
+
+func parseStandardJSONContracts(data json.RawMessage, name string) (*Contract, error) {
+	var files map[string]map[string]*standardJSONContract
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, errors.Wrap(err, "failed to decode contracts")
+	}
+
+	file := ""
+	contractName := name
+	if pos := strings.LastIndex(name, ":"); pos != -1 {
+		file, contractName = name[:pos], name[pos+1:]
+	}
+
+	var contractJSON *standardJSONContract
+	for fileKey, contracts := range files {
+		if file != "" && fileKey != file && !strings.HasSuffix(fileKey, "/"+file) {
+			continue
+		}
+		if contract, exists := contracts[contractName]; exists {
+			if contractJSON != nil {
+				return nil, fmt.Errorf("multiple contracts \"%s\" in JSON; use --name to provide the full name of the contract", name)
+			}
+			contractJSON = contract
+		}
+	}
+	if contractJSON == nil {
+		return nil, fmt.Errorf("no contract \"%s\" in JSON; use --name to provide the name of the contract", name)
+	}
+
+	contract := &Contract{
+		Name:              contractName,
+		MethodIdentifiers: contractJSON.Evm.MethodIdentifiers,
+		StorageLayout:     contractJSON.StorageLayout,
+	}
+	if err := contract.setAbi(contractJSON.Abi); err != nil {
+		return nil, err
+	}
+	if err := contract.setBytecode(contractJSON.Evm.Bytecode.Object, contractJSON.Evm.Bytecode.LinkReferences, false); err != nil {
+		return nil, err
+	}
+	if err := contract.setBytecode(contractJSON.Evm.DeployedBytecode.Object, contractJSON.Evm.DeployedBytecode.LinkReferences, true); err != nil {
+		return nil, err
+	}
+	contract.fillMethodIdentifiers()
+
+	return contract, nil
+}
+
+type artifactJSON struct {
+	// Hardhat fields.
+	ContractName           string         `json:"contractName"`
+	SourceName             string         `json:"sourceName"`
+	LinkReferences         LinkReferences `json:"linkReferences"`
+	DeployedLinkReferences LinkReferences `json:"deployedLinkReferences"`
+	// Common fields.
+	Abi              json.RawMessage `json:"abi"`
+	Bytecode         json.RawMessage `json:"bytecode"`
+	DeployedBytecode json.RawMessage `json:"deployedBytecode"`
+	// Foundry fields.
+	MethodIdentifiers map[string]string `json:"methodIdentifiers"`
+	StorageLayout     *StorageLayout    `json:"storageLayout"`
+	Metadata          json.RawMessage   `json:"metadata"`
+}
+
+type artifactMetadataJSON struct {
+	Settings struct {
+		CompilationTarget map[string]string `json:"compilationTarget"`
+	} `json:"settings"`
+}
+
+// parseArtifact parses a single-contract Foundry or Hardhat artifact.
+func parseArtifact(data []byte, name string) (*Contract, error) {
+	var artifact artifactJSON
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return nil, errors.Wrap(err, "failed to decode artifact")
+	}
+
+	if pos := strings.LastIndex(name, ":"); pos != -1 {
+		name = name[pos+1:]
+	}
+	if artifact.ContractName == "" && len(artifact.Metadata) > 0 {
+		// Foundry artifacts provide the name in their metadata.
+		var metadata artifactMetadataJSON
+		if err := unmarshalEmbeddedJSON(artifact.Metadata, &metadata); err == nil {
+			for _, targetName := range metadata.Settings.CompilationTarget {
+				artifact.ContractName = targetName
+			}
+		}
+	}
+	if artifact.ContractName != "" {
+		if name != "" && name != artifact.ContractName {
+			return nil, fmt.Errorf("no contract \"%s\" in JSON; artifact is for %s", name, artifact.ContractName)
+		}
+		name = artifact.ContractName
+	}
+
+	contract := &Contract{
+		Name:              name,
+		MethodIdentifiers: artifact.MethodIdentifiers,
+		StorageLayout:     artifact.StorageLayout,
+	}
+	if err := contract.setAbi(artifact.Abi); err != nil {
+		return nil, err
+	}
+
+	// Hardhat bytecode is a string, Foundry bytecode is an object.
+	bytecode, err := parseArtifactBytecode(artifact.Bytecode, artifact.LinkReferences)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode bytecode")
+	}
+	if err := contract.setBytecode(bytecode.Object, bytecode.LinkReferences, false); err != nil {
+		return nil, err
+	}
+	deployedBytecode, err := parseArtifactBytecode(artifact.DeployedBytecode, artifact.DeployedLinkReferences)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode deployed bytecode")
+	}
+	if err := contract.setBytecode(deployedBytecode.Object, deployedBytecode.LinkReferences, true); err != nil {
+		return nil, err
+	}
+	contract.fillMethodIdentifiers()
+
+	return contract, nil
+}
+
+func parseArtifactBytecode(data json.RawMessage, linkReferences LinkReferences) (*bytecodeJSON, error) {
+	res := &bytecodeJSON{}
+	if len(data) == 0 {
+		return res, nil
+	}
+	if data[0] == '"' {
+		if err := json.Unmarshal(data, &res.Object); err != nil {
+			return nil, err
+		}
+		res.LinkReferences = linkReferences
+
+		return res, nil
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *Contract) setAbi(data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	data, err := embeddedJSON(data)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode json")
+	}
+	if err := json.Unmarshal(data, &c.Abi); err != nil {
+		return errors.Wrap(err, "failed to decode json")
+	}
+	c.AbiJSON = data
+
+	return nil
+}
+
+// setBytecode sets the bytecode of the contract.  Link references supplied
+// with the bytecode take precedence over those found in the bytecode itself.
+func (c *Contract) setBytecode(input string, linkReferences LinkReferences, deployed bool) error {
+	if strings.TrimPrefix(input, "0x") == "" {
+		return nil
+	}
+	bytecode, refs, err := DecodeBytecode(input)
+	if err != nil {
+		return err
+	}
+	if len(linkReferences) > 0 {
+		refs = linkReferences
+	}
+	if deployed {
+		c.DeployedBinary = bytecode
+		c.DeployedLinkReferences = refs
+	} else {
+		c.Binary = bytecode
+		c.LinkReferences = refs
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testArtifactAbi = `[{"inputs":[{"internalType":"uint256","name":"x","type":"uint256"}],"name":"set","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
+	// testArtifactBytecode contains a hashed library placeholder at offset 4.
+	testArtifactBytecode         = `0x60806073__$1ce3d6a6d7a8c4d8a0d0e1ddc0c2c8f1d2$__6000`
+	testArtifactDeployedBytecode = `0x6080604052`
+	testArtifactLinkReferences   = `{"src/Lib.sol":{"Lib":[{"start":4,"length":20}]}}`
+	testArtifactStorageLayout    = `{"storage":[{"astId":3,"contract":"src/Store.sol:Store","label":"x","offset":0,"slot":"0","type":"t_uint256"}],"types":{"t_uint256":{"encoding":"inplace","label":"uint256","numberOfBytes":"32"}}}`
+)
+
+func TestParseContractJSON(t *testing.T) {
+	foundry := `{"abi":` + testArtifactAbi + `,"bytecode":{"object":"` + testArtifactBytecode + `","sourceMap":"","linkReferences":` + testArtifactLinkReferences + `},"deployedBytecode":{"object":"` + testArtifactDeployedBytecode + `","sourceMap":"","linkReferences":{}},"methodIdentifiers":{"set(uint256)":"60fe47b1"},"storageLayout":` + testArtifactStorageLayout + `,"id":0}`
+	hardhat := `{"_format":"hh-sol-artifact-1","contractName":"Store","sourceName":"contracts/Store.sol","abi":` + testArtifactAbi + `,"bytecode":"` + testArtifactBytecode + `","deployedBytecode":"` + testArtifactDeployedBytecode + `","linkReferences":` + testArtifactLinkReferences + `,"deployedLinkReferences":{}}`
+	standard := `{"contracts":{"src/Store.sol":{"Store":{"abi":` + testArtifactAbi + `,"evm":{"bytecode":{"object":"` + testArtifactBytecode[2:] + `","linkReferences":` + testArtifactLinkReferences + `},"deployedBytecode":{"object":"` + testArtifactDeployedBytecode[2:] + `","linkReferences":{}},"methodIdentifiers":{"set(uint256)":"60fe47b1"}},"storageLayout":` + testArtifactStorageLayout + `}},"src/Other.sol":{"Store":{"abi":[]}}},"sources":{}}`
+	combined := `{"contracts":{"src/Store.sol:Store":{"abi":` + testArtifactAbi + `,"bin":"` + testArtifactBytecode[2:] + `","bin-runtime":"` + testArtifactDeployedBytecode[2:] + `","storage-layout":` + testArtifactStorageLayout + `}},"version":"0.8.23"}`
+
+	tmpDir := t.TempDir()
+	foundryFile := filepath.Join(tmpDir, "Store.json")
+	require.NoError(t, os.WriteFile(foundryFile, []byte(foundry), 0o600))
+
+	tests := []struct {
+		name           string
+		input          string
+		contract       string
+		linkReferences LinkReferences
+		storageLayout  bool
+		err            string
+	}{
+		{
+			name:     "Unknown",
+			input:    `{"abi":[]}`,
+			contract: "Store",
+			err:      "JSON is not a recognised contract format",
+		},
+		{
+			name:           "Foundry",
+			input:          foundry,
+			contract:       "Store",
+			linkReferences: LinkReferences{"src/Lib.sol": {"Lib": {{Start: 4, Length: 20}}}},
+			storageLayout:  true,
+		},
+		{
+			name:           "FoundryFile",
+			input:          foundryFile,
+			contract:       "Store",
+			linkReferences: LinkReferences{"src/Lib.sol": {"Lib": {{Start: 4, Length: 20}}}},
+			storageLayout:  true,
+		},
+		{
+			name:           "Hardhat",
+			input:          hardhat,
+			contract:       "Store",
+			linkReferences: LinkReferences{"src/Lib.sol": {"Lib": {{Start: 4, Length: 20}}}},
+		},
+		{
+			name:     "HardhatWrongName",
+			input:    hardhat,
+			contract: "Other",
+			err:      `no contract "Other" in JSON; artifact is for Store`,
+		},
+		{
+			name:     "StandardAmbiguous",
+			input:    standard,
+			contract: "Store",
+			err:      `multiple contracts "Store" in JSON; use --name to provide the full name of the contract`,
+		},
+		{
+			name:           "Standard",
+			input:          standard,
+			contract:       "src/Store.sol:Store",
+			linkReferences: LinkReferences{"src/Lib.sol": {"Lib": {{Start: 4, Length: 20}}}},
+			storageLayout:  true,
+		},
+		{
+			name:     "StandardMissing",
+			input:    standard,
+			contract: "Missing",
+			err:      `no contract "Missing" in JSON; use --name to provide the name of the contract`,
+		},
+		{
+			name:           "Combined",
+			input:          combined,
+			contract:       "Store",
+			linkReferences: LinkReferences{"": {"$1ce3d6a6d7a8c4d8a0d0e1ddc0c2c8f1d2$": {{Start: 4, Length: 20}}}},
+			storageLayout:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			contract, err := ParseContractJSON(test.input, test.contract)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "Store", contract.Name)
+			require.Contains(t, contract.Abi.Methods, "set")
+			require.JSONEq(t, testArtifactAbi, string(contract.AbiJSON))
+			require.Len(t, contract.Binary, 26)
+			require.Equal(t, make([]byte, 20), contract.Binary[4:24])
+			require.Equal(t, []byte{0x60, 0x80, 0x60, 0x40, 0x52}, contract.DeployedBinary)
+			require.Equal(t, test.linkReferences, contract.LinkReferences)
+			require.Nil(t, contract.DeployedLinkReferences)
+			require.Equal(t, map[string]string{"set(uint256)": "60fe47b1"}, contract.MethodIdentifiers)
+			if test.storageLayout {
+				require.NotNil(t, contract.StorageLayout)
+				require.Len(t, contract.StorageLayout.Storage, 1)
+				require.Equal(t, "x", contract.StorageLayout.Storage[0].Label)
+				require.Equal(t, "32", contract.StorageLayout.Types["t_uint256"].NumberOfBytes)
+			} else {
+				require.Nil(t, contract.StorageLayout)
+			}
+		})
+	}
+}
+
+func TestDecodeBytecode(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		bytecode       []byte
+		linkReferences LinkReferences
+		err            string
+	}{
+		{
+			name:     "Plain",
+			input:    "0x6001",
+			bytecode: []byte{0x60, 0x01},
+		},
+		{
+			name:           "LegacyPlaceholder",
+			input:          "73__Lib.sol:Lib___________________________ff",
+			bytecode:       append(append([]byte{0x73}, make([]byte, 20)...), 0xff),
+			linkReferences: LinkReferences{"Lib.sol": {"Lib": {{Start: 1, Length: 20}}}},
+		},
+		{
+			name:  "TruncatedPlaceholder",
+			input: "73__$1ce3d6a6$__",
+			err:   "invalid library placeholder in bytecode",
+		},
+		{
+			name:  "BadHex",
+			input: "0x6g",
+			err:   "failed to decode bytecode: encoding/hex: invalid byte: U+0067 'g'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bytecode, linkReferences, err := DecodeBytecode(test.input)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.bytecode, bytecode)
+			require.Equal(t, test.linkReferences, linkReferences)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// missingStateMessages are the error messages with which nodes report that they do not hold state for a block.
+var missingStateMessages = []string{
+	"missing trie node",
+	"historical state",
+	"state is not available",
+	"state not available",
+	"state histories haven't been fully indexed",
+}
+
+// HeaderBackend is the backend required to obtain block headers.
+type HeaderBackend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// BlockAtTime returns the header of the last block with a timestamp at or
+// before the given time.
+func BlockAtTime(ctx context.Context, backend HeaderBackend, timestamp time.Time) (*types.Header, error) {
+	latest, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain latest block")
+	}
+	if !timestamp.Before(time.Unix(int64(latest.Time), 0)) {
+		return latest, nil
+	}
+
+	low, err := backend.HeaderByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain genesis block")
+	}
+	if timestamp.Before(time.Unix(int64(low.Time), 0)) {
+		return nil, fmt.Errorf("%s is before the genesis block", timestamp.Format(time.RFC3339))
+	}
+
+	return BlockAtTimeBetween(ctx, backend, timestamp, low, latest)
+}
+
+// BlockAtTimeBetween returns the header of the last block with a timestamp at
+// or before the given time, searching between the given blocks.  The time
+// must not be before that of the low block.
+func BlockAtTimeBetween(ctx context.Context, backend HeaderBackend, timestamp time.Time, low *types.Header, high *types.Header) (*types.Header, error) {
+	if timestamp.Before(time.Unix(int64(low.Time), 0)) {
+		return nil, fmt.Errorf("%s is before block %d", timestamp.Format(time.RFC3339), low.Number.Uint64())
+	}
+	if !timestamp.Before(time.Unix(int64(high.Time), 0)) {
+		return high, nil
+	}
+
+	// low is at or before the time and high is after it.
+	highNumber := high.Number.Uint64()
+	for highNumber-low.Number.Uint64() > 1 {
+		mid := low.Number.Uint64() + (highNumber-low.Number.Uint64())/2
+		header, err := backend.HeaderByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to obtain block %d", mid)
+		}
+		if timestamp.Before(time.Unix(int64(header.Time), 0)) {
+			highNumber = mid
+		} else {
+			low = header
+		}
+	}
+
+	return low, nil
+}
+
+// BlocksAtInterval returns the numbers of the blocks at each interval from the
+// time of the first block to the time of the last, being the last block at or
+// before each time.  Blocks are not repeated if the interval is shorter than
+// the time between blocks.
+func BlocksAtInterval(ctx context.Context, backend HeaderBackend, first *types.Header, last *types.Header, interval time.Duration) ([]uint64, error) {
+	if interval <= 0 {
+		return nil, errors.New("interval must be positive")
+	}
+
+	blocks := []uint64{first.Number.Uint64()}
+	low := first
+	end := time.Unix(int64(last.Time), 0)
+	for timestamp := time.Unix(int64(first.Time), 0).Add(interval); !timestamp.After(end); timestamp = timestamp.Add(interval) {
+		header, err := BlockAtTimeBetween(ctx, backend, timestamp, low, last)
+		if err != nil {
+			return nil, err
+		}
+		if header.Number.Uint64() != blocks[len(blocks)-1] {
+			blocks = append(blocks, header.Number.Uint64())
+		}
+		low = header
+	}
+
+	return blocks, nil
+}
+
+// IsMissingStateError returns true if the error is a node reporting that it
+// does not hold state for the requested block, as is the case for older blocks
+// on non-archive nodes.
+func IsMissingStateError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, missingStateMessage := range missingStateMessages {
+		if strings.Contains(msg, missingStateMessage) {
+			return true
+		}
+	}
+
+	return false
+}
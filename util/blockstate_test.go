@@ -0,0 +1,157 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// headerBackend serves headers with the given timestamps.
+type headerBackend []uint64
+
+func (b headerBackend) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		number = big.NewInt(int64(len(b) - 1))
+	}
+
+	return &types.Header{Number: number, Time: b[number.Uint64()]}, nil
+}
+
+func TestBlockAtTime(t *testing.T) {
+	// Blocks every 12 seconds from 1000, with a missed slot after block 4.
+	backend := headerBackend{1000, 1012, 1024, 1036, 1048, 1072, 1084, 1096}
+
+	tests := []struct {
+		name      string
+		timestamp int64
+		block     uint64
+		err       string
+	}{
+		{
+			name:      "BeforeGenesis",
+			timestamp: 999,
+			err:       "1970-01-01T00:16:39Z is before the genesis block",
+		},
+		{
+			name:      "Genesis",
+			timestamp: 1000,
+			block:     0,
+		},
+		{
+			name:      "BetweenBlocks",
+			timestamp: 1030,
+			block:     2,
+		},
+		{
+			name:      "Exact",
+			timestamp: 1036,
+			block:     3,
+		},
+		{
+			name:      "MissedSlot",
+			timestamp: 1060,
+			block:     4,
+		},
+		{
+			name:      "Latest",
+			timestamp: 1096,
+			block:     7,
+		},
+		{
+			name:      "Future",
+			timestamp: 2000,
+			block:     7,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header, err := BlockAtTime(context.Background(), backend, time.Unix(test.timestamp, 0).UTC())
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.block, header.Number.Uint64())
+			}
+		})
+	}
+}
+
+func TestBlocksAtInterval(t *testing.T) {
+	// Blocks every 12 seconds from 1000, with missed slots after blocks 4 and 5.
+	backend := headerBackend{1000, 1012, 1024, 1036, 1048, 1072, 1096, 1108}
+
+	tests := []struct {
+		name     string
+		first    uint64
+		last     uint64
+		interval time.Duration
+		blocks   []uint64
+	}{
+		{
+			name:     "Minute",
+			first:    0,
+			last:     7,
+			interval: time.Minute,
+			blocks:   []uint64{0, 4},
+		},
+		{
+			name:     "BlockTime",
+			first:    1,
+			last:     7,
+			interval: 12 * time.Second,
+			blocks:   []uint64{1, 2, 3, 4, 5, 6, 7},
+		},
+		{
+			name:     "Short",
+			first:    3,
+			last:     6,
+			interval: 5 * time.Second,
+			blocks:   []uint64{3, 4, 5, 6},
+		},
+		{
+			name:     "Long",
+			first:    0,
+			last:     7,
+			interval: time.Hour,
+			blocks:   []uint64{0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, err := backend.HeaderByNumber(context.Background(), new(big.Int).SetUint64(test.first))
+			require.NoError(t, err)
+			last, err := backend.HeaderByNumber(context.Background(), new(big.Int).SetUint64(test.last))
+			require.NoError(t, err)
+			blocks, err := BlocksAtInterval(context.Background(), backend, first, last, test.interval)
+			require.NoError(t, err)
+			require.Equal(t, test.blocks, blocks)
+		})
+	}
+}
+
+func TestIsMissingStateError(t *testing.T) {
+	require.False(t, IsMissingStateError(nil))
+	require.False(t, IsMissingStateError(errors.New("execution reverted")))
+	require.True(t, IsMissingStateError(errors.New("missing trie node 1b2c3d (path ) state 0x1b2c3d is not available, not found")))
+	require.True(t, IsMissingStateError(errors.New("historical state not available in path scheme yet")))
+}
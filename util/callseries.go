@@ -0,0 +1,101 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// CallSeriesBackend is the backend required to make a call across blocks.
+type CallSeriesBackend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// CallSeriesPoint is the result of a call at a block.
+type CallSeriesPoint struct {
+	Block uint64
+	// Time is the timestamp of the block.
+	Time   uint64
+	Output []byte
+	// Err is the error from the call, if it failed.
+	Err error
+}
+
+// CallSeries makes the same call at each of the given blocks, running up to
+// the given number of calls concurrently, and passes the results to the
+// handler in the order of the blocks.  The requests for each block are
+// subject to the given timeout, if it is positive.  Failure to obtain a block
+// is returned as an error, whereas failure of the call is passed to the
+// handler.
+func CallSeries(ctx context.Context, backend CallSeriesBackend, call ethereum.CallMsg, blocks []uint64, concurrency int, timeout time.Duration, handler func(*CallSeriesPoint) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	for len(blocks) > 0 {
+		// Make a window of calls concurrently.
+		window := concurrency
+		if window > len(blocks) {
+			window = len(blocks)
+		}
+		points := make([]*CallSeriesPoint, window)
+		errs := make([]error, window)
+		var wg sync.WaitGroup
+		for i := 0; i < window; i++ {
+			wg.Add(1)
+			go func(i int, block uint64) {
+				defer wg.Done()
+				ctx := ctx
+				if timeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, timeout)
+					defer cancel()
+				}
+				blockNumber := new(big.Int).SetUint64(block)
+				header, err := backend.HeaderByNumber(ctx, blockNumber)
+				if err != nil {
+					errs[i] = errors.Wrapf(err, "failed to obtain block %d", block)
+					return
+				}
+				point := &CallSeriesPoint{
+					Block: block,
+					Time:  header.Time,
+				}
+				point.Output, point.Err = backend.CallContract(ctx, call, blockNumber)
+				points[i] = point
+			}(i, blocks[i])
+		}
+		wg.Wait()
+
+		for i := range points {
+			if errs[i] != nil {
+				return errs[i]
+			}
+			if err := handler(points[i]); err != nil {
+				return err
+			}
+		}
+		blocks = blocks[window:]
+	}
+
+	return nil
+}
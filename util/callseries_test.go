@@ -0,0 +1,113 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// callSeriesBackend returns the block number from calls, failing before block 3.
+type callSeriesBackend struct{}
+
+func (callSeriesBackend) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	if number.Uint64() > 100 {
+		return nil, errors.New("not found")
+	}
+
+	return &types.Header{Number: number, Time: 1000 + 12*number.Uint64()}, nil
+}
+
+func (callSeriesBackend) CallContract(ctx context.Context, _ ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if blockNumber.Uint64() == 50 {
+		// Node hangs.
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if blockNumber.Uint64() < 3 {
+		return nil, errors.New("execution reverted")
+	}
+
+	return common.BigToHash(blockNumber).Bytes(), nil
+}
+
+func TestCallSeries(t *testing.T) {
+	tests := []struct {
+		name        string
+		blocks      []uint64
+		concurrency int
+		err         string
+	}{
+		{
+			name:        "Sequential",
+			blocks:      []uint64{1, 2, 3, 4, 5},
+			concurrency: 1,
+		},
+		{
+			name:        "Concurrent",
+			blocks:      []uint64{1, 3, 5, 7, 9, 11, 13},
+			concurrency: 3,
+		},
+		{
+			name:        "MissingBlock",
+			blocks:      []uint64{99, 100, 101},
+			concurrency: 2,
+			err:         "failed to obtain block 101: not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blocks := make([]uint64, 0)
+			err := CallSeries(context.Background(), callSeriesBackend{}, ethereum.CallMsg{}, test.blocks, test.concurrency, 0, func(point *CallSeriesPoint) error {
+				blocks = append(blocks, point.Block)
+				require.Equal(t, 1000+12*point.Block, point.Time)
+				if point.Block < 3 {
+					require.EqualError(t, point.Err, "execution reverted")
+				} else {
+					require.NoError(t, point.Err)
+					require.Equal(t, common.BigToHash(new(big.Int).SetUint64(point.Block)).Bytes(), point.Output)
+				}
+				return nil
+			})
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.blocks, blocks)
+		})
+	}
+}
+
+func TestCallSeriesTimeout(t *testing.T) {
+	var points []*CallSeriesPoint
+	err := CallSeries(context.Background(), callSeriesBackend{}, ethereum.CallMsg{}, []uint64{49, 50, 51}, 2, 10*time.Millisecond, func(point *CallSeriesPoint) error {
+		points = append(points, point)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	require.NoError(t, points[0].Err)
+	require.ErrorIs(t, points[1].Err, context.DeadlineExceeded)
+	require.NoError(t, points[2].Err)
+}
@@ -2,3 +2,5 @@ package contracts
 
 //go:generate abigen -abi ERC20.abi -out erc20.go -pkg contracts -type ERC20
 //go:generate abigen -abi eth2deposit.abi -out eth2deposit.go -pkg contracts -type Eth2Deposit
+//go:generate abigen -abi Safe.abi -out safe.go -pkg contracts -type Safe
+//go:generate abigen -abi EntryPoint.abi -out entrypoint.go -pkg contracts -type EntryPoint
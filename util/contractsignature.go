@@ -0,0 +1,178 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+var (
+	// erc1271MagicValue is returned by isValidSignature() for a valid signature.
+	erc1271MagicValue = crypto.Keccak256([]byte("isValidSignature(bytes32,bytes)"))[:4]
+
+	// erc6492Suffix marks a signature as wrapped for a counterfactual contract.
+	erc6492Suffix = common.FromHex("0x6492649264926492649264926492649264926492649264926492649264926492")
+
+	// erc6492Validator is creation code that calls factory with factoryData, then calls
+	// isValidSignature on the signer and returns the result.  It is run with eth_call,
+	// so nothing is deployed.  It is followed by the ABI-encoded factory and signer, the
+	// length of and the factory data, and finally the call data for isValidSignature().
+	erc6492Validator = common.FromHex("0x604538036045600039" +
+		"6000600060405160606000600051" + "5af150" +
+		"6000600060405160a50138036040516060016000602051" + "5af1" +
+		"3d60006000" + "3e" +
+		"604057" + "3d6000fd" +
+		"5b" + "3d6000f3")
+)
+
+// IsERC6492Signature returns true if the signature is wrapped as per ERC-6492.
+func IsERC6492Signature(signature []byte) bool {
+	return len(signature) > len(erc6492Suffix) && bytes.HasSuffix(signature, erc6492Suffix)
+}
+
+// UnwrapERC6492Signature unwraps an ERC-6492 signature, returning the factory, factory
+// data and inner signature.
+func UnwrapERC6492Signature(signature []byte) (common.Address, []byte, []byte, error) {
+	if !IsERC6492Signature(signature) {
+		return common.Address{}, nil, nil, errors.New("not an ERC-6492 signature")
+	}
+	vals, err := erc6492Arguments().Unpack(signature[:len(signature)-len(erc6492Suffix)])
+	if err != nil {
+		return common.Address{}, nil, nil, errors.Wrap(err, "invalid ERC-6492 signature")
+	}
+	factory, ok := vals[0].(common.Address)
+	if !ok {
+		return common.Address{}, nil, nil, errors.New("invalid ERC-6492 factory")
+	}
+	factoryData, ok := vals[1].([]byte)
+	if !ok {
+		return common.Address{}, nil, nil, errors.New("invalid ERC-6492 factory data")
+	}
+	innerSignature, ok := vals[2].([]byte)
+	if !ok {
+		return common.Address{}, nil, nil, errors.New("invalid ERC-6492 inner signature")
+	}
+
+	return factory, factoryData, innerSignature, nil
+}
+
+// WrapERC6492Signature wraps a signature as per ERC-6492.
+func WrapERC6492Signature(factory common.Address, factoryData []byte, signature []byte) ([]byte, error) {
+	data, err := erc6492Arguments().Pack(factory, factoryData, signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wrap signature")
+	}
+	return append(data, erc6492Suffix...), nil
+}
+
+// VerifyContractSignature verifies a signature from a contract, using ERC-1271
+// for deployed contracts and ERC-6492 for contracts yet to be deployed.
+func VerifyContractSignature(ctx context.Context,
+	caller bind.ContractCaller,
+	signer common.Address,
+	hash []byte,
+	signature []byte,
+) (
+	bool,
+	error,
+) {
+	code, err := caller.CodeAt(ctx, signer, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to obtain signer code")
+	}
+
+	var factory common.Address
+	var factoryData []byte
+	if IsERC6492Signature(signature) {
+		factory, factoryData, signature, err = UnwrapERC6492Signature(signature)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	callData, err := isValidSignatureCallData(hash, signature)
+	if err != nil {
+		return false, err
+	}
+
+	var res []byte
+	switch {
+	case len(code) > 0:
+		res, err = caller.CallContract(ctx, ethereum.CallMsg{
+			To:   &signer,
+			Data: callData,
+		}, nil)
+	case factoryData != nil:
+		res, err = caller.CallContract(ctx, ethereum.CallMsg{
+			Data: erc6492ValidatorCode(factory, signer, factoryData, callData),
+		}, nil)
+	default:
+		return false, errors.New("signer is not a contract")
+	}
+	if err != nil {
+		// A revert is an invalid signature.
+		return false, nil
+	}
+
+	return len(res) >= 4 && bytes.Equal(res[:4], erc1271MagicValue), nil
+}
+
+// isValidSignatureCallData returns the call data for isValidSignature(hash, signature).
+func isValidSignatureCallData(hash []byte, signature []byte) ([]byte, error) {
+	if len(hash) != common.HashLength {
+		return nil, errors.New("hash must be 32 bytes")
+	}
+	bytes32Type, err := abi.NewType("bytes32", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	bytesType, err := abi.NewType("bytes", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	args, err := abi.Arguments{{Type: bytes32Type}, {Type: bytesType}}.Pack(common.BytesToHash(hash), signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode isValidSignature call")
+	}
+
+	return append(append([]byte{}, erc1271MagicValue...), args...), nil
+}
+
+// erc6492ValidatorCode returns the validator code along with its arguments.
+func erc6492ValidatorCode(factory common.Address, signer common.Address, factoryData []byte, callData []byte) []byte {
+	code := make([]byte, 0, len(erc6492Validator)+96+len(factoryData)+len(callData))
+	code = append(code, erc6492Validator...)
+	code = append(code, common.LeftPadBytes(factory.Bytes(), 32)...)
+	code = append(code, common.LeftPadBytes(signer.Bytes(), 32)...)
+	code = append(code, common.LeftPadBytes(big.NewInt(int64(len(factoryData))).Bytes(), 32)...)
+	code = append(code, factoryData...)
+	code = append(code, callData...)
+
+	return code
+}
+
+func erc6492Arguments() abi.Arguments {
+	addressType, _ := abi.NewType("address", "", nil)
+	bytesType, _ := abi.NewType("bytes", "", nil)
+	return abi.Arguments{{Type: addressType}, {Type: bytesType}, {Type: bytesType}}
+}
@@ -0,0 +1,152 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// validAccountCode returns the ERC-1271 magic value for all calls.
+	validAccountCode = common.FromHex("0x631626ba7e60e01b60005260206000f3")
+	// invalidAccountCode returns 0xffffffff for all calls.
+	invalidAccountCode = common.FromHex("0x63ffffffff60e01b60005260206000f3")
+	// factoryCode creates a contract using its call data as creation code.
+	factoryCode = common.FromHex("0x3660006000373660006000f000")
+)
+
+// accountCreationCode returns creation code for 16-byte runtime code.
+func accountCreationCode(runtime []byte) []byte {
+	return append(common.FromHex("0x6010600c60003960106000f3"), runtime...)
+}
+
+func TestERC6492Wrapping(t *testing.T) {
+	factory := common.HexToAddress("0x5FfC014343cd971B7eb70732021E26C35B744cc4")
+	signature := []byte{0x01, 0x02, 0x03}
+	wrapped, err := WrapERC6492Signature(factory, []byte{0xaa}, signature)
+	require.NoError(t, err)
+	require.True(t, IsERC6492Signature(wrapped))
+	require.False(t, IsERC6492Signature(signature))
+
+	unwrappedFactory, factoryData, unwrappedSignature, err := UnwrapERC6492Signature(wrapped)
+	require.NoError(t, err)
+	require.Equal(t, factory, unwrappedFactory)
+	require.Equal(t, []byte{0xaa}, factoryData)
+	require.Equal(t, signature, unwrappedSignature)
+
+	_, _, _, err = UnwrapERC6492Signature(signature)
+	require.EqualError(t, err, "not an ERC-6492 signature")
+	_, _, _, err = UnwrapERC6492Signature(append([]byte{0x01}, erc6492Suffix...))
+	require.Error(t, err)
+}
+
+func TestVerifyContractSignature(t *testing.T) {
+	validAccount := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	invalidAccount := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	factory := common.HexToAddress("0x1000000000000000000000000000000000000003")
+	eoa := common.HexToAddress("0x1000000000000000000000000000000000000004")
+	// Contracts are created with nonce 1, so the first contract created by the factory is at nonce 1.
+	counterfactual := crypto.CreateAddress(factory, 1)
+
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		validAccount:   {Code: validAccountCode, Balance: big.NewInt(0)},
+		invalidAccount: {Code: invalidAccountCode, Balance: big.NewInt(0)},
+		factory:        {Code: factoryCode, Balance: big.NewInt(0), Nonce: 1},
+	}, 30000000)
+	defer backend.Close()
+
+	hash := crypto.Keccak256([]byte("message"))
+	signature := []byte{0x01, 0x02, 0x03}
+	validWrapped, err := WrapERC6492Signature(factory, accountCreationCode(validAccountCode), signature)
+	require.NoError(t, err)
+	invalidWrapped, err := WrapERC6492Signature(factory, accountCreationCode(invalidAccountCode), signature)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		signer    common.Address
+		hash      []byte
+		signature []byte
+		valid     bool
+		err       string
+	}{
+		{
+			name:      "Valid",
+			signer:    validAccount,
+			hash:      hash,
+			signature: signature,
+			valid:     true,
+		},
+		{
+			name:      "Invalid",
+			signer:    invalidAccount,
+			hash:      hash,
+			signature: signature,
+		},
+		{
+			name:      "NotContract",
+			signer:    eoa,
+			hash:      hash,
+			signature: signature,
+			err:       "signer is not a contract",
+		},
+		{
+			name:      "HashShort",
+			signer:    validAccount,
+			hash:      hash[1:],
+			signature: signature,
+			err:       "hash must be 32 bytes",
+		},
+		{
+			name:      "WrappedDeployed",
+			signer:    validAccount,
+			hash:      hash,
+			signature: invalidWrapped,
+			valid:     true,
+		},
+		{
+			name:      "WrappedCounterfactual",
+			signer:    counterfactual,
+			hash:      hash,
+			signature: validWrapped,
+			valid:     true,
+		},
+		{
+			name:      "WrappedCounterfactualInvalid",
+			signer:    counterfactual,
+			hash:      hash,
+			signature: invalidWrapped,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			valid, err := VerifyContractSignature(context.Background(), backend, test.signer, test.hash, test.signature)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.valid, valid)
+			}
+		})
+	}
+}
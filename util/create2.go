@@ -0,0 +1,86 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+var (
+	// DeterministicDeploymentProxy is the address of the deterministic deployment proxy, which
+	// is the same on all chains.
+	DeterministicDeploymentProxy = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+
+	// DeterministicDeploymentProxyDeployer is the address that deploys the deterministic deployment proxy.
+	DeterministicDeploymentProxyDeployer = common.HexToAddress("0x3fAB184622Dc19b6109349B94811493BF2a45362")
+
+	// DeterministicDeploymentProxyCost is the amount of Ether that the deployer requires to
+	// deploy the deterministic deployment proxy.
+	DeterministicDeploymentProxyCost = big.NewInt(10000000000000000)
+
+	// deterministicDeploymentProxyTx is the presigned transaction that deploys the deterministic
+	// deployment proxy.  It is not replay-protected, so can be used on any chain.
+	deterministicDeploymentProxyTx = common.FromHex("0xf8a58085174876e800830186a08080b853604580600e600039806000f350fe7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf31ba02222222222222222222222222222222222222222222222222222222222222222a02222222222222222222222222222222222222222222222222222222222222222")
+)
+
+// DeterministicDeploymentProxyTransaction returns the presigned transaction that
+// deploys the deterministic deployment proxy.
+func DeterministicDeploymentProxyTransaction() (*types.Transaction, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(deterministicDeploymentProxyTx); err != nil {
+		return nil, errors.Wrap(err, "failed to decode deterministic deployment proxy transaction")
+	}
+
+	return tx, nil
+}
+
+// ParseSalt parses a hex salt for CREATE2, left-padding it to 32 bytes.
+func ParseSalt(input string) ([32]byte, error) {
+	var salt [32]byte
+	input = strings.TrimPrefix(input, "0x")
+	if len(input)%2 == 1 {
+		input = "0" + input
+	}
+	data, err := hex.DecodeString(input)
+	if err != nil {
+		return salt, errors.Wrap(err, "invalid salt")
+	}
+	if len(data) > len(salt) {
+		return salt, errors.New("salt must be no more than 32 bytes")
+	}
+	copy(salt[len(salt)-len(data):], data)
+
+	return salt, nil
+}
+
+// Create2Address returns the address of a contract deployed with CREATE2.
+func Create2Address(factory common.Address, salt [32]byte, initCode []byte) common.Address {
+	return crypto.CreateAddress2(factory, salt, crypto.Keccak256(initCode))
+}
+
+// Create2CallData returns the data to send to a CREATE2 factory such as the
+// deterministic deployment proxy, which is the salt followed by the init code.
+func Create2CallData(salt [32]byte, initCode []byte) []byte {
+	data := make([]byte, 0, len(salt)+len(initCode))
+	data = append(data, salt[:]...)
+
+	return append(data, initCode...)
+}
@@ -0,0 +1,114 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeterministicDeploymentProxyTransaction(t *testing.T) {
+	tx, err := DeterministicDeploymentProxyTransaction()
+	require.NoError(t, err)
+	require.False(t, tx.Protected())
+
+	sender, err := types.Sender(types.HomesteadSigner{}, tx)
+	require.NoError(t, err)
+	require.Equal(t, DeterministicDeploymentProxyDeployer, sender)
+	require.Equal(t, DeterministicDeploymentProxy, crypto.CreateAddress(sender, tx.Nonce()))
+	require.Equal(t, DeterministicDeploymentProxyCost, new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas())))
+}
+
+func TestParseSalt(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		salt  [32]byte
+		err   string
+	}{
+		{
+			name:  "Empty",
+			input: "",
+		},
+		{
+			name:  "Short",
+			input: "0x1",
+			salt:  [32]byte{31: 0x01},
+		},
+		{
+			name:  "Full",
+			input: "0x0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20",
+			salt:  [32]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32},
+		},
+		{
+			name:  "Long",
+			input: "0x0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021",
+			err:   "salt must be no more than 32 bytes",
+		},
+		{
+			name:  "Invalid",
+			input: "0xzz",
+			err:   "invalid salt: encoding/hex: invalid byte: U+007A 'z'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			salt, err := ParseSalt(test.input)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.salt, salt)
+			}
+		})
+	}
+}
+
+func TestCreate2Address(t *testing.T) {
+	// Example 1 from EIP-1014.
+	require.Equal(t,
+		common.HexToAddress("0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"),
+		Create2Address(common.Address{}, [32]byte{}, []byte{0x00}),
+	)
+}
+
+func TestDeterministicDeploymentProxy(t *testing.T) {
+	ctx := context.Background()
+	tx, err := DeterministicDeploymentProxyTransaction()
+	require.NoError(t, err)
+	// The runtime code of the proxy is at the end of its creation code.
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		DeterministicDeploymentProxy: {Code: tx.Data()[14:], Balance: big.NewInt(0)},
+	}, 30000000)
+	defer backend.Close()
+
+	initCode := common.FromHex("0x6010600c60003960106000f300000000000000000000000000000000")
+	salt := [32]byte{31: 0x01}
+	res, err := backend.CallContract(ctx, ethereum.CallMsg{
+		To:   &DeterministicDeploymentProxy,
+		Data: Create2CallData(salt, initCode),
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, Create2Address(DeterministicDeploymentProxy, salt, initCode), common.BytesToAddress(res))
+}
@@ -0,0 +1,256 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package erc681 provides support for ERC-681 payment request URIs.
+package erc681
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const scheme = "ethereum:"
+
+var numberRegex = regexp.MustCompile(`^([0-9]+)(?:\.([0-9]+))?(?:[eE]([0-9]+))?$`)
+
+// Param is a parameter for a function call.
+type Param struct {
+	Type  string
+	Value string
+}
+
+// Request is an ERC-681 payment request.
+type Request struct {
+	// Pay is true if the URI has the "pay-" prefix.
+	Pay bool
+	// Target is the address or ENS name of the target of the request.
+	Target   string
+	ChainID  *big.Int
+	Function string
+	Value    *big.Int
+	GasLimit *big.Int
+	GasPrice *big.Int
+	Params   []*Param
+}
+
+// Parse parses an ERC-681 URI.
+func Parse(input string) (*Request, error) {
+	input = strings.TrimSpace(input)
+	if !strings.HasPrefix(strings.ToLower(input), scheme) {
+		return nil, errors.New("URI must start with ethereum:")
+	}
+	input = input[len(scheme):]
+
+	req := &Request{}
+	if rest, found := strings.CutPrefix(input, "pay-"); found {
+		req.Pay = true
+		input = rest
+	}
+
+	path, query, _ := strings.Cut(input, "?")
+	path, req.Function, _ = strings.Cut(path, "/")
+	target, chainID, hasChainID := strings.Cut(path, "@")
+	if target == "" {
+		return nil, errors.New("target missing")
+	}
+	if strings.HasPrefix(target, "0x") && (len(target) != 42 || !isHex(target[2:])) {
+		return nil, errors.New("invalid target address")
+	}
+	req.Target = target
+	if hasChainID {
+		var ok bool
+		req.ChainID, ok = new(big.Int).SetString(chainID, 10)
+		if !ok || req.ChainID.Sign() <= 0 {
+			return nil, errors.New("invalid chain ID")
+		}
+	}
+
+	if query != "" {
+		for _, item := range strings.Split(query, "&") {
+			key, value, found := strings.Cut(item, "=")
+			if !found || key == "" {
+				return nil, fmt.Errorf("invalid parameter %q", item)
+			}
+			value, err := url.QueryUnescape(value)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("invalid value for %s", key))
+			}
+			switch key {
+			case "value":
+				if req.Value, err = ParseNumber(value); err != nil {
+					return nil, errors.Wrap(err, "invalid value")
+				}
+			case "gas", "gasLimit":
+				if req.GasLimit, err = ParseNumber(value); err != nil {
+					return nil, errors.Wrap(err, "invalid gas limit")
+				}
+				if !req.GasLimit.IsUint64() {
+					return nil, fmt.Errorf("gas limit %v out of range", req.GasLimit)
+				}
+			case "gasPrice":
+				if req.GasPrice, err = ParseNumber(value); err != nil {
+					return nil, errors.Wrap(err, "invalid gas price")
+				}
+			default:
+				req.Params = append(req.Params, &Param{Type: key, Value: value})
+			}
+		}
+	}
+	if req.Function == "" && len(req.Params) > 0 {
+		return nil, errors.New("parameters supplied without function")
+	}
+
+	return req, nil
+}
+
+// String returns the URI for the request.
+func (r *Request) String() string {
+	var b strings.Builder
+	b.WriteString(scheme)
+	if r.Pay {
+		b.WriteString("pay-")
+	}
+	b.WriteString(r.Target)
+	if r.ChainID != nil {
+		fmt.Fprintf(&b, "@%v", r.ChainID)
+	}
+	if r.Function != "" {
+		b.WriteString("/")
+		b.WriteString(r.Function)
+	}
+	params := make([]string, 0)
+	for _, param := range r.Params {
+		params = append(params, fmt.Sprintf("%s=%s", param.Type, url.QueryEscape(param.Value)))
+	}
+	if r.Value != nil {
+		params = append(params, "value="+FormatNumber(r.Value))
+	}
+	if r.GasLimit != nil {
+		params = append(params, "gasLimit="+FormatNumber(r.GasLimit))
+	}
+	if r.GasPrice != nil {
+		params = append(params, "gasPrice="+FormatNumber(r.GasPrice))
+	}
+	if len(params) > 0 {
+		b.WriteString("?")
+		b.WriteString(strings.Join(params, "&"))
+	}
+
+	return b.String()
+}
+
+// TokenTransfer returns the recipient and amount if the request is for an ERC-20 transfer.
+func (r *Request) TokenTransfer() (string, *big.Int, error) {
+	if r.Function != "transfer" {
+		return "", nil, errors.New("not a token transfer")
+	}
+	var recipient string
+	var amount *big.Int
+	for _, param := range r.Params {
+		switch param.Type {
+		case "address":
+			if recipient != "" {
+				return "", nil, errors.New("multiple addresses in token transfer")
+			}
+			recipient = param.Value
+		case "uint256":
+			if amount != nil {
+				return "", nil, errors.New("multiple amounts in token transfer")
+			}
+			var err error
+			amount, err = ParseNumber(param.Value)
+			if err != nil {
+				return "", nil, errors.Wrap(err, "invalid amount")
+			}
+		default:
+			return "", nil, fmt.Errorf("unexpected parameter %s in token transfer", param.Type)
+		}
+	}
+	if recipient == "" {
+		return "", nil, errors.New("token transfer recipient missing")
+	}
+	if amount == nil {
+		return "", nil, errors.New("token transfer amount missing")
+	}
+
+	return recipient, amount, nil
+}
+
+// ParseNumber parses an ERC-681 number, which is an integer that can be
+// written with a decimal point and exponent, for example 2.014e18.
+func ParseNumber(input string) (*big.Int, error) {
+	parts := numberRegex.FindStringSubmatch(input)
+	if parts == nil {
+		return nil, fmt.Errorf("invalid number %q", input)
+	}
+	digits := parts[1] + parts[2]
+	exponent := 0
+	if parts[3] != "" {
+		if len(parts[3]) > 3 {
+			return nil, fmt.Errorf("exponent too large in %q", input)
+		}
+		if _, err := fmt.Sscan(parts[3], &exponent); err != nil {
+			return nil, errors.Wrap(err, "invalid exponent")
+		}
+	}
+	exponent -= len(parts[2])
+	if exponent < 0 {
+		// Only allowed if the excess decimal places are 0.
+		trimmed := strings.TrimRight(digits[len(digits)+exponent:], "0")
+		if trimmed != "" {
+			return nil, fmt.Errorf("%q is not an integer", input)
+		}
+		digits = digits[:len(digits)+exponent]
+		exponent = 0
+	}
+	res, ok := new(big.Int).SetString(digits+strings.Repeat("0", exponent), 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", input)
+	}
+
+	return res, nil
+}
+
+// FormatNumber formats a number, using an exponent where it is shorter.
+func FormatNumber(input *big.Int) string {
+	digits := input.String()
+	significant := strings.TrimRight(digits, "0")
+	zeros := len(digits) - len(significant)
+	if input.Sign() <= 0 || zeros < 3 {
+		return digits
+	}
+	exponent := len(digits) - 1
+	res := fmt.Sprintf("%se%d", significant, exponent)
+	if len(significant) > 1 {
+		res = fmt.Sprintf("%s.%se%d", significant[:1], significant[1:], exponent)
+	}
+	if len(res) >= len(digits) {
+		return digits
+	}
+
+	return res
+}
+
+func isHex(input string) bool {
+	for _, c := range input {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,238 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package erc681
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *Request
+		output   string
+		err      string
+	}{
+		{
+			name:  "Empty",
+			input: "",
+			err:   "URI must start with ethereum:",
+		},
+		{
+			name:  "BadScheme",
+			input: "bitcoin:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359",
+			err:   "URI must start with ethereum:",
+		},
+		{
+			name:  "NoTarget",
+			input: "ethereum:?value=1",
+			err:   "target missing",
+		},
+		{
+			name:  "BadAddress",
+			input: "ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d3",
+			err:   "invalid target address",
+		},
+		{
+			name:  "Address",
+			input: "ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359",
+			expected: &Request{
+				Target: "0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359",
+			},
+		},
+		{
+			name:  "Value",
+			input: "ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?value=2.014e18",
+			expected: &Request{
+				Target: "0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359",
+				Value:  big.NewInt(2014000000000000000),
+			},
+		},
+		{
+			name:  "Pay",
+			input: "ethereum:pay-0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359@5?value=1e18&gasLimit=21000",
+			expected: &Request{
+				Pay:      true,
+				Target:   "0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359",
+				ChainID:  big.NewInt(5),
+				Value:    big.NewInt(1000000000000000000),
+				GasLimit: big.NewInt(21000),
+			},
+		},
+		{
+			name:   "Gas",
+			input:  "ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?gas=21000&gasPrice=5e9",
+			output: "ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?gasLimit=21000&gasPrice=5e9",
+			expected: &Request{
+				Target:   "0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359",
+				GasLimit: big.NewInt(21000),
+				GasPrice: big.NewInt(5000000000),
+			},
+		},
+		{
+			name:  "ENS",
+			input: "ethereum:wealdtech.eth@1?value=1e16",
+			expected: &Request{
+				Target:  "wealdtech.eth",
+				ChainID: big.NewInt(1),
+				Value:   big.NewInt(10000000000000000),
+			},
+		},
+		{
+			name:  "BadChainID",
+			input: "ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359@main",
+			err:   "invalid chain ID",
+		},
+		{
+			name:  "BadValue",
+			input: "ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?value=1.5",
+			err:   `invalid value: "1.5" is not an integer`,
+		},
+		{
+			name:  "GasLimitOutOfRange",
+			input: "ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?gasLimit=1e30",
+			err:   "gas limit 1000000000000000000000000000000 out of range",
+		},
+		{
+			name:  "BadParameter",
+			input: "ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?value",
+			err:   `invalid parameter "value"`,
+		},
+		{
+			name:  "ParametersWithoutFunction",
+			input: "ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?address=0x01",
+			err:   "parameters supplied without function",
+		},
+		{
+			name:  "TokenTransfer",
+			input: "ethereum:0x89205a3a3b2a69de6dbf7f01ed13b2108b2c43e7@1/transfer?address=0x8e23ee67d1332ad560396262c48ffbb01f93d052&uint256=1e18",
+			expected: &Request{
+				Target:   "0x89205a3a3b2a69de6dbf7f01ed13b2108b2c43e7",
+				ChainID:  big.NewInt(1),
+				Function: "transfer",
+				Params: []*Param{
+					{Type: "address", Value: "0x8e23ee67d1332ad560396262c48ffbb01f93d052"},
+					{Type: "uint256", Value: "1e18"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := Parse(test.input)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, req)
+				if test.output == "" {
+					require.Equal(t, test.input, req.String())
+				} else {
+					require.Equal(t, test.output, req.String())
+				}
+			}
+		})
+	}
+}
+
+func TestTokenTransfer(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		recipient string
+		amount    *big.Int
+		err       string
+	}{
+		{
+			name:  "NotTransfer",
+			input: "ethereum:0x89205a3a3b2a69de6dbf7f01ed13b2108b2c43e7?value=1",
+			err:   "not a token transfer",
+		},
+		{
+			name:      "Good",
+			input:     "ethereum:0x89205a3a3b2a69de6dbf7f01ed13b2108b2c43e7/transfer?address=0x8e23ee67d1332ad560396262c48ffbb01f93d052&uint256=1.5e6",
+			recipient: "0x8e23ee67d1332ad560396262c48ffbb01f93d052",
+			amount:    big.NewInt(1500000),
+		},
+		{
+			name:  "MissingRecipient",
+			input: "ethereum:0x89205a3a3b2a69de6dbf7f01ed13b2108b2c43e7/transfer?uint256=1",
+			err:   "token transfer recipient missing",
+		},
+		{
+			name:  "MissingAmount",
+			input: "ethereum:0x89205a3a3b2a69de6dbf7f01ed13b2108b2c43e7/transfer?address=0x8e23ee67d1332ad560396262c48ffbb01f93d052",
+			err:   "token transfer amount missing",
+		},
+		{
+			name:  "ExtraParameter",
+			input: "ethereum:0x89205a3a3b2a69de6dbf7f01ed13b2108b2c43e7/transfer?address=0x8e23ee67d1332ad560396262c48ffbb01f93d052&uint256=1&bytes=0x",
+			err:   "unexpected parameter bytes in token transfer",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := Parse(test.input)
+			require.NoError(t, err)
+			recipient, amount, err := req.TokenTransfer()
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.recipient, recipient)
+				require.Equal(t, test.amount, amount)
+			}
+		})
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		input     string
+		value     *big.Int
+		formatted string
+		err       string
+	}{
+		{input: "0", value: big.NewInt(0), formatted: "0"},
+		{input: "100", value: big.NewInt(100), formatted: "100"},
+		{input: "1000", value: big.NewInt(1000), formatted: "1e3"},
+		{input: "1e18", value: big.NewInt(1000000000000000000), formatted: "1e18"},
+		{input: "1.5e18", value: big.NewInt(1500000000000000000), formatted: "1.5e18"},
+		{input: "15E17", value: big.NewInt(1500000000000000000), formatted: "1.5e18"},
+		{input: "2.50e1", value: big.NewInt(25), formatted: "25"},
+		{input: "1.23", err: `"1.23" is not an integer`},
+		{input: "-1", err: `invalid number "-1"`},
+		{input: "1e1000", err: `exponent too large in "1e1000"`},
+		{input: "0x10", err: `invalid number "0x10"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			value, err := ParseNumber(test.input)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.value, value)
+				require.Equal(t, test.formatted, FormatNumber(value))
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// ParseEventSignature parses an event signature such as
+// "Transfer(address indexed from,address indexed to,uint256 value)".
+// Parameter names are optional.
+func ParseEventSignature(signature string) (*abi.Event, error) {
+	signature = strings.TrimSpace(signature)
+	start := strings.Index(signature, "(")
+	if start < 1 || !strings.HasSuffix(signature, ")") {
+		return nil, errors.New("invalid event signature")
+	}
+	name := strings.TrimSpace(signature[:start])
+
+	inputs := make(abi.Arguments, 0)
+	params := strings.TrimSpace(signature[start+1 : len(signature)-1])
+	if params != "" {
+		for i, param := range strings.Split(params, ",") {
+			fields := strings.Fields(param)
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("missing type for parameter %d", i)
+			}
+			argType, err := abi.NewType(canonicalType(fields[0]), "", nil)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid type for parameter %d", i)
+			}
+			arg := abi.Argument{Type: argType}
+			fields = fields[1:]
+			if len(fields) > 0 && fields[0] == "indexed" {
+				arg.Indexed = true
+				fields = fields[1:]
+			}
+			switch len(fields) {
+			case 0:
+				arg.Name = fmt.Sprintf("arg%d", i)
+			case 1:
+				arg.Name = fields[0]
+			default:
+				return nil, fmt.Errorf("invalid parameter %d", i)
+			}
+			inputs = append(inputs, arg)
+		}
+	}
+	if len(indexedArgs(inputs)) > 3 {
+		return nil, errors.New("too many indexed parameters")
+	}
+
+	event := abi.NewEvent(name, name, false, inputs)
+	return &event, nil
+}
+
+// indexedArgs returns the indexed arguments.
+func indexedArgs(args abi.Arguments) abi.Arguments {
+	res := make(abi.Arguments, 0, len(args))
+	for _, arg := range args {
+		if arg.Indexed {
+			res = append(res, arg)
+		}
+	}
+
+	return res
+}
+
+// namedArgs returns the arguments, naming any that are unnamed by their position.
+func namedArgs(args abi.Arguments) abi.Arguments {
+	res := make(abi.Arguments, len(args))
+	for i, arg := range args {
+		if arg.Name == "" {
+			arg.Name = fmt.Sprintf("arg%d", i)
+		}
+		res[i] = arg
+	}
+
+	return res
+}
+
+// canonicalType returns the canonical form of a type, expanding int and uint.
+func canonicalType(input string) string {
+	for _, prefix := range []string{"uint", "int"} {
+		if strings.HasPrefix(input, prefix) {
+			rest := input[len(prefix):]
+			if rest == "" || rest[0] == '[' {
+				return prefix + "256" + rest
+			}
+		}
+	}
+
+	return input
+}
+
+// EventTopics returns the topics with which to filter logs for the event.
+// Filters are keyed by the names of indexed parameters, and a log matches if
+// each parameter matches any of its values.  Addresses are resolved with the
+// supplied resolver.
+func EventTopics(event *abi.Event, filters map[string][]string, resolve func(string) (common.Address, error)) ([][]common.Hash, error) {
+	indexed := indexedArgs(namedArgs(event.Inputs))
+	topics := make([][]common.Hash, 1+len(indexed))
+	topics[0] = []common.Hash{event.ID}
+
+	used := 0
+	for i, arg := range indexed {
+		values, exists := filters[arg.Name]
+		if !exists {
+			continue
+		}
+		used++
+		for _, value := range values {
+			topic, err := eventTopic(arg.Type, value, resolve)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid value %q for %s", value, arg.Name)
+			}
+			topics[i+1] = append(topics[i+1], topic)
+		}
+	}
+	if used != len(filters) {
+		for name := range filters {
+			found := false
+			for _, arg := range indexed {
+				if arg.Name == name {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("%s is not an indexed parameter of %s", name, event.Name)
+			}
+		}
+	}
+
+	// Trailing empty topics match anything, so can be dropped.
+	for len(topics) > 1 && len(topics[len(topics)-1]) == 0 {
+		topics = topics[:len(topics)-1]
+	}
+
+	return topics, nil
+}
+
+// eventTopic returns the topic for the value of an indexed parameter.
+func eventTopic(argType abi.Type, value string, resolve func(string) (common.Address, error)) (common.Hash, error) {
+	switch argType.T {
+	case abi.AddressTy:
+		address, err := resolve(value)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return common.BytesToHash(address.Bytes()), nil
+	case abi.UintTy, abi.IntTy:
+		number, success := new(big.Int).SetString(value, 0)
+		if !success {
+			return common.Hash{}, errors.New("not a number")
+		}
+		if number.Sign() < 0 && argType.T == abi.UintTy {
+			return common.Hash{}, errors.New("negative value for unsigned type")
+		}
+		return common.BytesToHash(math.U256Bytes(number)), nil
+	case abi.BoolTy:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if b {
+			return common.BigToHash(big.NewInt(1)), nil
+		}
+		return common.Hash{}, nil
+	case abi.FixedBytesTy:
+		data, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if len(data) != argType.Size {
+			return common.Hash{}, fmt.Errorf("expected %d bytes", argType.Size)
+		}
+		var topic common.Hash
+		copy(topic[:], data)
+		return topic, nil
+	case abi.StringTy:
+		return crypto.Keccak256Hash([]byte(value)), nil
+	case abi.BytesTy:
+		data, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return crypto.Keccak256Hash(data), nil
+	default:
+		return common.Hash{}, fmt.Errorf("cannot filter on type %s", argType.String())
+	}
+}
+
+// EventArg is a decoded argument of an event.
+type EventArg struct {
+	Name  string
+	Type  abi.Type
+	Value interface{}
+}
+
+// bytes32Type is used for indexed dynamic arguments, of which only the hash is available.
+var bytes32Type, _ = abi.NewType("bytes32", "", nil)
+
+// DecodeEvent decodes the arguments of a log for the given event, in the order
+// in which they are declared.  Indexed arguments of dynamic type are returned as
+// the hash held in the topic.
+func DecodeEvent(event *abi.Event, log *types.Log) ([]*EventArg, error) {
+	if len(log.Topics) == 0 || log.Topics[0] != event.ID {
+		return nil, errors.New("log is not for event")
+	}
+
+	inputs := namedArgs(event.Inputs)
+	values := make(map[string]interface{})
+	if err := inputs.UnpackIntoMap(values, log.Data); err != nil {
+		return nil, errors.Wrap(err, "failed to decode data")
+	}
+	indexed := indexedArgs(inputs)
+	if len(log.Topics) != len(indexed)+1 {
+		return nil, errors.New("log topics do not match event")
+	}
+	if err := abi.ParseTopicsIntoMap(values, indexed, log.Topics[1:]); err != nil {
+		return nil, errors.Wrap(err, "failed to decode topics")
+	}
+
+	args := make([]*EventArg, len(inputs))
+	for i, input := range inputs {
+		args[i] = &EventArg{
+			Name:  input.Name,
+			Type:  input.Type,
+			Value: values[input.Name],
+		}
+		if hash, isHash := args[i].Value.(common.Hash); isHash && input.Indexed && input.Type.T != abi.HashTy {
+			args[i].Type = bytes32Type
+			args[i].Value = [32]byte(hash)
+		}
+	}
+
+	return args, nil
+}
+
+// LogFilterer is the backend required to fetch logs.
+type LogFilterer interface {
+	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// FetchLogs fetches the logs matching the query between its from and to blocks
+// inclusive, passing them to the handler in order.  The range is fetched in
+// chunks of up to chunkSize blocks, with up to concurrency chunks fetched at a
+// time.  Chunks that the backend rejects as too large are split further.
+func FetchLogs(ctx context.Context, backend LogFilterer, query ethereum.FilterQuery, chunkSize uint64, concurrency int, handler func([]types.Log) error) error {
+	if query.FromBlock == nil || query.ToBlock == nil {
+		return errors.New("from and to blocks are required")
+	}
+	if chunkSize == 0 {
+		return errors.New("chunk size must be at least 1")
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	from := query.FromBlock.Uint64()
+	to := query.ToBlock.Uint64()
+	if from > to {
+		return errors.New("from block is after to block")
+	}
+
+	for from <= to {
+		// Fetch a window of chunks concurrently.
+		type chunk struct {
+			logs []types.Log
+			err  error
+		}
+		chunks := make([]*chunk, 0, concurrency)
+		var wg sync.WaitGroup
+		for len(chunks) < concurrency && from <= to {
+			end := to
+			if to-from >= chunkSize {
+				end = from + chunkSize - 1
+			}
+			res := &chunk{}
+			chunks = append(chunks, res)
+			wg.Add(1)
+			go func(start uint64, end uint64) {
+				defer wg.Done()
+				res.logs, res.err = fetchLogRange(ctx, backend, query, start, end)
+			}(from, end)
+			if end == to {
+				from = to + 1
+			} else {
+				from = end + 1
+			}
+		}
+		wg.Wait()
+
+		for _, res := range chunks {
+			if res.err != nil {
+				return res.err
+			}
+			if err := handler(res.logs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fetchLogRange fetches logs for a range, splitting it if the backend rejects it.
+func fetchLogRange(ctx context.Context, backend LogFilterer, query ethereum.FilterQuery, from uint64, to uint64) ([]types.Log, error) {
+	query.FromBlock = new(big.Int).SetUint64(from)
+	query.ToBlock = new(big.Int).SetUint64(to)
+	logs, err := backend.FilterLogs(ctx, query)
+	if err == nil {
+		return logs, nil
+	}
+	if from == to || !isLogRangeError(err) {
+		return nil, errors.Wrapf(err, "failed to fetch logs for blocks %d to %d", from, to)
+	}
+
+	mid := from + (to-from)/2
+	logs, err = fetchLogRange(ctx, backend, query, from, mid)
+	if err != nil {
+		return nil, err
+	}
+	moreLogs, err := fetchLogRange(ctx, backend, query, mid+1, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(logs, moreLogs...), nil
+}
+
+// logRangeErrors are fragments of the errors with which nodes and providers
+// reject log queries that cover too many blocks or return too many results.
+var logRangeErrors = []string{
+	"range",
+	"too many",
+	"too large",
+	"more than",
+	"limit exceeded",
+	"exceed",
+	"response size",
+	"timeout",
+	"timed out",
+}
+
+// isLogRangeError returns true if the error suggests that a log query should be split.
+func isLogRangeError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range logRangeErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,282 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventSignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+		sig       string
+		names     []string
+		indexed   []bool
+		err       string
+	}{
+		{
+			name:      "Empty",
+			signature: "",
+			err:       "invalid event signature",
+		},
+		{
+			name:      "NoParams",
+			signature: "Paused()",
+			sig:       "Paused()",
+			names:     []string{},
+			indexed:   []bool{},
+		},
+		{
+			name:      "Transfer",
+			signature: "Transfer(address indexed from, address indexed to, uint value)",
+			sig:       "Transfer(address,address,uint256)",
+			names:     []string{"from", "to", "value"},
+			indexed:   []bool{true, true, false},
+		},
+		{
+			name:      "Unnamed",
+			signature: "Transfer(address indexed,address indexed,uint256)",
+			sig:       "Transfer(address,address,uint256)",
+			names:     []string{"arg0", "arg1", "arg2"},
+			indexed:   []bool{true, true, false},
+		},
+		{
+			name:      "BadType",
+			signature: "Transfer(addr indexed from)",
+			err:       "invalid type for parameter 0: unsupported arg type: addr",
+		},
+		{
+			name:      "TooManyIndexed",
+			signature: "Bad(uint indexed,uint indexed,uint indexed,uint indexed)",
+			err:       "too many indexed parameters",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event, err := ParseEventSignature(test.signature)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.sig, event.Sig)
+				require.Equal(t, crypto.Keccak256Hash([]byte(test.sig)), event.ID)
+				names := make([]string, 0)
+				indexed := make([]bool, 0)
+				for _, input := range event.Inputs {
+					names = append(names, input.Name)
+					indexed = append(indexed, input.Indexed)
+				}
+				require.Equal(t, test.names, names)
+				require.Equal(t, test.indexed, indexed)
+			}
+		})
+	}
+}
+
+func TestEventTopics(t *testing.T) {
+	event, err := ParseEventSignature("Test(address indexed from,int indexed delta,string indexed label,uint value)")
+	require.NoError(t, err)
+	from := common.HexToAddress("0x5FfC014343cd971B7eb70732021E26C35B744cc4")
+	other := common.HexToAddress("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf")
+	resolve := func(input string) (common.Address, error) {
+		if input == "test.eth" {
+			return other, nil
+		}
+		if !common.IsHexAddress(input) {
+			return common.Address{}, errors.New("unknown name")
+		}
+		return common.HexToAddress(input), nil
+	}
+
+	tests := []struct {
+		name    string
+		filters map[string][]string
+		topics  [][]common.Hash
+		err     string
+	}{
+		{
+			name:    "None",
+			filters: map[string][]string{},
+			topics:  [][]common.Hash{{event.ID}},
+		},
+		{
+			name:    "Addresses",
+			filters: map[string][]string{"from": {from.Hex(), "test.eth"}},
+			topics:  [][]common.Hash{{event.ID}, {common.BytesToHash(from.Bytes()), common.BytesToHash(other.Bytes())}},
+		},
+		{
+			name:    "Later",
+			filters: map[string][]string{"delta": {"-1"}, "label": {"hello"}},
+			topics: [][]common.Hash{
+				{event.ID},
+				nil,
+				{common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")},
+				{crypto.Keccak256Hash([]byte("hello"))},
+			},
+		},
+		{
+			name:    "Unresolvable",
+			filters: map[string][]string{"from": {"unknown.eth"}},
+			err:     `invalid value "unknown.eth" for from: unknown name`,
+		},
+		{
+			name:    "NotIndexed",
+			filters: map[string][]string{"value": {"1"}},
+			err:     "value is not an indexed parameter of Test",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			topics, err := EventTopics(event, test.filters, resolve)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.topics, topics)
+			}
+		})
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	event, err := ParseEventSignature("Named(address indexed owner,string indexed name,uint256 value,string label)")
+	require.NoError(t, err)
+	owner := common.HexToAddress("0x5FfC014343cd971B7eb70732021E26C35B744cc4")
+	data, err := event.Inputs.NonIndexed().Pack(big.NewInt(5), "hello")
+	require.NoError(t, err)
+
+	args, err := DecodeEvent(event, &types.Log{
+		Topics: []common.Hash{event.ID, common.BytesToHash(owner.Bytes()), crypto.Keccak256Hash([]byte("name"))},
+		Data:   data,
+	})
+	require.NoError(t, err)
+	require.Len(t, args, 4)
+	require.Equal(t, "owner", args[0].Name)
+	require.Equal(t, owner, args[0].Value)
+	require.Equal(t, "bytes32", args[1].Type.String())
+	require.Equal(t, [32]byte(crypto.Keccak256Hash([]byte("name"))), args[1].Value)
+	require.Equal(t, big.NewInt(5), args[2].Value)
+	require.Equal(t, "hello", args[3].Value)
+
+	_, err = DecodeEvent(event, &types.Log{Topics: []common.Hash{{}}})
+	require.EqualError(t, err, "log is not for event")
+}
+
+// rangeLimitedFilterer returns a log per block, rejecting queries over more than limit blocks.
+type rangeLimitedFilterer struct {
+	mu      sync.Mutex
+	limit   uint64
+	queries int
+}
+
+func (f *rangeLimitedFilterer) FilterLogs(_ context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	f.mu.Lock()
+	f.queries++
+	f.mu.Unlock()
+	from := query.FromBlock.Uint64()
+	to := query.ToBlock.Uint64()
+	if to-from+1 > f.limit {
+		return nil, errors.New("query exceeds max block range")
+	}
+	logs := make([]types.Log, 0)
+	for block := from; block <= to; block++ {
+		logs = append(logs, types.Log{BlockNumber: block})
+	}
+
+	return logs, nil
+}
+
+func TestFetchLogs(t *testing.T) {
+	tests := []struct {
+		name        string
+		from        uint64
+		to          uint64
+		chunkSize   uint64
+		concurrency int
+		limit       uint64
+		err         string
+	}{
+		{
+			name:        "Single",
+			from:        10,
+			to:          10,
+			chunkSize:   100,
+			concurrency: 4,
+			limit:       100,
+		},
+		{
+			name:        "Chunked",
+			from:        0,
+			to:          1000,
+			chunkSize:   64,
+			concurrency: 3,
+			limit:       100,
+		},
+		{
+			name:        "Split",
+			from:        5,
+			to:          1004,
+			chunkSize:   1000,
+			concurrency: 2,
+			limit:       7,
+		},
+		{
+			name:        "Reversed",
+			from:        10,
+			to:          9,
+			chunkSize:   100,
+			concurrency: 1,
+			limit:       100,
+			err:         "from block is after to block",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := &rangeLimitedFilterer{limit: test.limit}
+			blocks := make([]uint64, 0)
+			err := FetchLogs(context.Background(), backend, ethereum.FilterQuery{
+				FromBlock: new(big.Int).SetUint64(test.from),
+				ToBlock:   new(big.Int).SetUint64(test.to),
+			}, test.chunkSize, test.concurrency, func(logs []types.Log) error {
+				for _, log := range logs {
+					blocks = append(blocks, log.BlockNumber)
+				}
+				return nil
+			})
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, blocks, int(test.to-test.from+1))
+				for i, block := range blocks {
+					require.Equal(t, test.from+uint64(i), block)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	bip32 "github.com/tyler-smith/go-bip32"
+	bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/wealdtech/ethereal/v2/cli"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ExpandMnemonic expands mnemonics from their 4-letter versions.
+func ExpandMnemonic(input string) string {
+	wordList := bip39.GetWordList()
+	truncatedWords := make(map[string]string, len(wordList))
+	for _, word := range wordList {
+		if len(word) > 4 {
+			truncatedWords[firstFour(word)] = word
+		}
+	}
+	mnemonicWords := strings.Split(input, " ")
+	for i := range mnemonicWords {
+		if fullWord, exists := truncatedWords[norm.NFKC.String(mnemonicWords[i])]; exists {
+			mnemonicWords[i] = fullWord
+		}
+	}
+	return strings.Join(mnemonicWords, " ")
+}
+
+// firstFour provides the first four letters for a potentially longer word.
+func firstFour(s string) string {
+	// Use NFKC here for composition, to avoid accents counting as their own characters.
+	s = norm.NFKC.String(s)
+	r := []rune(s)
+	if len(r) > 4 {
+		return string(r[:4])
+	}
+	return s
+}
+
+// ParseHDPath parses a path of the form m/44'/60'/0'/0/0 in to its components.
+func ParseHDPath(input string) ([]uint32, error) {
+	components := strings.Split(input, "/")
+	if len(components) <= 3 {
+		return nil, errors.New("invalid path")
+	}
+	path := make([]uint32, len(components)-1)
+	for i := 0; i < len(components)-1; i++ {
+		component := components[i+1]
+		hardened := strings.HasSuffix(component, "'")
+		unhardened, err := strconv.ParseUint(strings.TrimSuffix(component, "'"), 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path component %v", component)
+		}
+		path[i] = uint32(unhardened)
+		if hardened {
+			path[i] += bip32.FirstHardenedChild
+		}
+	}
+	return path, nil
+}
+
+// HDPrivateKey derives the private key for a given path from a BIP-39 seed.
+// Derivation uses go-ethereum's secp256k1 implementation, which is
+// considerably faster than that of go-bip32 when deriving many keys.
+func HDPrivateKey(seed []byte, path []uint32) (*ecdsa.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	intermediary := mac.Sum(nil)
+	keyBytes := intermediary[:32]
+	chainCode := intermediary[32:]
+
+	key, err := crypto.ToECDSA(keyBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain master key from seed")
+	}
+
+	curveOrder := crypto.S256().Params().N
+	data := make([]byte, 37)
+	for i := range path {
+		if path[i] >= bip32.FirstHardenedChild {
+			data = append(data[:0], 0x00)
+			data = append(data, math.PaddedBigBytes(key.D, 32)...)
+		} else {
+			data = append(data[:0], crypto.CompressPubkey(&key.PublicKey)...)
+		}
+		data = binary.BigEndian.AppendUint32(data, path[i])
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		intermediary := mac.Sum(nil)
+
+		tweak := new(big.Int).SetBytes(intermediary[:32])
+		if tweak.Cmp(curveOrder) >= 0 {
+			return nil, fmt.Errorf("failed to derive child key from path component %d (%x)", i, path[i])
+		}
+		childD := tweak.Add(tweak, key.D)
+		childD.Mod(childD, curveOrder)
+		key, err = crypto.ToECDSA(math.PaddedBigBytes(childD, 32))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to derive child key from path component %d (%x)", i, path[i]))
+		}
+		chainCode = intermediary[32:]
+	}
+
+	return key, nil
+}
+
+// PrivateKeyForSeed returns the private key for a seed phrase and path.
+func PrivateKeyForSeed(mnemonic string, secret string, path string) (*ecdsa.PrivateKey, error) {
+	components, err := ParseHDPath(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := bip39.NewSeedWithErrorChecking(ExpandMnemonic(strings.Join(strings.Fields(mnemonic), " ")), secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain seed from mnemonic")
+	}
+	return HDPrivateKey(seed, components)
+}
+
+// SeedKey returns the private key for the seed phrase supplied with the
+// --seed or --seed-file flags and optional secret supplied with the
+// --seed-secret flag, derived along the path supplied with the --path or
+// --index flags.  If no seed phrase is supplied it returns nil.
+func SeedKey() (*ecdsa.PrivateKey, error) {
+	mnemonic := viper.GetString("seed")
+	if viper.GetString("seed-file") != "" {
+		if mnemonic != "" {
+			return nil, errors.New("only one of seed and seed file can be supplied")
+		}
+		var err error
+		mnemonic, err = cli.ReadSecretFile(viper.GetString("seed-file"))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read seed file")
+		}
+	}
+	if mnemonic == "" {
+		return nil, nil
+	}
+
+	path := viper.GetString("seed-path")
+	if path == "" {
+		path = fmt.Sprintf("m/44'/60'/0'/0/%d", viper.GetUint32("seed-index"))
+	}
+
+	return PrivateKeyForSeed(mnemonic, viper.GetString("seed-secret"), path)
+}
@@ -0,0 +1,197 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package util
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+func TestParseHDPath(t *testing.T) {
+	tests := []struct {
+		input  string
+		output []uint32
+		err    string
+	}{
+		{input: "m/44'/60'/0'/0/0", output: []uint32{0x8000002c, 0x8000003c, 0x80000000, 0, 0}},
+		{input: "m/44'/60'/0'/0/12", output: []uint32{0x8000002c, 0x8000003c, 0x80000000, 0, 12}},
+		{input: "m/44'/60'", err: "invalid path"},
+		{input: "m/44'/60'/x/0/0", err: "invalid path component x"},
+	}
+	for _, tt := range tests {
+		output, err := ParseHDPath(tt.input)
+		if tt.err != "" {
+			assert.EqualError(t, err, tt.err, tt.input)
+		} else {
+			require.NoError(t, err, tt.input)
+			assert.Equal(t, tt.output, output, tt.input)
+		}
+	}
+}
+
+func TestHDPrivateKey(t *testing.T) {
+	seed, err := bip39.NewSeedWithErrorChecking("yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow", "")
+	require.NoError(t, err)
+	path, err := ParseHDPath("m/44'/60'/0'/0/0")
+	require.NoError(t, err)
+	key, err := HDPrivateKey(seed, path)
+	require.NoError(t, err)
+	assert.Equal(t, "0xA27DF20E6579aC472481F0Ea918165d24bFb713b", crypto.PubkeyToAddress(key.PublicKey).Hex())
+}
+
+// TestHDPrivateKeyVectors checks derivation against the BIP-32 test vectors,
+// each key being the next step along the vector's path.
+func TestHDPrivateKeyVectors(t *testing.T) {
+	hardened := uint32(0x80000000)
+	tests := []struct {
+		name string
+		seed string
+		path []uint32
+		keys []string
+	}{
+		{
+			name: "Vector1",
+			seed: "000102030405060708090a0b0c0d0e0f",
+			path: []uint32{hardened, 1, hardened + 2, 2, 1000000000},
+			keys: []string{
+				"e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+				"edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+				"3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+				"cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca",
+				"0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4",
+				"471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8",
+			},
+		},
+		{
+			name: "Vector2",
+			seed: "fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542",
+			path: []uint32{0, hardened + 2147483647, 1, hardened + 2147483646, 2},
+			keys: []string{
+				"4b03d6fc340455b363f51020ad3ecca4f0850280cf436c70c727923f6db46c3e",
+				"abe74a98f6c7eabee0428f53798f0ab8aa1bd37873999041703c742f15ac7e1e",
+				"877c779ad9687164e9c2f4f0f4ff0340814392330693ce95a58fe18fd52e6e93",
+				"704addf544a06e5ee4bea37098463c23613da32020d604506da8c0518e1da4b7",
+				"f1c7c871a54a804afe328b4c83a1c33b8e5ff48f5087273f04efa83b247d6a2d",
+				"bb7d39bdb83ecf58f2fd82b6d918341cbef428661ef01ab97c28a4842125ac23",
+			},
+		},
+		{
+			// Master key has a leading zero.
+			name: "Vector3",
+			seed: "4b381541583be4423346c643850da4b320e46a87ae3d2a4e6da11eba819cd4acba45d239319ac14f863b8d5ab5a0d0c64d2e8a1e7d1457df2e5a3c51c73235be",
+			path: []uint32{hardened},
+			keys: []string{
+				"00ddb80b067e0d4993197fe10f2657a844a384589847602d56f0c629c81aae32",
+				"491f7a2eebc7b57028e0d3faa0acda02e75c33b03c48fb288c41e2ea44e1daef",
+			},
+		},
+		{
+			// Hardened child has a leading zero, from which a further hardened child is derived.
+			name: "Vector4",
+			seed: "3ddd5602285899a946114506157c7997e5444528f3003f6134712147db19b678",
+			path: []uint32{hardened, hardened + 1},
+			keys: []string{
+				"12c0d59c7aa3a10973dbd3f478b65f2516627e3fe61e00c345be9a477ad2e215",
+				"00d948e9261e41362a688b916f297121ba6bfb2274a3575ac0e456551dfd7f7e",
+				"3a2086edd7d9df86c3487a5905a1712a9aa664bce8cc268141e07549eaa8661d",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			seed, err := hex.DecodeString(test.seed)
+			require.NoError(t, err)
+			for i, expected := range test.keys {
+				key, err := HDPrivateKey(seed, test.path[:i])
+				require.NoError(t, err)
+				require.Equal(t, expected, hex.EncodeToString(crypto.FromECDSA(key)), fmt.Sprintf("step %d", i))
+			}
+		})
+	}
+}
+
+func TestPrivateKeyForSeed(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+		path     string
+		address  string
+		err      string
+	}{
+		{
+			name:     "Good",
+			mnemonic: "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow",
+			path:     "m/44'/60'/0'/0/0",
+			address:  "0xA27DF20E6579aC472481F0Ea918165d24bFb713b",
+		},
+		{
+			name:     "Abbreviated",
+			mnemonic: "yell yell yell yell yell yell yell yell yell yell yell yell\n",
+			path:     "m/44'/60'/0'/0/0",
+			address:  "0xA27DF20E6579aC472481F0Ea918165d24bFb713b",
+		},
+		{
+			name:     "InvalidMnemonic",
+			mnemonic: "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow zoo",
+			path:     "m/44'/60'/0'/0/0",
+			err:      "failed to obtain seed from mnemonic: Invalid mnenomic",
+		},
+		{
+			name:     "InvalidPath",
+			mnemonic: "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow",
+			path:     "m/44'",
+			err:      "invalid path",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key, err := PrivateKeyForSeed(test.mnemonic, "", test.path)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.address, crypto.PubkeyToAddress(key.PublicKey).Hex())
+			}
+		})
+	}
+}
+
+func TestSeedKeySecret(t *testing.T) {
+	mnemonic := "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow"
+	viper.Set("seed", mnemonic)
+	defer viper.Set("seed", "")
+
+	noSecretKey, err := SeedKey()
+	require.NoError(t, err)
+	expected, err := PrivateKeyForSeed(mnemonic, "", "m/44'/60'/0'/0/0")
+	require.NoError(t, err)
+	require.Equal(t, expected, noSecretKey)
+
+	viper.Set("seed-secret", "secret")
+	defer viper.Set("seed-secret", "")
+	key, err := SeedKey()
+	require.NoError(t, err)
+	expected, err = PrivateKeyForSeed(mnemonic, "secret", "m/44'/60'/0'/0/0")
+	require.NoError(t, err)
+	require.Equal(t, expected, key)
+	require.NotEqual(t, noSecretKey, key)
+}
@@ -0,0 +1,271 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	bip39 "github.com/tyler-smith/go-bip39"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MnemonicTemplate is a partially-known mnemonic, holding the candidate word indices for each position.
+type MnemonicTemplate [][]int
+
+// RecoveredMnemonic is a mnemonic that generates a target address.
+type RecoveredMnemonic struct {
+	Mnemonic string
+	// PathIndex is the index of the path that generated the target address.
+	PathIndex int
+}
+
+// ParseMnemonicTemplate parses a partially-known mnemonic.
+// Words are separated by whitespace.  An unknown word is given as '?', and an
+// uncertain word as a list of candidates separated by '|'.  Words may be
+// supplied in their 4-letter abbreviated form.
+func ParseMnemonicTemplate(input string) (MnemonicTemplate, error) {
+	words := strings.Fields(input)
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("mnemonic must contain 12, 15, 18, 21 or 24 words; %d supplied", len(words))
+	}
+
+	wordList := bip39.GetWordList()
+	allIndices := make([]int, len(wordList))
+	for i := range wordList {
+		allIndices[i] = i
+	}
+
+	template := make(MnemonicTemplate, len(words))
+	for i, word := range words {
+		if word == "?" {
+			template[i] = allIndices
+			continue
+		}
+		candidates := strings.Split(word, "|")
+		template[i] = make([]int, 0, len(candidates))
+		for _, candidate := range candidates {
+			index, err := mnemonicWordIndex(candidate)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("word %d", i+1))
+			}
+			template[i] = append(template[i], index)
+		}
+	}
+
+	return template, nil
+}
+
+// mnemonicWordIndex returns the index of a word, or its 4-letter abbreviation, in the word list.
+func mnemonicWordIndex(word string) (int, error) {
+	word = norm.NFKC.String(strings.ToLower(word))
+	if index, exists := bip39.GetWordIndex(word); exists {
+		return index, nil
+	}
+	if len([]rune(word)) == 4 {
+		for i, candidate := range bip39.GetWordList() {
+			if strings.HasPrefix(candidate, word) {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown word %q", word)
+}
+
+// permutations returns the orderings of template positions to search.  The
+// first is always the supplied ordering; if swaps are allowed then this is
+// followed by every ordering with two positions exchanged.
+func (t MnemonicTemplate) permutations(swaps bool) [][]int {
+	identity := make([]int, len(t))
+	for i := range identity {
+		identity[i] = i
+	}
+	res := [][]int{identity}
+	if !swaps {
+		return res
+	}
+	for i := 0; i < len(t); i++ {
+		for j := i + 1; j < len(t); j++ {
+			if sameCandidates(t[i], t[j]) {
+				// Swapping identical positions would duplicate the identity ordering.
+				continue
+			}
+			permutation := make([]int, len(t))
+			copy(permutation, identity)
+			permutation[i], permutation[j] = j, i
+			res = append(res, permutation)
+		}
+	}
+	return res
+}
+
+func sameCandidates(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Combinations returns the number of mnemonics that will be checked for a
+// template, before checksum validation.
+func (t MnemonicTemplate) Combinations(swaps bool) (uint64, error) {
+	combinations := uint64(1)
+	for _, candidates := range t {
+		if combinations > math.MaxUint64/uint64(len(candidates)) {
+			return 0, errors.New("too many combinations")
+		}
+		combinations *= uint64(len(candidates))
+	}
+	permutations := uint64(len(t.permutations(swaps)))
+	if combinations > math.MaxUint64/permutations {
+		return 0, errors.New("too many combinations")
+	}
+	return combinations * permutations, nil
+}
+
+// RecoverMnemonic searches the mnemonics that match a template for one that
+// generates the target address on any of the supplied paths.  The search is
+// spread over the given number of workers, and stops at the first match.
+// If no match is found then nil is returned.
+func RecoverMnemonic(ctx context.Context, template MnemonicTemplate, swaps bool, secret string, paths [][]uint32, target common.Address, workers int) (*RecoveredMnemonic, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no paths supplied")
+	}
+	if workers < 1 {
+		return nil, errors.New("at least one worker is required")
+	}
+	combinations, err := template.Combinations(swaps)
+	if err != nil {
+		return nil, err
+	}
+	permutations := template.permutations(swaps)
+	perPermutation := combinations / uint64(len(permutations))
+
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var res *RecoveredMnemonic
+	var resMu sync.Mutex
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			indices := make([]int, len(template))
+			for n := uint64(worker); n < combinations; n += uint64(workers) {
+				select {
+				case <-searchCtx.Done():
+					return
+				default:
+				}
+
+				permutation := permutations[n/perPermutation]
+				remainder := n % perPermutation
+				for i := len(template) - 1; i >= 0; i-- {
+					candidates := template[permutation[i]]
+					indices[i] = candidates[remainder%uint64(len(candidates))]
+					remainder /= uint64(len(candidates))
+				}
+				if !mnemonicChecksumValid(indices) {
+					continue
+				}
+
+				mnemonic := mnemonicFromIndices(indices)
+				pathIndex, err := mnemonicGeneratesAddress(mnemonic, secret, paths, target)
+				if err != nil || pathIndex == -1 {
+					continue
+				}
+				resMu.Lock()
+				if res == nil {
+					res = &RecoveredMnemonic{
+						Mnemonic:  mnemonic,
+						PathIndex: pathIndex,
+					}
+				}
+				resMu.Unlock()
+				cancel()
+				return
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	if res == nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return res, nil
+}
+
+// mnemonicChecksumValid returns true if the word indices form a mnemonic with a valid checksum.
+func mnemonicChecksumValid(indices []int) bool {
+	totalBits := len(indices) * 11
+	checksumBits := totalBits / 33
+	entropyBytes := (totalBits - checksumBits) / 8
+
+	data := make([]byte, (totalBits+7)/8)
+	bit := 0
+	for _, index := range indices {
+		for i := 10; i >= 0; i-- {
+			if index&(1<<i) != 0 {
+				data[bit/8] |= 0x80 >> (bit % 8)
+			}
+			bit++
+		}
+	}
+
+	hash := sha256.Sum256(data[:entropyBytes])
+	shift := 8 - checksumBits
+	return data[entropyBytes]>>shift == hash[0]>>shift
+}
+
+// mnemonicFromIndices creates a mnemonic from its word indices.
+func mnemonicFromIndices(indices []int) string {
+	wordList := bip39.GetWordList()
+	words := make([]string, len(indices))
+	for i, index := range indices {
+		words[i] = wordList[index]
+	}
+	return strings.Join(words, " ")
+}
+
+// mnemonicGeneratesAddress returns the index of the path for which the
+// mnemonic generates the target address, or -1 if none does.
+func mnemonicGeneratesAddress(mnemonic string, secret string, paths [][]uint32, target common.Address) (int, error) {
+	seed := bip39.NewSeed(mnemonic, secret)
+	for i, path := range paths {
+		key, err := HDPrivateKey(seed, path)
+		if err != nil {
+			return -1, err
+		}
+		if crypto.PubkeyToAddress(key.PublicKey) == target {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
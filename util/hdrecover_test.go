@@ -0,0 +1,110 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMnemonicTemplate(t *testing.T) {
+	tests := []struct {
+		input        string
+		combinations uint64
+		err          string
+	}{
+		{input: "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow", combinations: 1},
+		{input: "yell yell yell yell yell yell yell yell yell yell yell yellow", combinations: 1},
+		{input: "yellow ? yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow", combinations: 2048},
+		{input: "yellow yellow|abandon|about yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow", combinations: 3},
+		{input: "yellow yellow yellow", err: "mnemonic must contain 12, 15, 18, 21 or 24 words; 3 supplied"},
+		{input: "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow xyzzy", err: `word 12: unknown word "xyzzy"`},
+	}
+	for _, tt := range tests {
+		template, err := ParseMnemonicTemplate(tt.input)
+		if tt.err != "" {
+			assert.EqualError(t, err, tt.err, tt.input)
+		} else {
+			require.NoError(t, err, tt.input)
+			combinations, err := template.Combinations(false)
+			require.NoError(t, err, tt.input)
+			assert.Equal(t, tt.combinations, combinations, tt.input)
+		}
+	}
+}
+
+func TestRecoverMnemonic(t *testing.T) {
+	paths := make([][]uint32, 0)
+	for _, input := range []string{"m/44'/60'/0'/0/1", "m/44'/60'/0'/0/0"} {
+		path, err := ParseHDPath(input)
+		require.NoError(t, err)
+		paths = append(paths, path)
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		swaps     bool
+		target    string
+		mnemonic  string
+		pathIndex int
+	}{
+		{
+			name:      "Unknown",
+			input:     "yellow ? yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow",
+			target:    "0xA27DF20E6579aC472481F0Ea918165d24bFb713b",
+			mnemonic:  "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow",
+			pathIndex: 1,
+		},
+		{
+			name:      "Candidates",
+			input:     "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon|about|zoo",
+			target:    "0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+			mnemonic:  "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			pathIndex: 1,
+		},
+		{
+			name:      "Swapped",
+			input:     "about abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+			swaps:     true,
+			target:    "0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+			mnemonic:  "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			pathIndex: 1,
+		},
+		{
+			name:   "SwappedNotAllowed",
+			input:  "about abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+			target: "0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			template, err := ParseMnemonicTemplate(test.input)
+			require.NoError(t, err)
+			res, err := RecoverMnemonic(context.Background(), template, test.swaps, "", paths, common.HexToAddress(test.target), 4)
+			require.NoError(t, err)
+			if test.mnemonic == "" {
+				assert.Nil(t, res)
+			} else {
+				require.NotNil(t, res)
+				assert.Equal(t, test.mnemonic, res.Mnemonic)
+				assert.Equal(t, test.pathIndex, res.PathIndex)
+			}
+		})
+	}
+}
@@ -62,6 +62,17 @@ func PrivateKeyForAccount(chainID *big.Int, address common.Address, passphrase s
 	return crypto.ToECDSAUnsafe(keyBytes), nil
 }
 
+// StoreKey stores a private key in the local keystore for a chain, encrypted
+// with the supplied passphrase.
+func StoreKey(chainID *big.Int, key *ecdsa.PrivateKey, passphrase string) (accounts.Account, error) {
+	ks := keystore.NewKeyStore(cli.GethKeystoreDir(chainID), keystore.StandardScryptN, keystore.StandardScryptP)
+	account, err := ks.ImportECDSA(key, passphrase)
+	if err != nil {
+		return accounts.Account{}, fmt.Errorf("unable to store key for %v: %v", crypto.PubkeyToAddress(key.PublicKey).Hex(), err)
+	}
+	return account, nil
+}
+
 const (
 	keyHeaderKDF = "scrypt"
 
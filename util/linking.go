@@ -0,0 +1,146 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// LibraryPlaceholder returns the placeholder used by solc for a library with
+// the given fully-qualified name, for example "contracts/Lib.sol:Lib".
+func LibraryPlaceholder(name string) string {
+	return fmt.Sprintf("$%s$", hex.EncodeToString(crypto.Keccak256([]byte(name)))[:34])
+}
+
+// Link links libraries in to the contract's bytecode.  Libraries are keyed by
+// name, optionally prefixed with their source file, for example "Lib" or
+// "contracts/Lib.sol:Lib".  Libraries that are not supplied remain unlinked.
+func (c *Contract) Link(libraries map[string]common.Address) error {
+	var err error
+	c.LinkReferences, err = linkBytecode(c.Binary, c.LinkReferences, libraries)
+	if err != nil {
+		return err
+	}
+	c.DeployedLinkReferences, err = linkBytecode(c.DeployedBinary, c.DeployedLinkReferences, libraries)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// NameLibraries names hashed placeholder references for the libraries with
+// the given fully-qualified names, so that they can be linked and reported by
+// name.  Names that do not match a placeholder are ignored.
+func (c *Contract) NameLibraries(names []string) {
+	for _, name := range names {
+		c.LinkReferences.rename(name)
+		c.DeployedLinkReferences.rename(name)
+	}
+}
+
+// UnlinkedLibraries returns the names of the libraries that have yet to be
+// linked in to the contract's bytecode.
+func (c *Contract) UnlinkedLibraries() []string {
+	names := make(map[string]bool)
+	for _, refs := range []LinkReferences{c.LinkReferences, c.DeployedLinkReferences} {
+		for file, libraries := range refs {
+			for name := range libraries {
+				names[libraryName(file, name)] = true
+			}
+		}
+	}
+
+	res := make([]string, 0, len(names))
+	for name := range names {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// linkBytecode links the supplied libraries in to the bytecode, returning
+// the references that remain unlinked.
+func linkBytecode(bytecode []byte,
+	refs LinkReferences,
+	libraries map[string]common.Address,
+) (
+	LinkReferences,
+	error,
+) {
+	remaining := make(LinkReferences)
+	for file, names := range refs {
+		for name, locations := range names {
+			address, found := findLibrary(file, name, libraries)
+			if !found {
+				if _, exists := remaining[file]; !exists {
+					remaining[file] = make(map[string][]LinkReference)
+				}
+				remaining[file][name] = locations
+				continue
+			}
+			for _, location := range locations {
+				if location.Length != common.AddressLength || location.Start < 0 || location.Start+location.Length > len(bytecode) {
+					return nil, fmt.Errorf("invalid link reference for library %s", libraryName(file, name))
+				}
+				copy(bytecode[location.Start:], address.Bytes())
+			}
+		}
+	}
+	if len(remaining) == 0 {
+		return nil, nil
+	}
+
+	return remaining, nil
+}
+
+// findLibrary finds the address of the library in the supplied libraries.
+func findLibrary(file string, name string, libraries map[string]common.Address) (common.Address, bool) {
+	for key, address := range libraries {
+		keyFile, keyName := "", key
+		if pos := strings.LastIndex(key, ":"); pos != -1 {
+			keyFile, keyName = key[:pos], key[pos+1:]
+		}
+		if file == "" && strings.HasPrefix(name, "$") {
+			// Hashed placeholders can only be matched by fully-qualified name.
+			if keyFile != "" && LibraryPlaceholder(key) == name {
+				return address, true
+			}
+			continue
+		}
+		if keyName != name {
+			continue
+		}
+		if keyFile == "" || keyFile == file || strings.HasSuffix(file, "/"+keyFile) {
+			return address, true
+		}
+	}
+
+	return common.Address{}, false
+}
+
+func libraryName(file string, name string) string {
+	if file == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s:%s", file, name)
+}
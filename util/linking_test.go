@@ -0,0 +1,170 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryPlaceholder(t *testing.T) {
+	placeholder := LibraryPlaceholder("Lib.sol:Lib")
+	require.Len(t, placeholder, 36)
+	require.True(t, strings.HasPrefix(placeholder, "$"))
+	require.True(t, strings.HasSuffix(placeholder, "$"))
+	require.NotEqual(t, placeholder, LibraryPlaceholder("Other.sol:Lib"))
+}
+
+func TestLink(t *testing.T) {
+	libAddress := common.HexToAddress("0x52f1A3027d3aA514F17E454C93ae1F79b3B12d5d")
+	otherAddress := common.HexToAddress("0x5FfC014343cd971B7eb70732021E26C35B744cc4")
+	hashed := "6001__" + LibraryPlaceholder("src/Lib.sol:Lib") + "__00"
+	named := "6001__src/Lib.sol:Lib" + strings.Repeat("_", 23) + "00" + "73__src/Other.sol:Other" + strings.Repeat("_", 19) + "00"
+
+	tests := []struct {
+		name      string
+		bytecode  string
+		libraries map[string]common.Address
+		unlinked  []string
+		linked    map[int]common.Address
+	}{
+		{
+			name:      "NoLibraries",
+			bytecode:  named,
+			libraries: map[string]common.Address{},
+			unlinked:  []string{"src/Lib.sol:Lib", "src/Other.sol:Other"},
+		},
+		{
+			name:      "ByName",
+			bytecode:  named,
+			libraries: map[string]common.Address{"Lib": libAddress},
+			unlinked:  []string{"src/Other.sol:Other"},
+			linked:    map[int]common.Address{2: libAddress},
+		},
+		{
+			name:      "ByFullName",
+			bytecode:  named,
+			libraries: map[string]common.Address{"src/Lib.sol:Lib": libAddress, "Other.sol:Other": otherAddress},
+			unlinked:  []string{},
+			linked:    map[int]common.Address{2: libAddress, 24: otherAddress},
+		},
+		{
+			name:      "WrongFile",
+			bytecode:  named,
+			libraries: map[string]common.Address{"other/Lib.sol:Lib": libAddress},
+			unlinked:  []string{"src/Lib.sol:Lib", "src/Other.sol:Other"},
+		},
+		{
+			name:      "HashedByName",
+			bytecode:  hashed,
+			libraries: map[string]common.Address{"Lib": libAddress},
+			unlinked:  []string{LibraryPlaceholder("src/Lib.sol:Lib")},
+		},
+		{
+			name:      "HashedByFullName",
+			bytecode:  hashed,
+			libraries: map[string]common.Address{"src/Lib.sol:Lib": libAddress},
+			unlinked:  []string{},
+			linked:    map[int]common.Address{2: libAddress},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bytecode, refs, err := DecodeBytecode(test.bytecode)
+			require.NoError(t, err)
+			contract := &Contract{Binary: bytecode, LinkReferences: refs}
+			require.NoError(t, contract.Link(test.libraries))
+			require.Equal(t, test.unlinked, contract.UnlinkedLibraries())
+			for start, address := range test.linked {
+				require.Equal(t, address.Bytes(), contract.Binary[start:start+20])
+			}
+		})
+	}
+}
+
+func TestLinkInvalidReference(t *testing.T) {
+	contract := &Contract{
+		Binary:         make([]byte, 10),
+		LinkReferences: LinkReferences{"Lib.sol": {"Lib": {{Start: 0, Length: 20}}}},
+	}
+	require.EqualError(t, contract.Link(map[string]common.Address{"Lib": {}}), "invalid link reference for library Lib.sol:Lib")
+}
+
+func TestLinkCombinedJSON(t *testing.T) {
+	input := `{"contracts":{"src/Store.sol:Store":{"abi":[],"bin":"6001__` + LibraryPlaceholder("src/Lib.sol:Lib") + `__00"},"src/Lib.sol:Lib":{"abi":[],"bin":"60016002"}}}`
+	contract, err := ParseContractJSON(input, "Store")
+	require.NoError(t, err)
+	require.Equal(t, []string{"src/Lib.sol:Lib"}, contract.UnlinkedLibraries())
+
+	libAddress := common.HexToAddress("0x52f1A3027d3aA514F17E454C93ae1F79b3B12d5d")
+	require.NoError(t, contract.Link(map[string]common.Address{"Lib": libAddress}))
+	require.Empty(t, contract.UnlinkedLibraries())
+	require.Equal(t, libAddress.Bytes(), contract.Binary[2:22])
+}
+
+func TestNameLibraries(t *testing.T) {
+	bytecode, refs, err := DecodeBytecode("6001__" + LibraryPlaceholder("src/Lib.sol:Lib") + "__00")
+	require.NoError(t, err)
+	contract := &Contract{Binary: bytecode, LinkReferences: refs}
+	require.Equal(t, []string{LibraryPlaceholder("src/Lib.sol:Lib")}, contract.UnlinkedLibraries())
+
+	contract.NameLibraries([]string{"src/Other.sol:Other", "src/Lib.sol:Lib"})
+	require.Equal(t, []string{"src/Lib.sol:Lib"}, contract.UnlinkedLibraries())
+
+	libAddress := common.HexToAddress("0x52f1A3027d3aA514F17E454C93ae1F79b3B12d5d")
+	require.NoError(t, contract.Link(map[string]common.Address{"Lib": libAddress}))
+	require.Empty(t, contract.UnlinkedLibraries())
+	require.Equal(t, libAddress.Bytes(), contract.Binary[2:22])
+}
+
+func TestContractNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		names []string
+	}{
+		{
+			name:  "Combined",
+			input: `{"contracts":{"src/Store.sol:Store":{"abi":[],"bin":"6001"},"src/Lib.sol:Lib":{"abi":[],"bin":"6002"}}}`,
+			names: []string{"src/Lib.sol:Lib", "src/Store.sol:Store"},
+		},
+		{
+			name:  "Standard",
+			input: `{"contracts":{"src/Store.sol":{"Store":{"abi":[]},"Lib":{"abi":[]}}}}`,
+			names: []string{"src/Store.sol:Lib", "src/Store.sol:Store"},
+		},
+		{
+			name:  "Hardhat",
+			input: `{"contractName":"Lib","sourceName":"contracts/Lib.sol","abi":[],"bytecode":"0x6001"}`,
+			names: []string{"contracts/Lib.sol:Lib"},
+		},
+		{
+			name:  "Foundry",
+			input: `{"abi":[],"bytecode":{"object":"0x6001"},"metadata":{"settings":{"compilationTarget":{"src/Lib.sol":"Lib"}}}}`,
+			names: []string{"src/Lib.sol:Lib"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			names, err := ContractNames(test.input)
+			require.NoError(t, err)
+			require.Equal(t, test.names, names)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// Multicall3Address is the address of the Multicall3 contract, which is the same on all chains where it is deployed.
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// multicall3ABI is the part of the Multicall3 ABI used to batch calls.
+var multicall3ABI = mustParseABI(`[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`)
+
+func mustParseABI(input string) abi.ABI {
+	res, err := abi.JSON(strings.NewReader(input))
+	if err != nil {
+		panic(err)
+	}
+
+	return res
+}
+
+// MulticallBackend is the backend required to make batched calls.
+type MulticallBackend interface {
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// MulticallCall is a call to be batched with Multicall3.
+type MulticallCall struct {
+	Target       common.Address
+	AllowFailure bool
+	Data         []byte
+}
+
+// MulticallResult is the result of a batched call.
+type MulticallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// multicall3Call and multicall3Result match the Multicall3 structs for packing.
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type multicall3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// PackAggregate3 returns the data for a Multicall3 aggregate3 call.
+func PackAggregate3(calls []*MulticallCall) ([]byte, error) {
+	args := make([]multicall3Call, len(calls))
+	for i, call := range calls {
+		args[i] = multicall3Call{
+			Target:       call.Target,
+			AllowFailure: call.AllowFailure,
+			CallData:     call.Data,
+		}
+	}
+
+	return multicall3ABI.Pack("aggregate3", args)
+}
+
+// Aggregate3 makes the calls in a single eth_call to Multicall3.  If a call
+// that does not allow failure fails then the entire call fails.  The block
+// number can be nil, in which case the calls are made against the latest block.
+func Aggregate3(ctx context.Context, backend MulticallBackend, from common.Address, blockNumber *big.Int, calls []*MulticallCall) ([]*MulticallResult, error) {
+	data, err := PackAggregate3(calls)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pack calls")
+	}
+	output, err := backend.CallContract(ctx, ethereum.CallMsg{
+		From: from,
+		To:   &Multicall3Address,
+		Data: data,
+	}, blockNumber)
+	if err != nil {
+		return nil, errors.Wrap(err, "multicall failed")
+	}
+	if len(output) == 0 {
+		return nil, errors.New("multicall returned no data; Multicall3 might not be available on this chain")
+	}
+
+	var results []multicall3Result
+	if err := multicall3ABI.UnpackIntoInterface(&results, "aggregate3", output); err != nil {
+		return nil, errors.Wrap(err, "failed to unpack multicall results")
+	}
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("expected %d multicall results, received %d", len(calls), len(results))
+	}
+
+	res := make([]*MulticallResult, len(results))
+	for i, result := range results {
+		res[i] = &MulticallResult{
+			Success:    result.Success,
+			ReturnData: result.ReturnData,
+		}
+	}
+
+	return res, nil
+}
+
+// ABICall is a call to a contract method for batching.
+type ABICall struct {
+	Target common.Address
+	ABI    *abi.ABI
+	Method string
+	Args   []interface{}
+	// Outputs are the decoded outputs of the call, if it succeeded.
+	Outputs []interface{}
+	// Err is the error from the call, if it failed.
+	Err error
+}
+
+// MulticallABI makes the calls, in a single request if Multicall3 is
+// available otherwise one at a time, setting the outputs or error of each.
+// The block number can be nil, in which case the calls are made against the
+// latest block.
+func MulticallABI(ctx context.Context, backend MulticallBackend, from common.Address, blockNumber *big.Int, calls []*ABICall) error {
+	batched := make([]*MulticallCall, len(calls))
+	for i, call := range calls {
+		data, err := call.ABI.Pack(call.Method, call.Args...)
+		if err != nil {
+			return errors.Wrapf(err, "failed to pack call to %s", call.Method)
+		}
+		batched[i] = &MulticallCall{Target: call.Target, AllowFailure: true, Data: data}
+	}
+
+	code, err := backend.CodeAt(ctx, Multicall3Address, blockNumber)
+	if err != nil {
+		return errors.Wrap(err, "failed to check for Multicall3")
+	}
+	var results []*MulticallResult
+	if len(code) > 0 {
+		results, err = Aggregate3(ctx, backend, from, blockNumber, batched)
+		if err != nil {
+			return err
+		}
+	} else {
+		results = make([]*MulticallResult, len(batched))
+		for i, call := range batched {
+			output, err := backend.CallContract(ctx, ethereum.CallMsg{From: from, To: &call.Target, Data: call.Data}, blockNumber)
+			results[i] = &MulticallResult{Success: err == nil, ReturnData: output}
+			if err != nil {
+				calls[i].Err = err
+			}
+		}
+	}
+
+	for i, result := range results {
+		call := calls[i]
+		switch {
+		case call.Err != nil:
+		case !result.Success:
+			call.Err = RevertError(result.ReturnData)
+		case len(result.ReturnData) == 0 && len(call.ABI.Methods[call.Method].Outputs) > 0:
+			call.Err = errors.New("no data returned")
+		default:
+			call.Outputs, call.Err = call.ABI.Unpack(call.Method, result.ReturnData)
+		}
+	}
+
+	return nil
+}
+
+// RevertError returns an error for the data returned by a reverted call,
+// including the reason if it is supplied.
+func RevertError(data []byte) error {
+	reason, err := abi.UnpackRevert(data)
+	if err == nil {
+		return fmt.Errorf("execution reverted: %s", reason)
+	}
+	if len(data) > 0 {
+		return fmt.Errorf("execution reverted: %#x", data)
+	}
+
+	return errors.New("execution reverted")
+}
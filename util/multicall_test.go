@@ -0,0 +1,159 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+var multicallTestABI = mustParseABI(`[{"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"fail","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`)
+
+// multicallBackend executes calls to a test contract, and to Multicall3 if deployed.
+type multicallBackend struct {
+	deployed bool
+	calls    int
+	// blockNumbers are the block numbers at which requests were made.
+	blockNumbers []*big.Int
+}
+
+func (b *multicallBackend) CodeAt(_ context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	b.blockNumbers = append(b.blockNumbers, blockNumber)
+	if contract == Multicall3Address && b.deployed {
+		return []byte{0x01}, nil
+	}
+
+	return nil, nil
+}
+
+func (b *multicallBackend) CallContract(_ context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	b.calls++
+	b.blockNumbers = append(b.blockNumbers, blockNumber)
+	if *call.To != Multicall3Address {
+		return b.execute(*call.To, call.Data)
+	}
+	if !b.deployed {
+		return nil, nil
+	}
+
+	method := multicall3ABI.Methods["aggregate3"]
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := args[0].([]struct {
+		Target       common.Address `json:"target"`
+		AllowFailure bool           `json:"allowFailure"`
+		CallData     []byte         `json:"callData"`
+	})
+	results := make([]multicall3Result, len(calls))
+	for i, call := range calls {
+		output, err := b.execute(call.Target, call.CallData)
+		if err != nil {
+			if !call.AllowFailure {
+				return nil, errors.New("execution reverted: Multicall3: call failed")
+			}
+			results[i] = multicall3Result{ReturnData: output}
+			continue
+		}
+		results[i] = multicall3Result{Success: true, ReturnData: output}
+	}
+
+	return method.Outputs.Pack(results)
+}
+
+// execute returns the contract's address as its value, and reverts with a reason for fail().
+func (b *multicallBackend) execute(target common.Address, data []byte) ([]byte, error) {
+	if bytes.Equal(data[:4], multicallTestABI.Methods["fail"].ID) {
+		// Error("nope").
+		return common.FromHex("0x08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000046e6f706500000000000000000000000000000000000000000000000000000000"), errors.New("execution reverted")
+	}
+
+	return multicallTestABI.Methods["value"].Outputs.Pack(target.Big())
+}
+
+func TestAggregate3(t *testing.T) {
+	backend := &multicallBackend{deployed: true}
+	value, err := multicallTestABI.Pack("value")
+	require.NoError(t, err)
+	fail, err := multicallTestABI.Pack("fail")
+	require.NoError(t, err)
+
+	results, err := Aggregate3(context.Background(), backend, common.Address{}, nil, []*MulticallCall{
+		{Target: common.BigToAddress(big.NewInt(5)), Data: value},
+		{Target: common.BigToAddress(big.NewInt(6)), AllowFailure: true, Data: fail},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.True(t, results[0].Success)
+	require.Equal(t, common.BigToHash(big.NewInt(5)).Bytes(), results[0].ReturnData)
+	require.False(t, results[1].Success)
+	require.EqualError(t, RevertError(results[1].ReturnData), "execution reverted: nope")
+
+	_, err = Aggregate3(context.Background(), backend, common.Address{}, nil, []*MulticallCall{
+		{Target: common.BigToAddress(big.NewInt(6)), Data: fail},
+	})
+	require.EqualError(t, err, "multicall failed: execution reverted: Multicall3: call failed")
+
+	_, err = Aggregate3(context.Background(), &multicallBackend{}, common.Address{}, nil, []*MulticallCall{})
+	require.EqualError(t, err, "multicall returned no data; Multicall3 might not be available on this chain")
+}
+
+func TestMulticallABI(t *testing.T) {
+	tests := []struct {
+		name     string
+		deployed bool
+		requests int
+	}{
+		{
+			name:     "Batched",
+			deployed: true,
+			requests: 1,
+		},
+		{
+			name:     "Sequential",
+			deployed: false,
+			requests: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := &multicallBackend{deployed: test.deployed}
+			calls := []*ABICall{
+				{Target: common.BigToAddress(big.NewInt(1)), ABI: &multicallTestABI, Method: "value"},
+				{Target: common.BigToAddress(big.NewInt(2)), ABI: &multicallTestABI, Method: "fail"},
+				{Target: common.BigToAddress(big.NewInt(3)), ABI: &multicallTestABI, Method: "value"},
+			}
+			blockNumber := big.NewInt(1234)
+			require.NoError(t, MulticallABI(context.Background(), backend, common.Address{}, blockNumber, calls))
+			require.Equal(t, test.requests, backend.calls)
+			for _, requested := range backend.blockNumbers {
+				require.Equal(t, blockNumber, requested)
+			}
+			require.Equal(t, []interface{}{big.NewInt(1)}, calls[0].Outputs)
+			require.NoError(t, calls[0].Err)
+			require.Error(t, calls[1].Err)
+			require.Nil(t, calls[1].Outputs)
+			require.Equal(t, []interface{}{big.NewInt(3)}, calls[2].Outputs)
+		})
+	}
+}
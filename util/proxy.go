@@ -0,0 +1,186 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+var (
+	// EIP1967ImplementationSlot is the storage slot holding the implementation of an EIP-1967 proxy.
+	EIP1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	// EIP1967AdminSlot is the storage slot holding the admin of an EIP-1967 proxy.
+	EIP1967AdminSlot = common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+	// EIP1967BeaconSlot is the storage slot holding the beacon of an EIP-1967 proxy.
+	EIP1967BeaconSlot = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+	// EIP1822ProxiableSlot is the storage slot holding the implementation of an EIP-1822 proxy.
+	EIP1822ProxiableSlot = common.HexToHash("0xc5f16f0fcc639fa48a6947836d9850f504798523bf8c9a3a87d5876cf622bcf7")
+
+	// eip1167Prefix and eip1167Suffix surround the implementation address in the code of an EIP-1167 minimal proxy.
+	eip1167Prefix = common.FromHex("0x363d3d373d3d3d363d73")
+	eip1167Suffix = common.FromHex("0x5af43d82803e903d91602b57fd5bf3")
+
+	// implementationSelector is the selector of implementation(), provided by EIP-1967 beacons.
+	implementationSelector = common.FromHex("0x5c60da1b")
+	// proxiableUUIDSelector is the selector of proxiableUUID(), provided by UUPS implementations.
+	proxiableUUIDSelector = common.FromHex("0x52d1902d")
+)
+
+// errNoContract is returned when there is no contract at an address.
+var errNoContract = errors.New("no contract at address")
+
+// maxProxyChain is the maximum number of proxies followed when resolving a proxy chain.
+const maxProxyChain = 8
+
+// ProxyBackend is the backend required to inspect proxies.
+type ProxyBackend interface {
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+	StorageAt(ctx context.Context, contract common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// Proxy contains information about a proxy contract.
+type Proxy struct {
+	// Address is the address of the proxy.
+	Address common.Address
+	// Type is the type of the proxy, for example "EIP-1967".
+	Type string
+	// Implementation is the address of the contract to which the proxy delegates.
+	Implementation common.Address
+	// Admin is the address of the admin of the proxy, if any.
+	Admin common.Address
+	// Beacon is the address of the beacon from which the implementation is obtained, if any.
+	Beacon common.Address
+}
+
+// DetectProxy returns information about the proxy at the given address, or nil if
+// the address is not a recognised proxy.  The block number can be nil, in which
+// case the proxy is inspected at the latest block.
+func DetectProxy(ctx context.Context, backend ProxyBackend, address common.Address, blockNumber *big.Int) (*Proxy, error) {
+	code, err := backend.CodeAt(ctx, address, blockNumber)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain code")
+	}
+	if len(code) == 0 {
+		return nil, errNoContract
+	}
+
+	if len(code) == len(eip1167Prefix)+common.AddressLength+len(eip1167Suffix) &&
+		bytes.HasPrefix(code, eip1167Prefix) &&
+		bytes.HasSuffix(code, eip1167Suffix) {
+		return &Proxy{
+			Address:        address,
+			Type:           "EIP-1167",
+			Implementation: common.BytesToAddress(code[len(eip1167Prefix) : len(eip1167Prefix)+common.AddressLength]),
+		}, nil
+	}
+
+	proxy := &Proxy{Address: address}
+	if proxy.Admin, err = storedAddress(ctx, backend, address, EIP1967AdminSlot, blockNumber); err != nil {
+		return nil, err
+	}
+	if proxy.Implementation, err = storedAddress(ctx, backend, address, EIP1967ImplementationSlot, blockNumber); err != nil {
+		return nil, err
+	}
+	if proxy.Implementation != (common.Address{}) {
+		proxy.Type = "EIP-1967"
+		if isUUPS(ctx, backend, proxy.Implementation, blockNumber) {
+			proxy.Type = "EIP-1967 (UUPS)"
+		}
+		return proxy, nil
+	}
+
+	if proxy.Beacon, err = storedAddress(ctx, backend, address, EIP1967BeaconSlot, blockNumber); err != nil {
+		return nil, err
+	}
+	if proxy.Beacon != (common.Address{}) {
+		res, err := backend.CallContract(ctx, ethereum.CallMsg{To: &proxy.Beacon, Data: implementationSelector}, blockNumber)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain implementation from beacon")
+		}
+		if len(res) != common.HashLength {
+			return nil, errors.New("beacon returned invalid implementation")
+		}
+		proxy.Type = "EIP-1967 beacon"
+		proxy.Implementation = common.BytesToAddress(res)
+		return proxy, nil
+	}
+
+	if proxy.Implementation, err = storedAddress(ctx, backend, address, EIP1822ProxiableSlot, blockNumber); err != nil {
+		return nil, err
+	}
+	if proxy.Implementation != (common.Address{}) {
+		proxy.Type = "EIP-1822"
+		return proxy, nil
+	}
+
+	return nil, nil
+}
+
+// ProxyChain returns the chain of proxies starting at the given address, ending
+// with the proxy that delegates to the final implementation.  The chain is empty
+// if the address is not a proxy.  The final implementation can have no code, for
+// example if it has yet to be deployed.  The block number can be nil, in which
+// case the chain is resolved at the latest block.
+func ProxyChain(ctx context.Context, backend ProxyBackend, address common.Address, blockNumber *big.Int) ([]*Proxy, error) {
+	chain := make([]*Proxy, 0)
+	seen := map[common.Address]bool{address: true}
+	for {
+		proxy, err := DetectProxy(ctx, backend, address, blockNumber)
+		if err != nil {
+			if len(chain) > 0 && errors.Is(err, errNoContract) {
+				return chain, nil
+			}
+			if len(chain) > 0 {
+				return nil, errors.Wrapf(err, "failed to inspect implementation %s", address.Hex())
+			}
+			return nil, err
+		}
+		if proxy == nil {
+			return chain, nil
+		}
+		chain = append(chain, proxy)
+		if seen[proxy.Implementation] {
+			return nil, errors.New("proxy chain contains a loop")
+		}
+		if len(chain) == maxProxyChain {
+			return nil, errors.New("proxy chain is too long")
+		}
+		seen[proxy.Implementation] = true
+		address = proxy.Implementation
+	}
+}
+
+// storedAddress returns the address held in a storage slot.
+func storedAddress(ctx context.Context, backend ProxyBackend, address common.Address, slot common.Hash, blockNumber *big.Int) (common.Address, error) {
+	value, err := backend.StorageAt(ctx, address, slot, blockNumber)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "failed to obtain storage")
+	}
+
+	return common.BytesToAddress(value), nil
+}
+
+// isUUPS returns true if the implementation states that it is a UUPS implementation.
+func isUUPS(ctx context.Context, backend ProxyBackend, implementation common.Address, blockNumber *big.Int) bool {
+	res, err := backend.CallContract(ctx, ethereum.CallMsg{To: &implementation, Data: proxiableUUIDSelector}, blockNumber)
+
+	return err == nil && bytes.Equal(res, EIP1967ImplementationSlot.Bytes())
+}
@@ -0,0 +1,157 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxySlots(t *testing.T) {
+	slot := func(name string) common.Hash {
+		hash := crypto.Keccak256Hash([]byte(name)).Big()
+		return common.BigToHash(hash.Sub(hash, big.NewInt(1)))
+	}
+	require.Equal(t, slot("eip1967.proxy.implementation"), EIP1967ImplementationSlot)
+	require.Equal(t, slot("eip1967.proxy.admin"), EIP1967AdminSlot)
+	require.Equal(t, slot("eip1967.proxy.beacon"), EIP1967BeaconSlot)
+	require.Equal(t, crypto.Keccak256Hash([]byte("PROXIABLE")), EIP1822ProxiableSlot)
+}
+
+func TestProxyChain(t *testing.T) {
+	ctx := context.Background()
+
+	implementation := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	transparent := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	admin := common.HexToAddress("0x1000000000000000000000000000000000000003")
+	beacon := common.HexToAddress("0x1000000000000000000000000000000000000004")
+	beaconProxy := common.HexToAddress("0x1000000000000000000000000000000000000005")
+	uups := common.HexToAddress("0x1000000000000000000000000000000000000006")
+	minimal := common.HexToAddress("0x1000000000000000000000000000000000000007")
+	loop := common.HexToAddress("0x1000000000000000000000000000000000000008")
+	uupsImplementation := common.HexToAddress("0x1000000000000000000000000000000000000009")
+	undeployed := common.HexToAddress("0x100000000000000000000000000000000000000a")
+	undeployedImplementation := common.HexToAddress("0x100000000000000000000000000000000000000b")
+
+	// Code that returns the given word.
+	returns := func(word common.Hash) []byte {
+		code := append([]byte{0x7f}, word.Bytes()...)
+		return append(code, common.FromHex("0x60005260206000f3")...)
+	}
+	// Code for a proxy; the details do not matter as it is never called.
+	proxyCode := common.FromHex("0x363d3d373d3d3d363d")
+	minimalCode := append(append(common.CopyBytes(eip1167Prefix), transparent.Bytes()...), eip1167Suffix...)
+
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		implementation: {Code: returns(common.Hash{}), Balance: big.NewInt(0)},
+		transparent: {Code: proxyCode, Balance: big.NewInt(0), Storage: map[common.Hash]common.Hash{
+			EIP1967ImplementationSlot: common.BytesToHash(implementation.Bytes()),
+			EIP1967AdminSlot:          common.BytesToHash(admin.Bytes()),
+		}},
+		beacon: {Code: returns(common.BytesToHash(implementation.Bytes())), Balance: big.NewInt(0)},
+		beaconProxy: {Code: proxyCode, Balance: big.NewInt(0), Storage: map[common.Hash]common.Hash{
+			EIP1967BeaconSlot: common.BytesToHash(beacon.Bytes()),
+		}},
+		uups: {Code: proxyCode, Balance: big.NewInt(0), Storage: map[common.Hash]common.Hash{
+			EIP1967ImplementationSlot: common.BytesToHash(uupsImplementation.Bytes()),
+		}},
+		uupsImplementation: {Code: returns(EIP1967ImplementationSlot), Balance: big.NewInt(0)},
+		minimal:            {Code: minimalCode, Balance: big.NewInt(0)},
+		loop: {Code: proxyCode, Balance: big.NewInt(0), Storage: map[common.Hash]common.Hash{
+			EIP1822ProxiableSlot: common.BytesToHash(loop.Bytes()),
+		}},
+		undeployed: {Code: proxyCode, Balance: big.NewInt(0), Storage: map[common.Hash]common.Hash{
+			EIP1967ImplementationSlot: common.BytesToHash(undeployedImplementation.Bytes()),
+		}},
+	}, 30000000)
+	defer backend.Close()
+
+	tests := []struct {
+		name    string
+		address common.Address
+		chain   []*Proxy
+		err     string
+	}{
+		{
+			name:    "Missing",
+			address: common.HexToAddress("0x2000000000000000000000000000000000000000"),
+			err:     "no contract at address",
+		},
+		{
+			name:    "NotProxy",
+			address: implementation,
+			chain:   []*Proxy{},
+		},
+		{
+			name:    "Transparent",
+			address: transparent,
+			chain: []*Proxy{
+				{Address: transparent, Type: "EIP-1967", Implementation: implementation, Admin: admin},
+			},
+		},
+		{
+			name:    "Beacon",
+			address: beaconProxy,
+			chain: []*Proxy{
+				{Address: beaconProxy, Type: "EIP-1967 beacon", Implementation: implementation, Beacon: beacon},
+			},
+		},
+		{
+			name:    "UUPS",
+			address: uups,
+			chain: []*Proxy{
+				{Address: uups, Type: "EIP-1967 (UUPS)", Implementation: uupsImplementation},
+			},
+		},
+		{
+			name:    "Chain",
+			address: minimal,
+			chain: []*Proxy{
+				{Address: minimal, Type: "EIP-1167", Implementation: transparent},
+				{Address: transparent, Type: "EIP-1967", Implementation: implementation, Admin: admin},
+			},
+		},
+		{
+			name:    "NoImplementationCode",
+			address: undeployed,
+			chain: []*Proxy{
+				{Address: undeployed, Type: "EIP-1967", Implementation: undeployedImplementation},
+			},
+		},
+		{
+			name:    "Loop",
+			address: loop,
+			err:     "proxy chain contains a loop",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chain, err := ProxyChain(ctx, backend, test.address, nil)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.chain, chain)
+			}
+		})
+	}
+}
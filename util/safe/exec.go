@@ -0,0 +1,105 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safe
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/ethereal/v2/util/contracts"
+)
+
+// ExecTransactionData returns the data to call execTransaction() for a transaction with the given signatures.
+func ExecTransactionData(tx *Transaction, signatures []*Signature) ([]byte, error) {
+	abi, err := contracts.SafeMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return abi.Pack("execTransaction",
+		tx.To,
+		bigOrZero(tx.Value),
+		tx.Data,
+		tx.Operation,
+		bigOrZero(tx.SafeTxGas),
+		bigOrZero(tx.BaseGas),
+		bigOrZero(tx.GasPrice),
+		tx.GasToken,
+		tx.RefundReceiver,
+		EncodeSignatures(signatures),
+	)
+}
+
+// IsExecTransaction returns true if the data is a call to execTransaction().
+func IsExecTransaction(data []byte) bool {
+	abi, err := contracts.SafeMetaData.GetAbi()
+	if err != nil {
+		return false
+	}
+	return len(data) >= 4 && bytes.Equal(data[:4], abi.Methods["execTransaction"].ID)
+}
+
+// DecodeExecTransaction decodes the data of a call to execTransaction(), returning
+// the transaction and the signatures.  The Safe, chain ID, version and nonce are
+// not part of the call so are left empty in the returned transaction.
+func DecodeExecTransaction(data []byte) (*Transaction, []byte, error) {
+	if !IsExecTransaction(data) {
+		return nil, nil, errors.New("data is not a call to execTransaction")
+	}
+	abi, err := contracts.SafeMetaData.GetAbi()
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := abi.Methods["execTransaction"].Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unpack execTransaction")
+	}
+
+	tx := &Transaction{}
+	var signatures []byte
+	var ok bool
+	if tx.To, ok = values[0].(common.Address); !ok {
+		return nil, nil, errors.New("invalid to")
+	}
+	if tx.Value, ok = values[1].(*big.Int); !ok {
+		return nil, nil, errors.New("invalid value")
+	}
+	if tx.Data, ok = values[2].([]byte); !ok {
+		return nil, nil, errors.New("invalid data")
+	}
+	if tx.Operation, ok = values[3].(uint8); !ok {
+		return nil, nil, errors.New("invalid operation")
+	}
+	if tx.SafeTxGas, ok = values[4].(*big.Int); !ok {
+		return nil, nil, errors.New("invalid safe tx gas")
+	}
+	if tx.BaseGas, ok = values[5].(*big.Int); !ok {
+		return nil, nil, errors.New("invalid base gas")
+	}
+	if tx.GasPrice, ok = values[6].(*big.Int); !ok {
+		return nil, nil, errors.New("invalid gas price")
+	}
+	if tx.GasToken, ok = values[7].(common.Address); !ok {
+		return nil, nil, errors.New("invalid gas token")
+	}
+	if tx.RefundReceiver, ok = values[8].(common.Address); !ok {
+		return nil, nil, errors.New("invalid refund receiver")
+	}
+	if signatures, ok = values[9].([]byte); !ok {
+		return nil, nil, errors.New("invalid signatures")
+	}
+
+	return tx, signatures, nil
+}
@@ -0,0 +1,205 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package safe
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTransaction() *Transaction {
+	return &Transaction{
+		Safe:           common.HexToAddress("0x5FfC014343cd971B7eb70732021E26C35B744cc4"),
+		ChainID:        big.NewInt(1),
+		Version:        "1.3.0",
+		To:             common.HexToAddress("0x2ab7150Bba7D5F181b3aF5623e52b15bB1054845"),
+		Value:          big.NewInt(1000000000000000000),
+		Data:           []byte{0x01, 0x02, 0x03},
+		Operation:      OperationCall,
+		SafeTxGas:      big.NewInt(100000),
+		BaseGas:        big.NewInt(0),
+		GasPrice:       big.NewInt(0),
+		GasToken:       common.Address{},
+		RefundReceiver: common.Address{},
+		Nonce:          big.NewInt(5),
+	}
+}
+
+// typedDataHash calculates the hash of a transaction using go-ethereum's EIP-712 implementation.
+func typedDataHash(t *testing.T, tx *Transaction, legacy bool) common.Hash {
+	t.Helper()
+	domainType := []apitypes.Type{{Name: "chainId", Type: "uint256"}, {Name: "verifyingContract", Type: "address"}}
+	domain := apitypes.TypedDataDomain{ChainId: (*math.HexOrDecimal256)(tx.ChainID), VerifyingContract: tx.Safe.Hex()}
+	if legacy {
+		domainType = domainType[1:]
+		domain.ChainId = nil
+	}
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": domainType,
+			"SafeTx": []apitypes.Type{
+				{Name: "to", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "data", Type: "bytes"},
+				{Name: "operation", Type: "uint8"},
+				{Name: "safeTxGas", Type: "uint256"},
+				{Name: "baseGas", Type: "uint256"},
+				{Name: "gasPrice", Type: "uint256"},
+				{Name: "gasToken", Type: "address"},
+				{Name: "refundReceiver", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+			},
+		},
+		PrimaryType: "SafeTx",
+		Domain:      domain,
+		Message: apitypes.TypedDataMessage{
+			"to":             tx.To.Hex(),
+			"value":          tx.Value.String(),
+			"data":           hexutil.Encode(tx.Data),
+			"operation":      fmt.Sprintf("%d", tx.Operation),
+			"safeTxGas":      tx.SafeTxGas.String(),
+			"baseGas":        tx.BaseGas.String(),
+			"gasPrice":       tx.GasPrice.String(),
+			"gasToken":       tx.GasToken.Hex(),
+			"refundReceiver": tx.RefundReceiver.Hex(),
+			"nonce":          tx.Nonce.String(),
+		},
+	}
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	require.NoError(t, err)
+	return common.BytesToHash(hash)
+}
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		legacy  bool
+	}{
+		{name: "Current", version: "1.4.1"},
+		{name: "Unversioned", version: ""},
+		{name: "L2", version: "1.3.0+L2"},
+		{name: "Legacy", version: "1.1.1", legacy: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tx := testTransaction()
+			tx.Version = test.version
+			hash, err := tx.Hash()
+			require.NoError(t, err)
+			assert.Equal(t, typedDataHash(t, tx, test.legacy), hash)
+		})
+	}
+}
+
+func TestTransactionJSON(t *testing.T) {
+	tx := testTransaction()
+	data, err := json.Marshal(tx)
+	require.NoError(t, err)
+
+	res := &Transaction{}
+	require.NoError(t, json.Unmarshal(data, res))
+	assert.Equal(t, tx, res)
+
+	// Alter the transaction so that it no longer matches its hash.
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	raw["to"] = "0x0000000000000000000000000000000000000001"
+	data, err = json.Marshal(raw)
+	require.NoError(t, err)
+	require.ErrorContains(t, json.Unmarshal(data, res), "does not match calculated hash")
+}
+
+func TestSignatures(t *testing.T) {
+	tx := testTransaction()
+	hash, err := tx.Hash()
+	require.NoError(t, err)
+
+	signatures := make([]*Signature, 0)
+	for i := 1; i <= 3; i++ {
+		key, err := crypto.ToECDSA(common.LeftPadBytes([]byte{byte(i)}, 32))
+		require.NoError(t, err)
+		signature, err := Sign(hash, key)
+		require.NoError(t, err)
+
+		signer, err := RecoverSigner(hash, signature.Signature)
+		require.NoError(t, err)
+		assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+
+		// Ensure JSON round-trips, and that the signer is recovered.
+		data, err := json.Marshal(signature)
+		require.NoError(t, err)
+		res := &Signature{}
+		require.NoError(t, json.Unmarshal(data, res))
+		assert.Equal(t, signature, res)
+
+		signatures = append(signatures, signature)
+	}
+	approved := ApprovedHashSignature(hash, common.HexToAddress("0x0000000000000000000000000000000000000001"))
+	signer, err := RecoverSigner(hash, approved.Signature)
+	require.NoError(t, err)
+	assert.Equal(t, approved.Signer, signer)
+	signatures = append(signatures, approved)
+
+	encoded := EncodeSignatures(signatures)
+	split := SplitSignatures(encoded)
+	require.Len(t, split, len(signatures))
+	// Signers must be in ascending order.
+	var previous common.Address
+	for _, signature := range split {
+		signer, err := RecoverSigner(hash, signature)
+		require.NoError(t, err)
+		assert.Positive(t, signer.Big().Cmp(previous.Big()))
+		previous = signer
+	}
+}
+
+func TestExecTransaction(t *testing.T) {
+	tx := testTransaction()
+	hash, err := tx.Hash()
+	require.NoError(t, err)
+	key, err := crypto.ToECDSA(common.LeftPadBytes([]byte{0x01}, 32))
+	require.NoError(t, err)
+	signature, err := Sign(hash, key)
+	require.NoError(t, err)
+
+	data, err := ExecTransactionData(tx, []*Signature{signature})
+	require.NoError(t, err)
+	require.True(t, IsExecTransaction(data))
+
+	decoded, signatures, err := DecodeExecTransaction(data)
+	require.NoError(t, err)
+	assert.Equal(t, signature.Signature, signatures)
+	// Fields not in the call are supplied by the caller.
+	decoded.Safe = tx.Safe
+	decoded.ChainID = tx.ChainID
+	decoded.Version = tx.Version
+	decoded.Nonce = tx.Nonce
+	decodedHash, err := decoded.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, hash, decodedHash)
+
+	_, _, err = DecodeExecTransaction([]byte{0x01, 0x02, 0x03, 0x04})
+	require.EqualError(t, err, "data is not a call to execTransaction")
+}
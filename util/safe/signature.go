@@ -0,0 +1,184 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package safe
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+const signatureLength = 65
+
+// Signature is an owner's signature of a Safe transaction.
+type Signature struct {
+	SafeTxHash common.Hash
+	Signer     common.Address
+	Signature  []byte
+}
+
+// signatureJSON is the JSON representation of a signature.
+type signatureJSON struct {
+	SafeTxHash string `json:"safe_tx_hash"`
+	Signer     string `json:"signer"`
+	Signature  string `json:"signature"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s *Signature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&signatureJSON{
+		SafeTxHash: s.SafeTxHash.Hex(),
+		Signer:     s.Signer.Hex(),
+		Signature:  fmt.Sprintf("%#x", s.Signature),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// The signer is recovered from the signature and checked against that supplied.
+func (s *Signature) UnmarshalJSON(input []byte) error {
+	var data signatureJSON
+	if err := json.Unmarshal(input, &data); err != nil {
+		return errors.Wrap(err, "invalid JSON")
+	}
+
+	hash, err := hex.DecodeString(strings.TrimPrefix(data.SafeTxHash, "0x"))
+	if err != nil || len(hash) != common.HashLength {
+		return errors.New("invalid safe transaction hash")
+	}
+	s.SafeTxHash = common.BytesToHash(hash)
+	s.Signature, err = hex.DecodeString(strings.TrimPrefix(data.Signature, "0x"))
+	if err != nil {
+		return errors.Wrap(err, "invalid signature")
+	}
+	s.Signer, err = RecoverSigner(s.SafeTxHash, s.Signature)
+	if err != nil {
+		return err
+	}
+	if data.Signer != "" && !strings.EqualFold(data.Signer, s.Signer.Hex()) {
+		return fmt.Errorf("signature is from %s, not %s", s.Signer.Hex(), data.Signer)
+	}
+
+	return nil
+}
+
+// Sign signs a Safe transaction hash with a private key.
+func Sign(hash common.Hash, key *ecdsa.PrivateKey) (*Signature, error) {
+	signature, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+	// Safe expects the v value as 27 or 28.
+	signature[64] += 27
+
+	return &Signature{
+		SafeTxHash: hash,
+		Signer:     crypto.PubkeyToAddress(key.PublicKey),
+		Signature:  signature,
+	}, nil
+}
+
+// ApprovedHashSignature returns a signature for an owner that is either
+// executing the transaction itself, or has previously approved the hash
+// on-chain with approveHash().
+func ApprovedHashSignature(hash common.Hash, owner common.Address) *Signature {
+	signature := make([]byte, signatureLength)
+	copy(signature[12:32], owner.Bytes())
+	signature[64] = 1
+
+	return &Signature{
+		SafeTxHash: hash,
+		Signer:     owner,
+		Signature:  signature,
+	}
+}
+
+// RecoverSigner recovers the signer of a Safe transaction hash.
+func RecoverSigner(hash common.Hash, signature []byte) (common.Address, error) {
+	if len(signature) != signatureLength {
+		return common.Address{}, fmt.Errorf("signature must be %d bytes", signatureLength)
+	}
+
+	v := signature[64]
+	switch {
+	case v == 0:
+		return common.Address{}, errors.New("contract signatures are not supported")
+	case v == 1:
+		// Approved hash; the owner is in r.
+		return common.BytesToAddress(signature[12:32]), nil
+	case v == 27 || v == 28:
+		return recoverAddress(hash.Bytes(), signature, v-27)
+	case v == 31 || v == 32:
+		// eth_sign signature, over the hash with the Ethereum signed message prefix.
+		return recoverAddress(accounts.TextHash(hash.Bytes()), signature, v-31)
+	default:
+		return common.Address{}, fmt.Errorf("invalid signature type %d", v)
+	}
+}
+
+func recoverAddress(hash []byte, signature []byte, recoveryID byte) (common.Address, error) {
+	sig := make([]byte, signatureLength)
+	copy(sig, signature)
+	sig[64] = recoveryID
+	pubKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "failed to recover signer")
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// EncodeSignatures encodes signatures in the form required by execTransaction(),
+// which is concatenated in ascending order of signer.
+func EncodeSignatures(signatures []*Signature) []byte {
+	sorted := make([]*Signature, len(signatures))
+	copy(sorted, signatures)
+	sort.Slice(sorted, func(i int, j int) bool {
+		return bytes.Compare(sorted[i].Signer.Bytes(), sorted[j].Signer.Bytes()) < 0
+	})
+
+	res := make([]byte, 0, len(sorted)*signatureLength)
+	for _, signature := range sorted {
+		res = append(res, signature.Signature...)
+	}
+	return res
+}
+
+// SplitSignatures splits signatures as supplied to execTransaction() in to
+// their individual fixed-length parts.
+func SplitSignatures(data []byte) [][]byte {
+	res := make([][]byte, 0)
+	// Contract signatures point to dynamic data after the fixed-length
+	// signatures, so stop at the earliest such data.
+	end := len(data)
+	for i := 0; i+signatureLength <= end; i += signatureLength {
+		signature := data[i : i+signatureLength]
+		if signature[64] == 0 {
+			offset := new(big.Int).SetBytes(signature[32:64])
+			if offset.IsInt64() && offset.Int64() < int64(end) {
+				end = int(offset.Int64())
+			}
+		}
+		res = append(res, signature)
+	}
+	return res
+}
@@ -0,0 +1,257 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package safe provides support for transactions on Safe multisig contracts.
+package safe
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+const (
+	// OperationCall is a standard call from the Safe.
+	OperationCall = uint8(0)
+	// OperationDelegateCall is a delegate call from the Safe.
+	OperationDelegateCall = uint8(1)
+)
+
+var (
+	safeTxTypeHash = crypto.Keccak256([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
+	// Safe 1.3.0 and later include the chain ID in the domain.
+	domainTypeHash = crypto.Keccak256([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+	// Safes prior to 1.3.0 only include the contract address in the domain.
+	legacyDomainTypeHash = crypto.Keccak256([]byte("EIP712Domain(address verifyingContract)"))
+)
+
+// Transaction is a transaction to be executed by a Safe.
+type Transaction struct {
+	Safe           common.Address
+	ChainID        *big.Int
+	Version        string
+	To             common.Address
+	Value          *big.Int
+	Data           []byte
+	Operation      uint8
+	SafeTxGas      *big.Int
+	BaseGas        *big.Int
+	GasPrice       *big.Int
+	GasToken       common.Address
+	RefundReceiver common.Address
+	Nonce          *big.Int
+}
+
+// transactionJSON is the JSON representation of a transaction.
+type transactionJSON struct {
+	Safe           string `json:"safe"`
+	ChainID        string `json:"chain_id"`
+	Version        string `json:"version"`
+	To             string `json:"to"`
+	Value          string `json:"value"`
+	Data           string `json:"data"`
+	Operation      uint8  `json:"operation"`
+	SafeTxGas      string `json:"safe_tx_gas"`
+	BaseGas        string `json:"base_gas"`
+	GasPrice       string `json:"gas_price"`
+	GasToken       string `json:"gas_token"`
+	RefundReceiver string `json:"refund_receiver"`
+	Nonce          string `json:"nonce"`
+	SafeTxHash     string `json:"safe_tx_hash"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t *Transaction) MarshalJSON() ([]byte, error) {
+	hash, err := t.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&transactionJSON{
+		Safe:           t.Safe.Hex(),
+		ChainID:        bigString(t.ChainID),
+		Version:        t.Version,
+		To:             t.To.Hex(),
+		Value:          bigString(t.Value),
+		Data:           fmt.Sprintf("%#x", t.Data),
+		Operation:      t.Operation,
+		SafeTxGas:      bigString(t.SafeTxGas),
+		BaseGas:        bigString(t.BaseGas),
+		GasPrice:       bigString(t.GasPrice),
+		GasToken:       t.GasToken.Hex(),
+		RefundReceiver: t.RefundReceiver.Hex(),
+		Nonce:          bigString(t.Nonce),
+		SafeTxHash:     hash.Hex(),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Transaction) UnmarshalJSON(input []byte) error {
+	var data transactionJSON
+	if err := json.Unmarshal(input, &data); err != nil {
+		return errors.Wrap(err, "invalid JSON")
+	}
+
+	var err error
+	if t.Safe, err = parseAddress("safe", data.Safe); err != nil {
+		return err
+	}
+	if t.ChainID, err = parseBig("chain ID", data.ChainID); err != nil {
+		return err
+	}
+	t.Version = data.Version
+	if t.To, err = parseAddress("to", data.To); err != nil {
+		return err
+	}
+	if t.Value, err = parseBig("value", data.Value); err != nil {
+		return err
+	}
+	if t.Data, err = hex.DecodeString(strings.TrimPrefix(data.Data, "0x")); err != nil {
+		return errors.Wrap(err, "invalid data")
+	}
+	if data.Operation != OperationCall && data.Operation != OperationDelegateCall {
+		return fmt.Errorf("invalid operation %d", data.Operation)
+	}
+	t.Operation = data.Operation
+	if t.SafeTxGas, err = parseBig("safe tx gas", data.SafeTxGas); err != nil {
+		return err
+	}
+	if t.BaseGas, err = parseBig("base gas", data.BaseGas); err != nil {
+		return err
+	}
+	if t.GasPrice, err = parseBig("gas price", data.GasPrice); err != nil {
+		return err
+	}
+	if t.GasToken, err = parseAddress("gas token", data.GasToken); err != nil {
+		return err
+	}
+	if t.RefundReceiver, err = parseAddress("refund receiver", data.RefundReceiver); err != nil {
+		return err
+	}
+	if t.Nonce, err = parseBig("nonce", data.Nonce); err != nil {
+		return err
+	}
+
+	if data.SafeTxHash != "" {
+		// Ensure that the hash matches the contents of the transaction.
+		hash, err := t.Hash()
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(hash.Hex(), data.SafeTxHash) {
+			return fmt.Errorf("safe transaction hash %s does not match calculated hash %s", data.SafeTxHash, hash.Hex())
+		}
+	}
+
+	return nil
+}
+
+// Hash returns the EIP-712 hash of the transaction, as signed by the owners of the Safe.
+func (t *Transaction) Hash() (common.Hash, error) {
+	domainSeparator, err := t.domainSeparator()
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	structHash := crypto.Keccak256(
+		safeTxTypeHash,
+		common.LeftPadBytes(t.To.Bytes(), 32),
+		math.U256Bytes(bigOrZero(t.Value)),
+		crypto.Keccak256(t.Data),
+		common.LeftPadBytes([]byte{t.Operation}, 32),
+		math.U256Bytes(bigOrZero(t.SafeTxGas)),
+		math.U256Bytes(bigOrZero(t.BaseGas)),
+		math.U256Bytes(bigOrZero(t.GasPrice)),
+		common.LeftPadBytes(t.GasToken.Bytes(), 32),
+		common.LeftPadBytes(t.RefundReceiver.Bytes(), 32),
+		math.U256Bytes(bigOrZero(t.Nonce)),
+	)
+
+	return common.BytesToHash(crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, structHash)), nil
+}
+
+// domainSeparator returns the EIP-712 domain separator for the transaction's Safe.
+func (t *Transaction) domainSeparator() ([]byte, error) {
+	legacy, err := isLegacyVersion(t.Version)
+	if err != nil {
+		return nil, err
+	}
+	if legacy {
+		return crypto.Keccak256(legacyDomainTypeHash, common.LeftPadBytes(t.Safe.Bytes(), 32)), nil
+	}
+	if t.ChainID == nil {
+		return nil, errors.New("chain ID required")
+	}
+	return crypto.Keccak256(domainTypeHash, math.U256Bytes(new(big.Int).Set(t.ChainID)), common.LeftPadBytes(t.Safe.Bytes(), 32)), nil
+}
+
+// isLegacyVersion returns true if the Safe version predates 1.3.0.
+// An empty version is considered to be current.
+func isLegacyVersion(version string) (bool, error) {
+	if version == "" {
+		return false, nil
+	}
+	bits := strings.Split(strings.SplitN(version, "+", 2)[0], ".")
+	if len(bits) < 2 {
+		return false, fmt.Errorf("invalid version %s", version)
+	}
+	major, err := strconv.Atoi(bits[0])
+	if err != nil {
+		return false, fmt.Errorf("invalid version %s", version)
+	}
+	minor, err := strconv.Atoi(bits[1])
+	if err != nil {
+		return false, fmt.Errorf("invalid version %s", version)
+	}
+	return major < 1 || (major == 1 && minor < 3), nil
+}
+
+func bigOrZero(input *big.Int) *big.Int {
+	if input == nil {
+		return new(big.Int)
+	}
+	// U256Bytes modifies its input, so work on a copy.
+	return new(big.Int).Set(input)
+}
+
+func bigString(input *big.Int) string {
+	return bigOrZero(input).String()
+}
+
+func parseBig(name string, input string) (*big.Int, error) {
+	if input == "" {
+		return new(big.Int), nil
+	}
+	res, ok := new(big.Int).SetString(input, 0)
+	if !ok || res.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s %s", name, input)
+	}
+	return res, nil
+}
+
+func parseAddress(name string, input string) (common.Address, error) {
+	if input == "" {
+		return common.Address{}, nil
+	}
+	if !common.IsHexAddress(input) {
+		return common.Address{}, fmt.Errorf("invalid %s %s", name, input)
+	}
+	return common.HexToAddress(input), nil
+}
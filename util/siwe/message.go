@@ -0,0 +1,311 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package siwe provides support for Sign-In with Ethereum (EIP-4361) messages.
+package siwe
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	headerSuffix = " wants you to sign in with your Ethereum account:"
+	// Version is the only version of message defined by EIP-4361.
+	Version       = "1"
+	nonceAlphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+var (
+	nonceRegex   = regexp.MustCompile(`^[a-zA-Z0-9]{8,}$`)
+	addressRegex = regexp.MustCompile(`^0x[a-fA-F0-9]{40}$`)
+	schemeRegex  = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+\-.]*$`)
+)
+
+// Message is a Sign-In with Ethereum message.
+type Message struct {
+	Scheme         string
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        *big.Int
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// GenerateNonce generates a random alphanumeric nonce.
+func GenerateNonce() (string, error) {
+	res := make([]byte, 17)
+	max := big.NewInt(int64(len(nonceAlphabet)))
+	for i := range res {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to generate nonce")
+		}
+		res[i] = nonceAlphabet[n.Int64()]
+	}
+	return string(res), nil
+}
+
+// String returns the canonical text of the message, to be signed.
+func (m *Message) String() string {
+	var b strings.Builder
+	if m.Scheme != "" {
+		b.WriteString(m.Scheme)
+		b.WriteString("://")
+	}
+	b.WriteString(m.Domain)
+	b.WriteString(headerSuffix)
+	b.WriteString("\n")
+	b.WriteString(m.Address.Hex())
+	b.WriteString("\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "URI: %s\n", m.URI)
+	fmt.Fprintf(&b, "Version: %s\n", m.Version)
+	fmt.Fprintf(&b, "Chain ID: %v\n", m.ChainID)
+	fmt.Fprintf(&b, "Nonce: %s\n", m.Nonce)
+	fmt.Fprintf(&b, "Issued At: %s", m.IssuedAt.Format(time.RFC3339))
+	if m.ExpirationTime != nil {
+		fmt.Fprintf(&b, "\nExpiration Time: %s", m.ExpirationTime.Format(time.RFC3339))
+	}
+	if m.NotBefore != nil {
+		fmt.Fprintf(&b, "\nNot Before: %s", m.NotBefore.Format(time.RFC3339))
+	}
+	if m.RequestID != "" {
+		fmt.Fprintf(&b, "\nRequest ID: %s", m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, resource := range m.Resources {
+			fmt.Fprintf(&b, "\n- %s", resource)
+		}
+	}
+
+	return b.String()
+}
+
+// Validate checks that the fields of the message are well-formed.
+func (m *Message) Validate() error {
+	if m.Domain == "" {
+		return errors.New("domain missing")
+	}
+	if strings.ContainsAny(m.Domain, "/ \n") {
+		return errors.New("invalid domain")
+	}
+	if m.Scheme != "" && !schemeRegex.MatchString(m.Scheme) {
+		return errors.New("invalid scheme")
+	}
+	if strings.Contains(m.Statement, "\n") {
+		return errors.New("statement cannot contain newlines")
+	}
+	if err := validateURI(m.URI); err != nil {
+		return errors.Wrap(err, "invalid URI")
+	}
+	if m.Version != Version {
+		return fmt.Errorf("unsupported version %q", m.Version)
+	}
+	if m.ChainID == nil || m.ChainID.Sign() <= 0 {
+		return errors.New("invalid chain ID")
+	}
+	if !nonceRegex.MatchString(m.Nonce) {
+		return errors.New("nonce must be at least 8 alphanumeric characters")
+	}
+	if m.IssuedAt.IsZero() {
+		return errors.New("issued at missing")
+	}
+	if m.ExpirationTime != nil && !m.ExpirationTime.After(m.IssuedAt) {
+		return errors.New("expiration time must be after issued at")
+	}
+	if m.NotBefore != nil && m.ExpirationTime != nil && !m.ExpirationTime.After(*m.NotBefore) {
+		return errors.New("expiration time must be after not before")
+	}
+	for _, resource := range m.Resources {
+		if err := validateURI(resource); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("invalid resource %q", resource))
+		}
+	}
+
+	return nil
+}
+
+// ValidateTime checks that the message is valid at the given time.
+func (m *Message) ValidateTime(now time.Time) error {
+	if m.IssuedAt.After(now) {
+		return errors.New("message issued in the future")
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return errors.New("message not yet valid")
+	}
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return errors.New("message expired")
+	}
+
+	return nil
+}
+
+// ParseMessage parses the canonical text of a message.
+func ParseMessage(input string) (*Message, error) {
+	input = strings.TrimSuffix(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	lines := strings.Split(input, "\n")
+	// Header, address, blank, [statement], blank, and the required fields.
+	if len(lines) < 9 {
+		return nil, errors.New("message too short")
+	}
+	m := &Message{}
+
+	header, found := strings.CutSuffix(lines[0], headerSuffix)
+	if !found {
+		return nil, errors.New("invalid header")
+	}
+	if scheme, domain, found := strings.Cut(header, "://"); found {
+		m.Scheme = scheme
+		m.Domain = domain
+	} else {
+		m.Domain = header
+	}
+
+	if !addressRegex.MatchString(lines[1]) {
+		return nil, errors.New("invalid address")
+	}
+	m.Address = common.HexToAddress(lines[1])
+	if m.Address.Hex() != lines[1] {
+		return nil, errors.New("address must be checksummed")
+	}
+
+	if lines[2] != "" {
+		return nil, errors.New("missing blank line after address")
+	}
+	pos := 3
+	if lines[pos] != "" {
+		m.Statement = lines[pos]
+		pos++
+	}
+	if lines[pos] != "" {
+		return nil, errors.New("missing blank line before fields")
+	}
+	pos++
+
+	var err error
+	if m.URI, pos, err = requiredField(lines, pos, "URI"); err != nil {
+		return nil, err
+	}
+	if m.Version, pos, err = requiredField(lines, pos, "Version"); err != nil {
+		return nil, err
+	}
+	var chainID string
+	if chainID, pos, err = requiredField(lines, pos, "Chain ID"); err != nil {
+		return nil, err
+	}
+	var ok bool
+	if m.ChainID, ok = new(big.Int).SetString(chainID, 10); !ok {
+		return nil, errors.New("invalid chain ID")
+	}
+	if m.Nonce, pos, err = requiredField(lines, pos, "Nonce"); err != nil {
+		return nil, err
+	}
+	var issuedAt string
+	if issuedAt, pos, err = requiredField(lines, pos, "Issued At"); err != nil {
+		return nil, err
+	}
+	if m.IssuedAt, err = time.Parse(time.RFC3339, issuedAt); err != nil {
+		return nil, errors.Wrap(err, "invalid issued at")
+	}
+
+	if value, found := optionalField(lines, &pos, "Expiration Time"); found {
+		expirationTime, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid expiration time")
+		}
+		m.ExpirationTime = &expirationTime
+	}
+	if value, found := optionalField(lines, &pos, "Not Before"); found {
+		notBefore, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid not before")
+		}
+		m.NotBefore = &notBefore
+	}
+	if value, found := optionalField(lines, &pos, "Request ID"); found {
+		m.RequestID = value
+	}
+	if pos < len(lines) && lines[pos] == "Resources:" {
+		pos++
+		for pos < len(lines) && strings.HasPrefix(lines[pos], "- ") {
+			m.Resources = append(m.Resources, strings.TrimPrefix(lines[pos], "- "))
+			pos++
+		}
+	}
+	if pos != len(lines) {
+		return nil, fmt.Errorf("unexpected line %q", lines[pos])
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func requiredField(lines []string, pos int, name string) (string, int, error) {
+	if pos >= len(lines) {
+		return "", pos, fmt.Errorf("%s missing", name)
+	}
+	value, found := strings.CutPrefix(lines[pos], name+": ")
+	if !found {
+		return "", pos, fmt.Errorf("%s missing", name)
+	}
+	return value, pos + 1, nil
+}
+
+func optionalField(lines []string, pos *int, name string) (string, bool) {
+	if *pos >= len(lines) {
+		return "", false
+	}
+	value, found := strings.CutPrefix(lines[*pos], name+": ")
+	if found {
+		*pos++
+	}
+	return value, found
+}
+
+func validateURI(input string) error {
+	if input == "" {
+		return errors.New("missing")
+	}
+	uri, err := url.Parse(input)
+	if err != nil {
+		return err
+	}
+	if uri.Scheme == "" {
+		return errors.New("no scheme")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siwe
+
+import (
+	"context"
+	"crypto/ecdsa"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/ethereal/v2/util"
+)
+
+// Hash returns the hash of message text, as signed with personal_sign.
+func Hash(text string) []byte {
+	return accounts.TextHash([]byte(text))
+}
+
+// Sign signs message text with the given key.
+func Sign(text string, key *ecdsa.PrivateKey) ([]byte, error) {
+	signature, err := crypto.Sign(Hash(text), key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign message")
+	}
+	// Wallets provide the v value as 27 or 28.
+	signature[64] += 27
+
+	return signature, nil
+}
+
+// VerifySignature verifies the signature of message text by the given address.
+// If caller is supplied then contract signatures are also verified.
+func VerifySignature(ctx context.Context,
+	caller bind.ContractCaller,
+	address common.Address,
+	text string,
+	signature []byte,
+) error {
+	hash := Hash(text)
+
+	if len(signature) == crypto.SignatureLength && !util.IsERC6492Signature(signature) {
+		sig := make([]byte, crypto.SignatureLength)
+		copy(sig, signature)
+		if sig[64] >= 27 {
+			sig[64] -= 27
+		}
+		if pubKey, err := crypto.SigToPub(hash, sig); err == nil && crypto.PubkeyToAddress(*pubKey) == address {
+			return nil
+		}
+	}
+
+	if caller == nil {
+		return errors.New("signature does not match address")
+	}
+	code, err := caller.CodeAt(ctx, address, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain address code")
+	}
+	if len(code) == 0 && !util.IsERC6492Signature(signature) {
+		return errors.New("signature does not match address")
+	}
+	valid, err := util.VerifyContractSignature(ctx, caller, address, hash, signature)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("contract signature is not valid")
+	}
+
+	return nil
+}
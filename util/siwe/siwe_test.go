@@ -0,0 +1,186 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siwe
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// exampleMessage is the example message from EIP-4361.
+const exampleMessage = `service.invalid wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+I accept the ServiceOrg Terms of Service: https://service.invalid/tos
+
+URI: https://service.invalid/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "Example",
+			input: exampleMessage,
+		},
+		{
+			name:  "TrailingNewline",
+			input: exampleMessage + "\n",
+		},
+		{
+			name:  "NoStatement",
+			input: strings.Replace(exampleMessage, "I accept the ServiceOrg Terms of Service: https://service.invalid/tos\n\n", "\n", 1),
+		},
+		{
+			name:  "Scheme",
+			input: "https://" + exampleMessage,
+		},
+		{
+			name:  "Optional",
+			input: strings.Replace(exampleMessage, "Issued At: 2021-09-30T16:25:24Z", "Issued At: 2021-09-30T16:25:24Z\nExpiration Time: 2021-09-30T17:25:24Z\nNot Before: 2021-09-30T16:30:00Z\nRequest ID: abc", 1),
+		},
+		{
+			name:  "Short",
+			input: "service.invalid wants you to sign in with your Ethereum account:",
+			err:   "message too short",
+		},
+		{
+			name:  "BadHeader",
+			input: strings.Replace(exampleMessage, "wants you to sign in", "wants you to log in", 1),
+			err:   "invalid header",
+		},
+		{
+			name:  "NotChecksummed",
+			input: strings.Replace(exampleMessage, "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", 1),
+			err:   "address must be checksummed",
+		},
+		{
+			name:  "MissingURI",
+			input: strings.Replace(exampleMessage, "URI: https://service.invalid/login\n", "", 1),
+			err:   "URI missing",
+		},
+		{
+			name:  "BadVersion",
+			input: strings.Replace(exampleMessage, "Version: 1", "Version: 2", 1),
+			err:   `unsupported version "2"`,
+		},
+		{
+			name:  "BadChainID",
+			input: strings.Replace(exampleMessage, "Chain ID: 1", "Chain ID: one", 1),
+			err:   "invalid chain ID",
+		},
+		{
+			name:  "ShortNonce",
+			input: strings.Replace(exampleMessage, "Nonce: 32891756", "Nonce: 1234", 1),
+			err:   "nonce must be at least 8 alphanumeric characters",
+		},
+		{
+			name:  "BadIssuedAt",
+			input: strings.Replace(exampleMessage, "Issued At: 2021-09-30T16:25:24Z", "Issued At: yesterday", 1),
+			err:   `invalid issued at: parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`,
+		},
+		{
+			name:  "ExpiredBeforeIssued",
+			input: strings.Replace(exampleMessage, "Issued At: 2021-09-30T16:25:24Z", "Issued At: 2021-09-30T16:25:24Z\nExpiration Time: 2021-09-30T15:25:24Z", 1),
+			err:   "expiration time must be after issued at",
+		},
+		{
+			name:  "Trailing",
+			input: exampleMessage + "\nExtra: field",
+			err:   `unexpected line "Extra: field"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg, err := ParseMessage(test.input)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, strings.TrimSuffix(test.input, "\n"), msg.String())
+			}
+		})
+	}
+}
+
+func TestValidateTime(t *testing.T) {
+	msg, err := ParseMessage(strings.Replace(exampleMessage, "Issued At: 2021-09-30T16:25:24Z", "Issued At: 2021-09-30T16:25:24Z\nExpiration Time: 2021-09-30T17:25:24Z\nNot Before: 2021-09-30T16:30:00Z", 1))
+	require.NoError(t, err)
+
+	require.EqualError(t, msg.ValidateTime(time.Date(2021, 9, 30, 16, 0, 0, 0, time.UTC)), "message issued in the future")
+	require.EqualError(t, msg.ValidateTime(time.Date(2021, 9, 30, 16, 27, 0, 0, time.UTC)), "message not yet valid")
+	require.NoError(t, msg.ValidateTime(time.Date(2021, 9, 30, 16, 45, 0, 0, time.UTC)))
+	require.EqualError(t, msg.ValidateTime(time.Date(2021, 9, 30, 17, 25, 24, 0, time.UTC)), "message expired")
+}
+
+func TestGenerateNonce(t *testing.T) {
+	nonce, err := GenerateNonce()
+	require.NoError(t, err)
+	require.Regexp(t, nonceRegex, nonce)
+	nonce2, err := GenerateNonce()
+	require.NoError(t, err)
+	require.NotEqual(t, nonce, nonce2)
+}
+
+func TestSignatures(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	msg := &Message{
+		Domain:   "service.invalid",
+		Address:  address,
+		URI:      "https://service.invalid/login",
+		Version:  Version,
+		ChainID:  big.NewInt(1),
+		Nonce:    "32891756",
+		IssuedAt: time.Date(2021, 9, 30, 16, 25, 24, 0, time.UTC),
+	}
+	require.NoError(t, msg.Validate())
+	text := msg.String()
+
+	signature, err := Sign(text, key)
+	require.NoError(t, err)
+	require.NoError(t, VerifySignature(ctx, nil, address, text, signature))
+	require.EqualError(t, VerifySignature(ctx, nil, address, text+" ", signature), "signature does not match address")
+	require.EqualError(t, VerifySignature(ctx, nil, common.HexToAddress("0x01"), text, signature), "signature does not match address")
+
+	// A contract that accepts all signatures.
+	contract := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		contract: {Code: common.FromHex("0x631626ba7e60e01b60005260206000f3"), Balance: big.NewInt(0)},
+	}, 30000000)
+	defer backend.Close()
+	require.NoError(t, VerifySignature(ctx, backend, contract, text, []byte{0x01}))
+	require.EqualError(t, VerifySignature(ctx, backend, common.HexToAddress("0x02"), text, signature), "signature does not match address")
+}
@@ -0,0 +1,184 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slip39
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+const (
+	// secretIndex is the x co-ordinate of the shared secret.
+	secretIndex = 255
+	// digestIndex is the x co-ordinate of the digest share.
+	digestIndex = 254
+	// digestLength is the length of the digest in bytes.
+	digestLength = 4
+)
+
+// rawShare is a share prior to encoding.
+type rawShare struct {
+	x    byte
+	data []byte
+}
+
+// expTable and logTable are the exponent and logarithm tables for GF(256)
+// with the Rijndael polynomial x^8 + x^4 + x^3 + x + 1.
+var expTable, logTable = func() ([255]byte, [256]byte) {
+	var exp [255]byte
+	var log [256]byte
+	poly := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(poly)
+		log[poly] = byte(i)
+		// Multiply by the generator 3.
+		poly = (poly << 1) ^ poly
+		if poly&0x100 != 0 {
+			poly ^= 0x11b
+		}
+	}
+	return exp, log
+}()
+
+// interpolate returns f(x) for the polynomial that passes through the supplied shares.
+func interpolate(shares []*rawShare, x byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares for interpolation")
+	}
+	xs := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if xs[share.x] {
+			return nil, errors.New("share indices must be unique")
+		}
+		xs[share.x] = true
+		if len(share.data) != len(shares[0].data) {
+			return nil, errors.New("shares must have the same length")
+		}
+	}
+
+	for _, share := range shares {
+		if share.x == x {
+			return share.data, nil
+		}
+	}
+
+	// Sum of the logarithms of (x - x_i); subtraction in GF(256) is XOR.
+	logProd := 0
+	for _, share := range shares {
+		logProd += int(logTable[share.x^x])
+	}
+
+	res := make([]byte, len(shares[0].data))
+	for _, share := range shares {
+		logBasis := logProd - int(logTable[share.x^x])
+		for _, other := range shares {
+			if other.x != share.x {
+				logBasis -= int(logTable[share.x^other.x])
+			}
+		}
+		logBasis = ((logBasis % 255) + 255) % 255
+		for i, val := range share.data {
+			if val != 0 {
+				res[i] ^= expTable[(int(logTable[val])+logBasis)%255]
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// createDigest creates the digest used to verify the shared secret.
+func createDigest(randomData []byte, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomData)
+	mac.Write(secret)
+	return mac.Sum(nil)[:digestLength]
+}
+
+// splitSecret splits a secret in to count shares, threshold of which are required to recover it.
+func splitSecret(threshold int, count int, secret []byte) ([]*rawShare, error) {
+	if threshold < 1 {
+		return nil, errors.New("threshold must be at least 1")
+	}
+	if threshold > count {
+		return nil, fmt.Errorf("threshold %d cannot be greater than share count %d", threshold, count)
+	}
+	if count > maxShareCount {
+		return nil, fmt.Errorf("share count cannot be greater than %d", maxShareCount)
+	}
+
+	if threshold == 1 {
+		shares := make([]*rawShare, count)
+		for i := range shares {
+			shares[i] = &rawShare{x: byte(i), data: secret}
+		}
+		return shares, nil
+	}
+
+	randomShareCount := threshold - 2
+	shares := make([]*rawShare, 0, count)
+	for i := 0; i < randomShareCount; i++ {
+		data := make([]byte, len(secret))
+		if _, err := rand.Read(data); err != nil {
+			return nil, err
+		}
+		shares = append(shares, &rawShare{x: byte(i), data: data})
+	}
+
+	randomPart := make([]byte, len(secret)-digestLength)
+	if _, err := rand.Read(randomPart); err != nil {
+		return nil, err
+	}
+	digest := createDigest(randomPart, secret)
+
+	baseShares := make([]*rawShare, 0, threshold)
+	baseShares = append(baseShares, shares...)
+	baseShares = append(baseShares,
+		&rawShare{x: digestIndex, data: append(digest, randomPart...)},
+		&rawShare{x: secretIndex, data: secret},
+	)
+
+	for i := randomShareCount; i < count; i++ {
+		data, err := interpolate(baseShares, byte(i))
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, &rawShare{x: byte(i), data: data})
+	}
+
+	return shares, nil
+}
+
+// recoverSecret recovers a secret from threshold shares.
+func recoverSecret(threshold int, shares []*rawShare) ([]byte, error) {
+	if threshold == 1 {
+		return shares[0].data, nil
+	}
+
+	secret, err := interpolate(shares, secretIndex)
+	if err != nil {
+		return nil, err
+	}
+	digestShare, err := interpolate(shares, digestIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(digestShare[:digestLength], createDigest(digestShare[digestLength:], secret)) {
+		return nil, errors.New("invalid digest of the shared secret")
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,430 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slip39 implements SLIP-0039 Shamir's secret-sharing for mnemonic codes.
+package slip39
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	radixBits               = 10
+	idLengthBits            = 15
+	iterationExpBits        = 4
+	checksumWords           = 3
+	minMnemonicWords        = 20
+	minSecretBytes          = 16
+	maxShareCount           = 16
+	baseIterationCount      = 10000
+	roundCount              = 4
+	customization           = "shamir"
+	extendableCustomization = "shamir_extendable"
+)
+
+// Group defines the member threshold and count for a group of shares.
+type Group struct {
+	MemberThreshold int
+	MemberCount     int
+}
+
+// Share is a single decoded SLIP-39 share.
+type Share struct {
+	Identifier        uint16
+	Extendable        bool
+	IterationExponent int
+	GroupIndex        int
+	GroupThreshold    int
+	GroupCount        int
+	MemberIndex       int
+	MemberThreshold   int
+	Value             []byte
+}
+
+// GenerateMnemonics splits a master secret in to groups of mnemonic shares.
+// groupThreshold groups must be recovered to obtain the master secret, and each
+// group is recovered by obtaining the member threshold of its shares.
+func GenerateMnemonics(groupThreshold int,
+	groups []*Group,
+	masterSecret []byte,
+	passphrase []byte,
+	extendable bool,
+	iterationExponent int,
+) (
+	[][]string,
+	error,
+) {
+	if len(masterSecret) < minSecretBytes {
+		return nil, fmt.Errorf("master secret must be at least %d bits", minSecretBytes*8)
+	}
+	if len(masterSecret)%2 != 0 {
+		return nil, errors.New("master secret must be an even number of bytes")
+	}
+	if err := checkPassphrase(passphrase); err != nil {
+		return nil, err
+	}
+	if iterationExponent < 0 || iterationExponent >= 1<<iterationExpBits {
+		return nil, fmt.Errorf("iteration exponent must be between 0 and %d", 1<<iterationExpBits-1)
+	}
+	if groupThreshold < 1 || groupThreshold > len(groups) {
+		return nil, fmt.Errorf("group threshold must be between 1 and the number of groups (%d)", len(groups))
+	}
+	for i, group := range groups {
+		if group.MemberThreshold < 1 || group.MemberThreshold > group.MemberCount {
+			return nil, fmt.Errorf("group %d threshold must be between 1 and its member count", i+1)
+		}
+		if group.MemberThreshold == 1 && group.MemberCount > 1 {
+			return nil, fmt.Errorf("group %d cannot have a threshold of 1 with multiple members; use 1 of 1 instead", i+1)
+		}
+	}
+
+	var idBytes [2]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, err
+	}
+	identifier := binary.BigEndian.Uint16(idBytes[:]) & (1<<idLengthBits - 1)
+
+	ems := encrypt(masterSecret, passphrase, iterationExponent, identifier, extendable)
+
+	groupShares, err := splitSecret(groupThreshold, len(groups), ems)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([][]string, len(groups))
+	for i, groupShare := range groupShares {
+		memberShares, err := splitSecret(groups[i].MemberThreshold, groups[i].MemberCount, groupShare.data)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = make([]string, len(memberShares))
+		for j, memberShare := range memberShares {
+			share := &Share{
+				Identifier:        identifier,
+				Extendable:        extendable,
+				IterationExponent: iterationExponent,
+				GroupIndex:        int(groupShare.x),
+				GroupThreshold:    groupThreshold,
+				GroupCount:        len(groups),
+				MemberIndex:       int(memberShare.x),
+				MemberThreshold:   groups[i].MemberThreshold,
+				Value:             memberShare.data,
+			}
+			res[i][j] = share.Mnemonic()
+		}
+	}
+
+	return res, nil
+}
+
+// CombineMnemonics recovers the master secret from a set of mnemonic shares.
+func CombineMnemonics(mnemonics []string, passphrase []byte) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, errors.New("no mnemonics supplied")
+	}
+	if err := checkPassphrase(passphrase); err != nil {
+		return nil, err
+	}
+
+	shares := make([]*Share, len(mnemonics))
+	for i, mnemonic := range mnemonics {
+		share, err := ParseShare(mnemonic)
+		if err != nil {
+			return nil, fmt.Errorf("share %d: %w", i+1, err)
+		}
+		shares[i] = share
+	}
+
+	first := shares[0]
+	groups := make(map[int][]*Share)
+	for i, share := range shares {
+		if share.Identifier != first.Identifier ||
+			share.Extendable != first.Extendable ||
+			share.IterationExponent != first.IterationExponent {
+			return nil, fmt.Errorf("share %d does not belong to the same set as share 1", i+1)
+		}
+		if share.GroupThreshold != first.GroupThreshold || share.GroupCount != first.GroupCount {
+			return nil, fmt.Errorf("share %d has different group parameters from share 1", i+1)
+		}
+		for _, existing := range groups[share.GroupIndex] {
+			if existing.MemberThreshold != share.MemberThreshold {
+				return nil, fmt.Errorf("share %d has a different member threshold from other shares in group %d", i+1, share.GroupIndex+1)
+			}
+			if existing.MemberIndex == share.MemberIndex {
+				if string(existing.Value) != string(share.Value) {
+					return nil, fmt.Errorf("share %d conflicts with another share in group %d", i+1, share.GroupIndex+1)
+				}
+			}
+		}
+		groups[share.GroupIndex] = append(groups[share.GroupIndex], share)
+	}
+
+	groupIndices := make([]int, 0, len(groups))
+	for groupIndex := range groups {
+		groupIndices = append(groupIndices, groupIndex)
+	}
+	sort.Ints(groupIndices)
+
+	groupShares := make([]*rawShare, 0, first.GroupThreshold)
+	for _, groupIndex := range groupIndices {
+		memberShares := uniqueMemberShares(groups[groupIndex])
+		threshold := memberShares[0].MemberThreshold
+		if len(memberShares) < threshold {
+			continue
+		}
+		rawShares := make([]*rawShare, threshold)
+		for i := 0; i < threshold; i++ {
+			rawShares[i] = &rawShare{x: byte(memberShares[i].MemberIndex), data: memberShares[i].Value}
+		}
+		groupSecret, err := recoverSecret(threshold, rawShares)
+		if err != nil {
+			return nil, fmt.Errorf("group %d: %w", groupIndex+1, err)
+		}
+		groupShares = append(groupShares, &rawShare{x: byte(groupIndex), data: groupSecret})
+		if len(groupShares) == first.GroupThreshold {
+			break
+		}
+	}
+	if len(groupShares) < first.GroupThreshold {
+		return nil, fmt.Errorf("insufficient shares; %d complete group(s) required but %d available", first.GroupThreshold, len(groupShares))
+	}
+
+	ems, err := recoverSecret(first.GroupThreshold, groupShares)
+	if err != nil {
+		return nil, err
+	}
+
+	return decrypt(ems, passphrase, first.IterationExponent, first.Identifier, first.Extendable), nil
+}
+
+// uniqueMemberShares removes duplicate member shares.
+func uniqueMemberShares(shares []*Share) []*Share {
+	seen := make(map[int]bool, len(shares))
+	res := make([]*Share, 0, len(shares))
+	for _, share := range shares {
+		if !seen[share.MemberIndex] {
+			seen[share.MemberIndex] = true
+			res = append(res, share)
+		}
+	}
+	return res
+}
+
+// ParseShare parses a mnemonic in to a share.
+func ParseShare(mnemonic string) (*Share, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < minMnemonicWords {
+		return nil, fmt.Errorf("mnemonic must be at least %d words", minMnemonicWords)
+	}
+	indices := make([]int, len(words))
+	for i, word := range words {
+		index, exists := wordIndices[word]
+		if !exists {
+			return nil, fmt.Errorf("unknown word %q", word)
+		}
+		indices[i] = index
+	}
+
+	paddingBits := (radixBits * (len(words) - 7)) % 16
+	if paddingBits > 8 {
+		return nil, errors.New("invalid mnemonic length")
+	}
+
+	idExp := indices[0]<<radixBits | indices[1]
+	extendable := (idExp>>iterationExpBits)&1 == 1
+	if !verifyChecksum(indices, extendable) {
+		return nil, errors.New("invalid mnemonic checksum")
+	}
+
+	params := indices[2]<<radixBits | indices[3]
+	share := &Share{
+		Identifier:        uint16(idExp >> (iterationExpBits + 1)),
+		Extendable:        extendable,
+		IterationExponent: idExp & (1<<iterationExpBits - 1),
+		GroupIndex:        params >> 16,
+		GroupThreshold:    (params>>12)&0x0f + 1,
+		GroupCount:        (params>>8)&0x0f + 1,
+		MemberIndex:       (params >> 4) & 0x0f,
+		MemberThreshold:   params&0x0f + 1,
+	}
+	if share.GroupThreshold > share.GroupCount {
+		return nil, errors.New("group threshold cannot be greater than group count")
+	}
+
+	valueWords := indices[4 : len(indices)-checksumWords]
+	valueBytes := (radixBits*len(valueWords) - paddingBits) / 8
+	value := new(big.Int)
+	for _, index := range valueWords {
+		value.Lsh(value, radixBits)
+		value.Or(value, big.NewInt(int64(index)))
+	}
+	if value.BitLen() > valueBytes*8 {
+		return nil, errors.New("invalid mnemonic padding")
+	}
+	share.Value = value.FillBytes(make([]byte, valueBytes))
+	if len(share.Value) < minSecretBytes {
+		return nil, errors.New("share value too short")
+	}
+
+	return share, nil
+}
+
+// Mnemonic returns the mnemonic for the share.
+func (s *Share) Mnemonic() string {
+	ext := 0
+	if s.Extendable {
+		ext = 1
+	}
+	idExp := int(s.Identifier)<<(iterationExpBits+1) | ext<<iterationExpBits | s.IterationExponent
+	params := s.GroupIndex<<16 |
+		(s.GroupThreshold-1)<<12 |
+		(s.GroupCount-1)<<8 |
+		s.MemberIndex<<4 |
+		(s.MemberThreshold - 1)
+
+	indices := []int{
+		idExp >> radixBits,
+		idExp & (1<<radixBits - 1),
+		params >> radixBits,
+		params & (1<<radixBits - 1),
+	}
+
+	valueWords := (len(s.Value)*8 + radixBits - 1) / radixBits
+	value := new(big.Int).SetBytes(s.Value)
+	valueIndices := make([]int, valueWords)
+	mask := big.NewInt(1<<radixBits - 1)
+	for i := valueWords - 1; i >= 0; i-- {
+		valueIndices[i] = int(new(big.Int).And(value, mask).Int64())
+		value.Rsh(value, radixBits)
+	}
+	indices = append(indices, valueIndices...)
+	indices = append(indices, createChecksum(indices, s.Extendable)...)
+
+	words := make([]string, len(indices))
+	for i, index := range indices {
+		words[i] = wordList[index]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// checkPassphrase ensures that the passphrase contains only printable ASCII characters.
+func checkPassphrase(passphrase []byte) error {
+	for _, b := range passphrase {
+		if b < 32 || b > 126 {
+			return errors.New("passphrase must contain only printable ASCII characters")
+		}
+	}
+	return nil
+}
+
+// rs1024Polymod calculates the RS1024 checksum polynomial.
+func rs1024Polymod(values []int) int {
+	gen := [...]int{
+		0xe0e040, 0x1c1c080, 0x3838100, 0x7070200, 0xe0e0009,
+		0x1c0c2412, 0x38086c24, 0x3090fc48, 0x21b1f890, 0x3f3f120,
+	}
+	chk := 1
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xfffff)<<10 ^ v
+		for i := 0; i < 10; i++ {
+			if (b>>i)&1 != 0 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func checksumCustomization(extendable bool) []int {
+	cs := customization
+	if extendable {
+		cs = extendableCustomization
+	}
+	res := make([]int, len(cs))
+	for i := range cs {
+		res[i] = int(cs[i])
+	}
+	return res
+}
+
+func createChecksum(data []int, extendable bool) []int {
+	values := checksumCustomization(extendable)
+	values = append(values, data...)
+	values = append(values, make([]int, checksumWords)...)
+	polymod := rs1024Polymod(values) ^ 1
+	res := make([]int, checksumWords)
+	for i := range res {
+		res[i] = (polymod >> (radixBits * (checksumWords - 1 - i))) & (1<<radixBits - 1)
+	}
+	return res
+}
+
+func verifyChecksum(data []int, extendable bool) bool {
+	values := checksumCustomization(extendable)
+	values = append(values, data...)
+	return rs1024Polymod(values) == 1
+}
+
+// roundFunction is the Feistel round function.
+func roundFunction(i int, passphrase []byte, iterationExponent int, salt []byte, r []byte) []byte {
+	password := append([]byte{byte(i)}, passphrase...)
+	fullSalt := make([]byte, 0, len(salt)+len(r))
+	fullSalt = append(fullSalt, salt...)
+	fullSalt = append(fullSalt, r...)
+	iterations := (baseIterationCount << iterationExponent) / roundCount
+	return pbkdf2.Key(password, fullSalt, iterations, len(r), sha256.New)
+}
+
+func cipherSalt(identifier uint16, extendable bool) []byte {
+	if extendable {
+		return []byte{}
+	}
+	salt := []byte(customization)
+	return binary.BigEndian.AppendUint16(salt, identifier)
+}
+
+func feistel(input []byte, passphrase []byte, iterationExponent int, identifier uint16, extendable bool, rounds []int) []byte {
+	half := len(input) / 2
+	l := append([]byte{}, input[:half]...)
+	r := append([]byte{}, input[half:]...)
+	salt := cipherSalt(identifier, extendable)
+	for _, i := range rounds {
+		f := roundFunction(i, passphrase, iterationExponent, salt, r)
+		for j := range l {
+			l[j] ^= f[j]
+		}
+		l, r = r, l
+	}
+	return append(r, l...)
+}
+
+// encrypt encrypts the master secret with the passphrase.
+func encrypt(masterSecret []byte, passphrase []byte, iterationExponent int, identifier uint16, extendable bool) []byte {
+	return feistel(masterSecret, passphrase, iterationExponent, identifier, extendable, []int{0, 1, 2, 3})
+}
+
+// decrypt decrypts the encrypted master secret with the passphrase.
+func decrypt(ems []byte, passphrase []byte, iterationExponent int, identifier uint16, extendable bool) []byte {
+	return feistel(ems, passphrase, iterationExponent, identifier, extendable, []int{3, 2, 1, 0})
+}
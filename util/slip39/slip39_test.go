@@ -0,0 +1,142 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slip39
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		mnemonics []string
+		secret    string
+		err       string
+	}{
+		{
+			name:      "Single128",
+			mnemonics: []string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard"},
+			secret:    "bb54aac4b89dc868ba37d9cc21b2cece",
+		},
+		{
+			name:      "BadChecksum",
+			mnemonics: []string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision kidney"},
+			err:       "share 1: invalid mnemonic checksum",
+		},
+		{
+			name: "TwoOfThree128",
+			mnemonics: []string{
+				"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+				"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip twice unkind craft early superior advocate guest smoking",
+			},
+			secret: "b43ceb7e57a0ea8766221624d01b0864",
+		},
+		{
+			name:      "Single256",
+			mnemonics: []string{"theory painting academic academic armed sweater year military elder discuss acne wildlife boring employer fused large satoshi bundle carbon diagnose anatomy hamster leaves tracks paces beyond phantom capital marvel lips brave detect luck"},
+			secret:    "989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret, err := CombineMnemonics(test.mnemonics, []byte("TREZOR"))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.secret, hex.EncodeToString(secret))
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	secret, err := hex.DecodeString("0c94e2e5e6d4e3dfaa8b5d0ae0b1b4b3f1a2c3d4e5f60718293a4b5c6d7e8f90")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		groupThreshold int
+		groups         []*Group
+		extendable     bool
+		combine        [][2]int
+		err            string
+	}{
+		{
+			name:           "Single",
+			groupThreshold: 1,
+			groups:         []*Group{{MemberThreshold: 1, MemberCount: 1}},
+			combine:        [][2]int{{0, 0}},
+		},
+		{
+			name:           "TwoOfThree",
+			groupThreshold: 1,
+			groups:         []*Group{{MemberThreshold: 2, MemberCount: 3}},
+			extendable:     true,
+			combine:        [][2]int{{0, 2}, {0, 0}},
+		},
+		{
+			name:           "Insufficient",
+			groupThreshold: 1,
+			groups:         []*Group{{MemberThreshold: 3, MemberCount: 5}},
+			combine:        [][2]int{{0, 1}, {0, 4}},
+			err:            "insufficient shares; 1 complete group(s) required but 0 available",
+		},
+		{
+			name:           "Groups",
+			groupThreshold: 2,
+			groups: []*Group{
+				{MemberThreshold: 1, MemberCount: 1},
+				{MemberThreshold: 2, MemberCount: 3},
+				{MemberThreshold: 3, MemberCount: 5},
+			},
+			extendable: true,
+			combine:    [][2]int{{2, 4}, {1, 1}, {2, 0}, {0, 0}, {2, 3}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shares, err := GenerateMnemonics(test.groupThreshold, test.groups, secret, []byte("secret"), test.extendable, 0)
+			require.NoError(t, err)
+			require.Len(t, shares, len(test.groups))
+			mnemonics := make([]string, 0, len(test.combine))
+			for _, pos := range test.combine {
+				mnemonics = append(mnemonics, shares[pos[0]][pos[1]])
+			}
+			res, err := CombineMnemonics(mnemonics, []byte("secret"))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, secret, res)
+			}
+		})
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	secret := make([]byte, 16)
+	_, err := GenerateMnemonics(1, []*Group{{MemberThreshold: 1, MemberCount: 3}}, secret, nil, true, 0)
+	require.EqualError(t, err, "group 1 cannot have a threshold of 1 with multiple members; use 1 of 1 instead")
+	_, err = GenerateMnemonics(2, []*Group{{MemberThreshold: 2, MemberCount: 3}}, secret, nil, true, 0)
+	require.EqualError(t, err, "group threshold must be between 1 and the number of groups (1)")
+	_, err = GenerateMnemonics(1, []*Group{{MemberThreshold: 2, MemberCount: 3}}, secret[:15], nil, true, 0)
+	require.EqualError(t, err, "master secret must be at least 128 bits")
+}
@@ -29,23 +29,66 @@ type Contract struct {
 	Name   string
 	Abi    abi.ABI
 	Binary []byte
+	// DeployedBinary is the runtime bytecode of the contract.
+	DeployedBinary []byte
+	// LinkReferences are the locations of library addresses in Binary.
+	LinkReferences LinkReferences
+	// DeployedLinkReferences are the locations of library addresses in DeployedBinary.
+	DeployedLinkReferences LinkReferences
+	StorageLayout          *StorageLayout
+	// MethodIdentifiers maps function signatures to their hex selectors.
+	MethodIdentifiers map[string]string
+	// AbiJSON is the JSON of the ABI, if the contract was parsed from JSON.
+	AbiJSON []byte
+}
+
+// LinkReference is the location of a library address in contract bytecode.
+type LinkReference struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// LinkReferences are the locations of library addresses in contract bytecode,
+// keyed by source file and library name.  If the bytecode only contains a
+// hashed placeholder the source file is empty and the library name is the
+// placeholder, for example "$a1b2...$".
+type LinkReferences map[string]map[string][]LinkReference
+
+// StorageLayout is the storage layout of a contract, as output by solc.
+type StorageLayout struct {
+	Storage []*StorageItem          `json:"storage"`
+	Types   map[string]*StorageType `json:"types"`
+}
+
+// StorageItem is a state variable, or a member of a struct.
+type StorageItem struct {
+	AstID    int    `json:"astId"`
+	Contract string `json:"contract"`
+	Label    string `json:"label"`
+	Offset   int    `json:"offset"`
+	Slot     string `json:"slot"`
+	Type     string `json:"type"`
+}
+
+// StorageType is a type referenced by a storage layout.
+type StorageType struct {
+	Encoding      string         `json:"encoding"`
+	Label         string         `json:"label"`
+	NumberOfBytes string         `json:"numberOfBytes"`
+	Base          string         `json:"base,omitempty"`
+	Key           string         `json:"key,omitempty"`
+	Value         string         `json:"value,omitempty"`
+	Members       []*StorageItem `json:"members,omitempty"`
 }
 
 // ParseCombinedJSON parses a combined JSON output of solc for a specific contract.
 func ParseCombinedJSON(input string, name string) (*Contract, error) {
-	var err error
-	var data []byte
-	if strings.HasPrefix(input, "{") {
-		data = []byte(input)
-	} else {
-		// Input is a filename.
-		data, err = os.ReadFile(input)
-		if err != nil {
-			return nil, err
-		}
+	data, err := readJSONInput(input)
+	if err != nil {
+		return nil, err
 	}
 
-	var contractsJSON map[string]any
+	var contractsJSON map[string]json.RawMessage
 	err = json.Unmarshal(data, &contractsJSON)
 	if err != nil {
 		return nil, err
@@ -54,41 +97,175 @@ func ParseCombinedJSON(input string, name string) (*Contract, error) {
 	if !exists {
 		return nil, errors.New("JSON does not contain contracts element")
 	}
-	contractsMap := contracts.(map[string]any)
+
+	return parseCombinedJSONContracts(contracts, name)
+}
+
+type combinedJSONContract struct {
+	Abi           json.RawMessage   `json:"abi"`
+	Bin           string            `json:"bin"`
+	BinRuntime    string            `json:"bin-runtime"`
+	Hashes        map[string]string `json:"hashes"`
+	StorageLayout json.RawMessage   `json:"storage-layout"`
+}
+
+func parseCombinedJSONContracts(data json.RawMessage, name string) (*Contract, error) {
+	var contractsMap map[string]*combinedJSONContract
+	if err := json.Unmarshal(data, &contractsMap); err != nil {
+		return nil, errors.Wrap(err, "failed to decode contracts")
+	}
+
 	// See if this is our name.
+	var contractJSON *combinedJSONContract
 	for contractKey, contractValue := range contractsMap {
-		if strings.HasSuffix(contractKey, fmt.Sprintf(":%s", name)) {
-			// Found our contract.
-			contract := &Contract{Name: name}
-
-			contractJSON := contractValue.(map[string]any)
-
-			// Obtain ABI.
-			abiJSON, exists := contractJSON["abi"]
-			if exists {
-				bytes, err := json.Marshal(abiJSON)
-				if err != nil {
-					return nil, err
-				}
-				var abi abi.ABI
-				if err := json.Unmarshal(bytes, &abi); err != nil {
-					return nil, errors.Wrap(err, "failed to decode json")
-				}
-				contract.Abi = abi
+		if contractKey == name || strings.HasSuffix(contractKey, fmt.Sprintf(":%s", name)) {
+			if contractJSON != nil {
+				return nil, fmt.Errorf("multiple contracts \"%s\" in JSON; use --name to provide the full name of the contract", name)
 			}
+			contractJSON = contractValue
+		}
+	}
+	if contractJSON == nil {
+		return nil, fmt.Errorf("no contract \"%s\" in JSON; use --name to provide the name of the contract", name)
+	}
 
-			// Obtain binary.
-			binStr, exists := contractJSON["bin"]
-			if exists {
-				bin, err := hex.DecodeString(binStr.(string))
-				if err != nil {
-					return nil, err
-				}
-				contract.Binary = bin
-			}
+	// Found our contract.
+	contract := &Contract{
+		Name:              name,
+		MethodIdentifiers: contractJSON.Hashes,
+	}
+	if err := contract.setAbi(contractJSON.Abi); err != nil {
+		return nil, err
+	}
+	if err := contract.setBytecode(contractJSON.Bin, nil, false); err != nil {
+		return nil, err
+	}
+	if err := contract.setBytecode(contractJSON.BinRuntime, nil, true); err != nil {
+		return nil, err
+	}
+	// Hashed placeholders can be named if the library is also in the JSON.
+	names := make([]string, 0, len(contractsMap))
+	for contractKey := range contractsMap {
+		names = append(names, contractKey)
+	}
+	contract.NameLibraries(names)
+	if len(contractJSON.StorageLayout) > 0 {
+		contract.StorageLayout = &StorageLayout{}
+		if err := unmarshalEmbeddedJSON(contractJSON.StorageLayout, contract.StorageLayout); err != nil {
+			return nil, errors.Wrap(err, "failed to decode storage layout")
+		}
+	}
+	contract.fillMethodIdentifiers()
+
+	return contract, nil
+}
 
-			return contract, nil
+// rename renames a hashed placeholder reference to the given fully-qualified
+// library name, if the placeholder is for that library.
+func (l LinkReferences) rename(name string) {
+	pos := strings.LastIndex(name, ":")
+	if pos == -1 {
+		return
+	}
+	placeholder := LibraryPlaceholder(name)
+	refs, exists := l[""][placeholder]
+	if !exists {
+		return
+	}
+	delete(l[""], placeholder)
+	if len(l[""]) == 0 {
+		delete(l, "")
+	}
+	file, library := name[:pos], name[pos+1:]
+	if _, exists := l[file]; !exists {
+		l[file] = make(map[string][]LinkReference)
+	}
+	l[file][library] = refs
+}
+
+// readJSONInput returns the input if it is JSON, otherwise the contents of the file it names.
+func readJSONInput(input string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(input), "{") {
+		return []byte(input), nil
+	}
+
+	// Input is a filename.
+	return os.ReadFile(input)
+}
+
+// unmarshalEmbeddedJSON unmarshals JSON that might have been encoded as a string.
+func unmarshalEmbeddedJSON(data json.RawMessage, v any) error {
+	data, err := embeddedJSON(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// embeddedJSON returns JSON that might be embedded in a string.
+func embeddedJSON(data json.RawMessage) (json.RawMessage, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return nil, err
+		}
+		data = json.RawMessage(str)
+	}
+
+	return data, nil
+}
+
+// DecodeBytecode decodes hex bytecode.  Any library placeholders are replaced
+// with zeros, and their locations returned.
+func DecodeBytecode(input string) ([]byte, LinkReferences, error) {
+	input = strings.TrimPrefix(input, "0x")
+
+	refs := make(LinkReferences)
+	var bytecode strings.Builder
+	for i := 0; i < len(input); {
+		if input[i] != '_' {
+			bytecode.WriteByte(input[i])
+			i++
+			continue
+		}
+		if i%2 != 0 || i+40 > len(input) {
+			return nil, nil, errors.New("invalid library placeholder in bytecode")
+		}
+		// Placeholders are either __$<hash>$__ or __<file>:<name>___...
+		placeholder := strings.TrimRight(input[i+2:i+40], "_")
+		file, name := "", placeholder
+		if !strings.HasPrefix(placeholder, "$") {
+			if pos := strings.LastIndex(placeholder, ":"); pos != -1 {
+				file, name = placeholder[:pos], placeholder[pos+1:]
+			}
+		}
+		if _, exists := refs[file]; !exists {
+			refs[file] = make(map[string][]LinkReference)
 		}
+		refs[file][name] = append(refs[file][name], LinkReference{Start: i / 2, Length: 20})
+		bytecode.WriteString(strings.Repeat("0", 40))
+		i += 40
+	}
+
+	res, err := hex.DecodeString(bytecode.String())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to decode bytecode")
+	}
+	if len(refs) == 0 {
+		refs = nil
+	}
+
+	return res, refs, nil
+}
+
+// fillMethodIdentifiers creates method identifiers from the ABI if they are not present.
+func (c *Contract) fillMethodIdentifiers() {
+	if len(c.MethodIdentifiers) > 0 || len(c.Abi.Methods) == 0 {
+		return
+	}
+	c.MethodIdentifiers = make(map[string]string, len(c.Abi.Methods))
+	for _, method := range c.Abi.Methods {
+		c.MethodIdentifiers[method.Sig] = hex.EncodeToString(method.ID)
 	}
-	return nil, fmt.Errorf("no contract \"%s\" in JSON; use --name to provide the name of the contract", name)
 }
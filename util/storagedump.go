@@ -0,0 +1,239 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// maxLabelledArrayLength is the longest fixed-size array for which elements are labelled.
+const maxLabelledArrayLength = 1024
+
+// StorageEntry is a non-zero slot in contract storage.
+type StorageEntry struct {
+	// Key is the hash of the slot, by which nodes order storage.
+	Key common.Hash
+	// Slot is the slot itself, if the node knows it.
+	Slot *common.Hash
+	// Value is the value held in the slot.
+	Value common.Hash
+}
+
+// storageRangeResult is the result of debug_storageRangeAt.
+type storageRangeResult struct {
+	Storage map[common.Hash]struct {
+		Key   *common.Hash `json:"key"`
+		Value common.Hash  `json:"value"`
+	} `json:"storage"`
+	NextKey *common.Hash `json:"nextKey"`
+}
+
+// DumpStorage obtains the storage of a contract in pages of up to pageSize
+// entries with debug_storageRangeAt, passing them to the handler in key order.
+// The storage is that before the transaction at txIndex in the given block.
+func DumpStorage(ctx context.Context, client *rpc.Client, blockHash common.Hash, txIndex int, contract common.Address, pageSize int, handler func([]*StorageEntry) error) error {
+	if pageSize < 1 {
+		return errors.New("page size must be at least 1")
+	}
+
+	start := common.Hash{}
+	for {
+		var res storageRangeResult
+		if err := client.CallContext(ctx, &res, "debug_storageRangeAt", blockHash, txIndex, contract, hexutil.Bytes(start.Bytes()), pageSize); err != nil {
+			return errors.Wrap(err, "failed to obtain storage range")
+		}
+
+		entries := make([]*StorageEntry, 0, len(res.Storage))
+		for key, value := range res.Storage {
+			entries = append(entries, &StorageEntry{
+				Key:   key,
+				Slot:  value.Key,
+				Value: value.Value,
+			})
+		}
+		sort.Slice(entries, func(i int, j int) bool {
+			return entries[i].Key.Big().Cmp(entries[j].Key.Big()) < 0
+		})
+		if err := handler(entries); err != nil {
+			return err
+		}
+
+		if res.NextKey == nil {
+			return nil
+		}
+		start = *res.NextKey
+	}
+}
+
+// StorageChange is a change to a slot in contract storage.
+type StorageChange struct {
+	Key  common.Hash
+	Slot *common.Hash
+	From common.Hash
+	To   common.Hash
+}
+
+// DiffStorage returns the changes between two sets of storage entries, each in key order.
+func DiffStorage(before []*StorageEntry, after []*StorageEntry) []*StorageChange {
+	changes := make([]*StorageChange, 0)
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		var cmp int
+		switch {
+		case i == len(before):
+			cmp = 1
+		case j == len(after):
+			cmp = -1
+		default:
+			cmp = before[i].Key.Big().Cmp(after[j].Key.Big())
+		}
+
+		switch {
+		case cmp < 0:
+			// Slot has been cleared.
+			changes = append(changes, &StorageChange{Key: before[i].Key, Slot: before[i].Slot, From: before[i].Value})
+			i++
+		case cmp > 0:
+			// Slot has been set.
+			changes = append(changes, &StorageChange{Key: after[j].Key, Slot: after[j].Slot, To: after[j].Value})
+			j++
+		default:
+			if before[i].Value != after[j].Value {
+				slot := before[i].Slot
+				if slot == nil {
+					slot = after[j].Slot
+				}
+				changes = append(changes, &StorageChange{Key: before[i].Key, Slot: slot, From: before[i].Value, To: after[j].Value})
+			}
+			i++
+			j++
+		}
+	}
+
+	return changes
+}
+
+// StorageLabeller provides names for storage slots.
+type StorageLabeller struct {
+	// labels are keyed by the hash of the slot, as it is always available from the node.
+	labels map[common.Hash]string
+	slots  map[common.Hash]common.Hash
+}
+
+// NewStorageLabeller creates a labeller for well-known proxy slots and, if a
+// layout is supplied, the variables that it places at fixed slots.
+func NewStorageLabeller(layout *StorageLayout) (*StorageLabeller, error) {
+	labeller := &StorageLabeller{
+		labels: make(map[common.Hash]string),
+		slots:  make(map[common.Hash]common.Hash),
+	}
+	labeller.add(EIP1967ImplementationSlot, "EIP-1967 implementation")
+	labeller.add(EIP1967AdminSlot, "EIP-1967 admin")
+	labeller.add(EIP1967BeaconSlot, "EIP-1967 beacon")
+	labeller.add(EIP1822ProxiableSlot, "EIP-1822 implementation")
+
+	if layout != nil {
+		for _, item := range layout.Storage {
+			loc, err := layout.itemLocation(item.Label, big.NewInt(0), item)
+			if err != nil {
+				return nil, err
+			}
+			if err := labeller.addLocation(layout, loc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return labeller, nil
+}
+
+// addLocation adds labels for the fixed slots used by a variable.
+func (l *StorageLabeller) addLocation(layout *StorageLayout, loc *StorageLocation) error {
+	switch {
+	case loc.Type.Encoding == "mapping":
+		// Values are at hashed slots that depend on their keys.
+	case loc.Type.Encoding == "dynamic_array":
+		l.add(loc.Slot, loc.Name+".length")
+	case len(loc.Type.Members) > 0:
+		for _, member := range loc.Type.Members {
+			memberLoc, err := layout.memberLocation(loc, member.Label)
+			if err != nil {
+				return err
+			}
+			if err := l.addLocation(layout, memberLoc); err != nil {
+				return err
+			}
+		}
+	case loc.Type.Base != "":
+		length, err := staticArrayLength(loc.Type)
+		if err != nil {
+			return err
+		}
+		if length.Int64() > maxLabelledArrayLength {
+			l.add(loc.Slot, loc.Name)
+			return nil
+		}
+		for i := int64(0); i < length.Int64(); i++ {
+			elementLoc, err := layout.elementLocation(fmt.Sprintf("%s[%d]", loc.Name, i), loc.Slot.Big(), loc.Type, big.NewInt(i))
+			if err != nil {
+				return err
+			}
+			if err := l.addLocation(layout, elementLoc); err != nil {
+				return err
+			}
+		}
+	default:
+		l.add(loc.Slot, loc.Name)
+	}
+
+	return nil
+}
+
+// add adds a label for a slot, combining it with any existing labels for packed variables.
+func (l *StorageLabeller) add(slot common.Hash, label string) {
+	key := crypto.Keccak256Hash(slot.Bytes())
+	if existing, exists := l.labels[key]; exists {
+		if strings.Contains(", "+existing+", ", ", "+label+", ") {
+			return
+		}
+		label = existing + ", " + label
+	}
+	l.labels[key] = label
+	l.slots[key] = slot
+}
+
+// Label returns the label for the slot with the given key, or an empty string if it is not known.
+func (l *StorageLabeller) Label(key common.Hash) string {
+	return l.labels[key]
+}
+
+// Slot returns the slot with the given key, if it is known.
+func (l *StorageLabeller) Slot(key common.Hash) *common.Hash {
+	slot, exists := l.slots[key]
+	if !exists {
+		return nil
+	}
+
+	return &slot
+}
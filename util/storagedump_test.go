@@ -0,0 +1,151 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// storageRangeService provides debug_storageRangeAt for a fixed set of slots.
+type storageRangeService struct {
+	slots map[common.Hash]common.Hash
+	calls int
+}
+
+type storageRangeEntry struct {
+	Key   *common.Hash `json:"key"`
+	Value common.Hash  `json:"value"`
+}
+
+type storageRangeResponse struct {
+	Storage map[common.Hash]storageRangeEntry `json:"storage"`
+	NextKey *common.Hash                      `json:"nextKey"`
+}
+
+func (s *storageRangeService) StorageRangeAt(_ common.Hash, _ int, _ common.Address, start hexutil.Bytes, max int) storageRangeResponse {
+	s.calls++
+	keys := make([]common.Hash, 0)
+	slots := make(map[common.Hash]common.Hash)
+	for slot := range s.slots {
+		key := crypto.Keccak256Hash(slot.Bytes())
+		if key.Big().Cmp(new(big.Int).SetBytes(start)) >= 0 {
+			keys = append(keys, key)
+			slots[key] = slot
+		}
+	}
+	sort.Slice(keys, func(i int, j int) bool { return keys[i].Big().Cmp(keys[j].Big()) < 0 })
+
+	res := storageRangeResponse{Storage: make(map[common.Hash]storageRangeEntry)}
+	for i, key := range keys {
+		if i == max {
+			next := key
+			res.NextKey = &next
+			break
+		}
+		slot := slots[key]
+		entry := storageRangeEntry{Value: s.slots[slot]}
+		// Only some preimages are known.
+		if slot.Big().Int64()%2 == 0 {
+			entry.Key = &slot
+		}
+		res.Storage[key] = entry
+	}
+
+	return res
+}
+
+func TestDumpStorage(t *testing.T) {
+	service := &storageRangeService{slots: make(map[common.Hash]common.Hash)}
+	for i := int64(0); i < 10; i++ {
+		service.slots[common.BigToHash(big.NewInt(i))] = common.BigToHash(big.NewInt(i + 100))
+	}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("debug", service))
+	defer server.Stop()
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	entries := make([]*StorageEntry, 0)
+	err := DumpStorage(context.Background(), client, common.Hash{}, 0, common.Address{}, 3, func(page []*StorageEntry) error {
+		require.LessOrEqual(t, len(page), 3)
+		entries = append(entries, page...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, service.calls)
+	require.Len(t, entries, 10)
+	for i, entry := range entries {
+		if i > 0 {
+			require.Equal(t, 1, entry.Key.Big().Cmp(entries[i-1].Key.Big()))
+		}
+		if entry.Slot != nil {
+			require.Equal(t, crypto.Keccak256Hash(entry.Slot.Bytes()), entry.Key)
+			require.Equal(t, service.slots[*entry.Slot], entry.Value)
+		}
+	}
+
+	require.EqualError(t, DumpStorage(context.Background(), client, common.Hash{}, 0, common.Address{}, 0, nil), "page size must be at least 1")
+}
+
+func TestDiffStorage(t *testing.T) {
+	entry := func(key int64, value int64) *StorageEntry {
+		return &StorageEntry{Key: common.BigToHash(big.NewInt(key)), Value: common.BigToHash(big.NewInt(value))}
+	}
+	before := []*StorageEntry{entry(1, 10), entry(2, 20), entry(4, 40)}
+	after := []*StorageEntry{entry(2, 21), entry(3, 30), entry(4, 40), entry(5, 50)}
+
+	changes := DiffStorage(before, after)
+	require.Equal(t, []*StorageChange{
+		{Key: common.BigToHash(big.NewInt(1)), From: common.BigToHash(big.NewInt(10))},
+		{Key: common.BigToHash(big.NewInt(2)), From: common.BigToHash(big.NewInt(20)), To: common.BigToHash(big.NewInt(21))},
+		{Key: common.BigToHash(big.NewInt(3)), To: common.BigToHash(big.NewInt(30))},
+		{Key: common.BigToHash(big.NewInt(5)), To: common.BigToHash(big.NewInt(50))},
+	}, changes)
+	require.Empty(t, DiffStorage(after, after))
+}
+
+func TestStorageLabeller(t *testing.T) {
+	layout, err := ParseStorageLayout(testStorageLayout)
+	require.NoError(t, err)
+	labeller, err := NewStorageLabeller(layout)
+	require.NoError(t, err)
+
+	key := func(slot common.Hash) common.Hash {
+		return crypto.Keccak256Hash(slot.Bytes())
+	}
+	require.Equal(t, "a, b, c", labeller.Label(key(slotN(0))))
+	require.Equal(t, "", labeller.Label(key(slotN(1))))
+	require.Equal(t, "name", labeller.Label(key(slotN(2))))
+	require.Equal(t, "config.fee", labeller.Label(key(slotN(4))))
+	require.Equal(t, "list.length", labeller.Label(key(slotN(5))))
+	require.Equal(t, "small[0], small[1], small[2]", labeller.Label(key(slotN(6))))
+	require.Equal(t, "EIP-1967 implementation", labeller.Label(key(EIP1967ImplementationSlot)))
+	slot := labeller.Slot(key(slotN(4)))
+	require.NotNil(t, slot)
+	require.Equal(t, slotN(4), *slot)
+	require.Nil(t, labeller.Slot(key(slotN(1))))
+
+	labeller, err = NewStorageLabeller(nil)
+	require.NoError(t, err)
+	require.Equal(t, "EIP-1967 admin", labeller.Label(key(EIP1967AdminSlot)))
+}
@@ -0,0 +1,522 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// maxStorageBytesLength is the longest string or bytes value that will be read from storage.
+const maxStorageBytesLength = 1024 * 1024
+
+// StorageReader is the backend required to read contract storage.
+type StorageReader interface {
+	StorageAt(ctx context.Context, contract common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+// StorageLocation is the location of a variable in contract storage.
+type StorageLocation struct {
+	// Name is the path to the variable, for example "balances[0x…]".
+	Name string
+	Slot common.Hash
+	// Offset is the offset of the variable within the slot, in bytes from the right.
+	Offset int
+	Type   *StorageType
+}
+
+// StorageValue is a value decoded from contract storage.
+type StorageValue struct {
+	Name  string
+	Type  abi.Type
+	Value interface{}
+	// Raw is the undecoded value.
+	Raw []byte
+}
+
+// ParseStorageLayout parses a storage layout as output by solc, or a path to one.
+func ParseStorageLayout(input string) (*StorageLayout, error) {
+	data, err := readJSONInput(input)
+	if err != nil {
+		return nil, err
+	}
+	layout := &StorageLayout{}
+	if err := unmarshalEmbeddedJSON(data, layout); err != nil {
+		return nil, errors.Wrap(err, "invalid storage layout")
+	}
+
+	return layout, nil
+}
+
+// Locate returns the location of a variable, which can select struct members
+// with "." and array elements or mapping values with "[]", for example
+// "config.owner" or "balances[0x…]".  Addresses in mapping keys are resolved
+// with the supplied resolver.
+func (l *StorageLayout) Locate(variable string, resolve func(string) (common.Address, error)) (*StorageLocation, error) {
+	name, selectors, err := parseStorageVariable(variable)
+	if err != nil {
+		return nil, err
+	}
+
+	var loc *StorageLocation
+	for _, item := range l.Storage {
+		if item.Label == name {
+			loc, err = l.itemLocation(name, big.NewInt(0), item)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if loc == nil {
+		return nil, fmt.Errorf("unknown variable %s", name)
+	}
+
+	for _, selector := range selectors {
+		if strings.HasPrefix(selector, ".") {
+			loc, err = l.memberLocation(loc, selector[1:])
+		} else {
+			loc, err = l.indexLocation(loc, selector[1:len(selector)-1], resolve)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return loc, nil
+}
+
+// parseStorageVariable splits a variable in to its name and selectors.
+func parseStorageVariable(variable string) (string, []string, error) {
+	variable = strings.TrimSpace(variable)
+	end := strings.IndexAny(variable, ".[")
+	if end == -1 {
+		end = len(variable)
+	}
+	name := variable[:end]
+	if name == "" {
+		return "", nil, errors.New("missing variable name")
+	}
+
+	selectors := make([]string, 0)
+	rest := variable[end:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return "", nil, errors.New("missing member name")
+			}
+			selectors = append(selectors, rest[:end+1])
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return "", nil, errors.New("missing ]")
+			}
+			selectors = append(selectors, rest[:end+1])
+			rest = rest[end+1:]
+		default:
+			return "", nil, fmt.Errorf("unexpected %q", rest[0])
+		}
+	}
+
+	return name, selectors, nil
+}
+
+// itemLocation returns the location of an item relative to a base slot.
+func (l *StorageLayout) itemLocation(name string, base *big.Int, item *StorageItem) (*StorageLocation, error) {
+	slot, success := new(big.Int).SetString(item.Slot, 10)
+	if !success {
+		return nil, fmt.Errorf("invalid slot %q for %s", item.Slot, name)
+	}
+	storageType, err := l.storageType(item.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StorageLocation{
+		Name:   name,
+		Slot:   slotHash(new(big.Int).Add(base, slot)),
+		Offset: item.Offset,
+		Type:   storageType,
+	}, nil
+}
+
+// storageType returns the type with the given identifier.
+func (l *StorageLayout) storageType(id string) (*StorageType, error) {
+	storageType, exists := l.Types[id]
+	if !exists {
+		return nil, fmt.Errorf("unknown type %s", id)
+	}
+
+	return storageType, nil
+}
+
+// memberLocation returns the location of a member of a struct.
+func (l *StorageLayout) memberLocation(loc *StorageLocation, member string) (*StorageLocation, error) {
+	if len(loc.Type.Members) == 0 {
+		return nil, fmt.Errorf("%s is not a struct", loc.Name)
+	}
+	for _, item := range loc.Type.Members {
+		if item.Label == member {
+			return l.itemLocation(fmt.Sprintf("%s.%s", loc.Name, member), loc.Slot.Big(), item)
+		}
+	}
+
+	return nil, fmt.Errorf("%s has no member %s", loc.Name, member)
+}
+
+// arrayLengthRe matches the length of a static array in its type label.
+var arrayLengthRe = regexp.MustCompile(`\[(\d+)\]$`)
+
+// indexLocation returns the location of an array element or mapping value.
+func (l *StorageLayout) indexLocation(loc *StorageLocation, key string, resolve func(string) (common.Address, error)) (*StorageLocation, error) {
+	name := fmt.Sprintf("%s[%s]", loc.Name, key)
+	switch {
+	case loc.Type.Encoding == "mapping":
+		keyType, err := l.storageType(loc.Type.Key)
+		if err != nil {
+			return nil, err
+		}
+		encodedKey, err := storageMappingKey(keyType, strings.Trim(key, `"`), resolve)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key for %s", loc.Name)
+		}
+		valueType, err := l.storageType(loc.Type.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &StorageLocation{
+			Name: name,
+			Slot: crypto.Keccak256Hash(encodedKey, loc.Slot.Bytes()),
+			Type: valueType,
+		}, nil
+	case loc.Type.Encoding == "dynamic_array":
+		index, success := new(big.Int).SetString(key, 0)
+		if !success || index.Sign() < 0 {
+			return nil, fmt.Errorf("invalid index %s for %s", key, loc.Name)
+		}
+		return l.elementLocation(name, crypto.Keccak256Hash(loc.Slot.Bytes()).Big(), loc.Type, index)
+	case loc.Type.Base != "":
+		index, success := new(big.Int).SetString(key, 0)
+		if !success || index.Sign() < 0 {
+			return nil, fmt.Errorf("invalid index %s for %s", key, loc.Name)
+		}
+		length, err := staticArrayLength(loc.Type)
+		if err != nil {
+			return nil, err
+		}
+		if index.Cmp(length) >= 0 {
+			return nil, fmt.Errorf("index %s out of range for %s", key, loc.Name)
+		}
+		return l.elementLocation(name, loc.Slot.Big(), loc.Type, index)
+	default:
+		return nil, fmt.Errorf("%s is not an array or mapping", loc.Name)
+	}
+}
+
+// staticArrayLength returns the length of a static array.
+func staticArrayLength(arrayType *StorageType) (*big.Int, error) {
+	match := arrayLengthRe.FindStringSubmatch(arrayType.Label)
+	if match == nil {
+		return nil, fmt.Errorf("unknown length for %s", arrayType.Label)
+	}
+	length, _ := new(big.Int).SetString(match[1], 10)
+
+	return length, nil
+}
+
+// elementLocation returns the location of an array element, given the slot at which the array data starts.
+func (l *StorageLayout) elementLocation(name string, start *big.Int, arrayType *StorageType, index *big.Int) (*StorageLocation, error) {
+	elementType, err := l.storageType(arrayType.Base)
+	if err != nil {
+		return nil, err
+	}
+	size, err := strconv.ParseInt(elementType.NumberOfBytes, 10, 64)
+	if err != nil || size < 1 {
+		return nil, fmt.Errorf("invalid size for %s", elementType.Label)
+	}
+
+	slot := new(big.Int)
+	offset := int64(0)
+	if size <= 32 {
+		// Elements are packed, and do not span slots.
+		perSlot := big.NewInt(32 / size)
+		remainder := new(big.Int)
+		slot.DivMod(index, perSlot, remainder)
+		offset = remainder.Int64() * size
+	} else {
+		slot.Mul(index, big.NewInt((size+31)/32))
+	}
+
+	return &StorageLocation{
+		Name:   name,
+		Slot:   slotHash(slot.Add(slot, start)),
+		Offset: int(offset),
+		Type:   elementType,
+	}, nil
+}
+
+// storageMappingKey encodes a key for a mapping.
+func storageMappingKey(keyType *StorageType, key string, resolve func(string) (common.Address, error)) ([]byte, error) {
+	label := keyType.Label
+	switch {
+	case label == "string":
+		return []byte(key), nil
+	case label == "bytes":
+		return hex.DecodeString(strings.TrimPrefix(key, "0x"))
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract "):
+		address, err := resolve(key)
+		if err != nil {
+			return nil, err
+		}
+		return common.LeftPadBytes(address.Bytes(), 32), nil
+	case label == "bool":
+		b, err := strconv.ParseBool(key)
+		if err != nil {
+			return nil, err
+		}
+		if b {
+			return common.LeftPadBytes([]byte{1}, 32), nil
+		}
+		return make([]byte, 32), nil
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "int") || strings.HasPrefix(label, "enum "):
+		number, success := new(big.Int).SetString(key, 0)
+		if !success {
+			return nil, errors.New("not a number")
+		}
+		if number.Sign() < 0 && !strings.HasPrefix(label, "int") {
+			return nil, errors.New("negative value for unsigned type")
+		}
+		return math.U256Bytes(number), nil
+	case strings.HasPrefix(label, "bytes"):
+		data, err := hex.DecodeString(strings.TrimPrefix(key, "0x"))
+		if err != nil {
+			return nil, err
+		}
+		if fmt.Sprintf("bytes%d", len(data)) != label {
+			return nil, fmt.Errorf("expected %s", label)
+		}
+		return common.RightPadBytes(data, 32), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", label)
+	}
+}
+
+// slotHash returns a slot number as a hash, wrapping if it overflows.
+func slotHash(slot *big.Int) common.Hash {
+	return common.BigToHash(math.U256(new(big.Int).Set(slot)))
+}
+
+// Read reads and decodes the value at a location.  Structs and static arrays
+// are decoded as their individual members and elements, and dynamic arrays as
+// their length.  Mappings cannot be read without a key.  The block number can
+// be nil, in which case the value is read from the latest block.
+func (l *StorageLayout) Read(ctx context.Context, backend StorageReader, contract common.Address, blockNumber *big.Int, loc *StorageLocation) ([]*StorageValue, error) {
+	reader := &storageSlotReader{
+		ctx:         ctx,
+		backend:     backend,
+		contract:    contract,
+		blockNumber: blockNumber,
+		slots:       make(map[common.Hash][]byte),
+	}
+
+	return l.read(reader, loc)
+}
+
+func (l *StorageLayout) read(reader *storageSlotReader, loc *StorageLocation) ([]*StorageValue, error) {
+	switch {
+	case loc.Type.Encoding == "mapping":
+		return nil, fmt.Errorf("%s is a mapping; a key is required", loc.Name)
+	case loc.Type.Encoding == "dynamic_array":
+		word, err := reader.slot(loc.Slot)
+		if err != nil {
+			return nil, err
+		}
+		return []*StorageValue{{
+			Name:  loc.Name + ".length",
+			Type:  storageABIType("uint256"),
+			Value: new(big.Int).SetBytes(word),
+			Raw:   word,
+		}}, nil
+	case len(loc.Type.Members) > 0:
+		values := make([]*StorageValue, 0)
+		for _, member := range loc.Type.Members {
+			memberLoc, err := l.memberLocation(loc, member.Label)
+			if err != nil {
+				return nil, err
+			}
+			memberValues, err := l.read(reader, memberLoc)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, memberValues...)
+		}
+		return values, nil
+	case loc.Type.Base != "":
+		length, err := staticArrayLength(loc.Type)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]*StorageValue, 0)
+		for i := int64(0); i < length.Int64(); i++ {
+			elementLoc, err := l.elementLocation(fmt.Sprintf("%s[%d]", loc.Name, i), loc.Slot.Big(), loc.Type, big.NewInt(i))
+			if err != nil {
+				return nil, err
+			}
+			elementValues, err := l.read(reader, elementLoc)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, elementValues...)
+		}
+		return values, nil
+	case loc.Type.Encoding == "bytes":
+		data, err := reader.bytes(loc.Slot)
+		if err != nil {
+			return nil, err
+		}
+		if loc.Type.Label == "string" {
+			return []*StorageValue{{Name: loc.Name, Type: storageABIType("string"), Value: string(data), Raw: data}}, nil
+		}
+		return []*StorageValue{{Name: loc.Name, Type: storageABIType("bytes"), Value: data, Raw: data}}, nil
+	default:
+		value, err := l.readInplace(reader, loc)
+		if err != nil {
+			return nil, err
+		}
+		return []*StorageValue{value}, nil
+	}
+}
+
+// readInplace reads a value that is stored within a single slot.
+func (l *StorageLayout) readInplace(reader *storageSlotReader, loc *StorageLocation) (*StorageValue, error) {
+	size, err := strconv.Atoi(loc.Type.NumberOfBytes)
+	if err != nil || size < 1 || size+loc.Offset > 32 {
+		return nil, fmt.Errorf("invalid size for %s", loc.Name)
+	}
+	word, err := reader.slot(loc.Slot)
+	if err != nil {
+		return nil, err
+	}
+	raw := word[32-loc.Offset-size : 32-loc.Offset]
+
+	value := &StorageValue{Name: loc.Name, Raw: raw}
+	label := loc.Type.Label
+	switch {
+	case label == "bool":
+		value.Type = storageABIType("bool")
+		value.Value = raw[len(raw)-1] != 0
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract "):
+		value.Type = storageABIType("address")
+		value.Value = common.BytesToAddress(raw)
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "enum "):
+		value.Type = storageABIType(fmt.Sprintf("uint%d", size*8))
+		value.Value = new(big.Int).SetBytes(raw)
+	case strings.HasPrefix(label, "int"):
+		value.Type = storageABIType(fmt.Sprintf("int%d", size*8))
+		number := new(big.Int).SetBytes(raw)
+		if raw[0]&0x80 != 0 {
+			number.Sub(number, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+		}
+		value.Value = number
+	default:
+		// Fixed bytes, and types such as user-defined value types that are shown raw.
+		value.Type = storageABIType(fmt.Sprintf("bytes%d", size))
+		value.Value = raw
+	}
+
+	return value, nil
+}
+
+// storageABIType returns the ABI type for a known-good type name.
+func storageABIType(name string) abi.Type {
+	res, err := abi.NewType(name, "", nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return res
+}
+
+// storageSlotReader reads slots from storage, caching the results.
+type storageSlotReader struct {
+	ctx         context.Context
+	backend     StorageReader
+	contract    common.Address
+	blockNumber *big.Int
+	slots       map[common.Hash][]byte
+}
+
+func (r *storageSlotReader) slot(slot common.Hash) ([]byte, error) {
+	if word, exists := r.slots[slot]; exists {
+		return word, nil
+	}
+	word, err := r.backend.StorageAt(r.ctx, r.contract, slot, r.blockNumber)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to obtain storage slot %s", slot.Hex())
+	}
+	word = common.LeftPadBytes(word, 32)
+	r.slots[slot] = word
+
+	return word, nil
+}
+
+// bytes reads a string or bytes value, which is held in the slot itself if
+// it is short, otherwise in consecutive slots starting at the hash of the slot.
+func (r *storageSlotReader) bytes(slot common.Hash) ([]byte, error) {
+	word, err := r.slot(slot)
+	if err != nil {
+		return nil, err
+	}
+	if word[31]&0x01 == 0 {
+		length := int(word[31] / 2)
+		if length > 31 {
+			return nil, errors.New("invalid short bytes length")
+		}
+		return word[:length], nil
+	}
+
+	length := new(big.Int).SetBytes(word)
+	length.Rsh(length, 1)
+	if !length.IsInt64() || length.Int64() > maxStorageBytesLength {
+		return nil, fmt.Errorf("bytes length %s too large", length.String())
+	}
+	data := make([]byte, 0, length.Int64()+31)
+	start := crypto.Keccak256Hash(slot.Bytes()).Big()
+	for i := int64(0); int64(len(data)) < length.Int64(); i++ {
+		word, err := r.slot(slotHash(new(big.Int).Add(start, big.NewInt(i))))
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, word...)
+	}
+
+	return data[:length.Int64()], nil
+}
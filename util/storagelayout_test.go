@@ -0,0 +1,274 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// testStorageLayout is the layout of:
+//
+//	contract Test {
+//	    struct Config { address owner; uint256 fee; }
+//	    uint128 a; int64 b; bool c;
+//	    mapping(address => uint256) balances;
+//	    string name;
+//	    Config config;
+//	    uint256[] list;
+//	    uint8[3] small;
+//	    mapping(string => Config) configs;
+//	}
+const testStorageLayout = `{
+  "storage": [
+    {"label": "a", "offset": 0, "slot": "0", "type": "t_uint128"},
+    {"label": "b", "offset": 16, "slot": "0", "type": "t_int64"},
+    {"label": "c", "offset": 24, "slot": "0", "type": "t_bool"},
+    {"label": "balances", "offset": 0, "slot": "1", "type": "t_mapping(t_address,t_uint256)"},
+    {"label": "name", "offset": 0, "slot": "2", "type": "t_string_storage"},
+    {"label": "config", "offset": 0, "slot": "3", "type": "t_struct(Config)_storage"},
+    {"label": "list", "offset": 0, "slot": "5", "type": "t_array(t_uint256)dyn_storage"},
+    {"label": "small", "offset": 0, "slot": "6", "type": "t_array(t_uint8)3_storage"},
+    {"label": "configs", "offset": 0, "slot": "7", "type": "t_mapping(t_string_memory_ptr,t_struct(Config)_storage)"}
+  ],
+  "types": {
+    "t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+    "t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+    "t_int64": {"encoding": "inplace", "label": "int64", "numberOfBytes": "8"},
+    "t_uint8": {"encoding": "inplace", "label": "uint8", "numberOfBytes": "1"},
+    "t_uint128": {"encoding": "inplace", "label": "uint128", "numberOfBytes": "16"},
+    "t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+    "t_string_storage": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+    "t_string_memory_ptr": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+    "t_mapping(t_address,t_uint256)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => uint256)", "numberOfBytes": "32", "value": "t_uint256"},
+    "t_mapping(t_string_memory_ptr,t_struct(Config)_storage)": {"encoding": "mapping", "key": "t_string_memory_ptr", "label": "mapping(string => struct Test.Config)", "numberOfBytes": "32", "value": "t_struct(Config)_storage"},
+    "t_array(t_uint256)dyn_storage": {"base": "t_uint256", "encoding": "dynamic_array", "label": "uint256[]", "numberOfBytes": "32"},
+    "t_array(t_uint8)3_storage": {"base": "t_uint8", "encoding": "inplace", "label": "uint8[3]", "numberOfBytes": "32"},
+    "t_struct(Config)_storage": {"encoding": "inplace", "label": "struct Test.Config", "numberOfBytes": "64", "members": [
+      {"label": "owner", "offset": 0, "slot": "0", "type": "t_address"},
+      {"label": "fee", "offset": 0, "slot": "1", "type": "t_uint256"}
+    ]}
+  }
+}`
+
+// mapStorage is contract storage held in a map.
+type mapStorage map[common.Hash][]byte
+
+func (s mapStorage) StorageAt(_ context.Context, _ common.Address, key common.Hash, _ *big.Int) ([]byte, error) {
+	if value, exists := s[key]; exists {
+		return value, nil
+	}
+
+	return make([]byte, 32), nil
+}
+
+func slotN(n int64) common.Hash {
+	return common.BigToHash(big.NewInt(n))
+}
+
+func TestStorageLayoutLocate(t *testing.T) {
+	layout, err := ParseStorageLayout(testStorageLayout)
+	require.NoError(t, err)
+	holder := common.HexToAddress("0x5FfC014343cd971B7eb70732021E26C35B744cc4")
+	resolve := func(input string) (common.Address, error) {
+		if !common.IsHexAddress(input) {
+			return common.Address{}, errors.New("unknown name")
+		}
+		return common.HexToAddress(input), nil
+	}
+	listStart := crypto.Keccak256Hash(slotN(5).Bytes()).Big()
+	configsSlot := crypto.Keccak256Hash([]byte("main"), slotN(7).Bytes()).Big()
+
+	tests := []struct {
+		name     string
+		variable string
+		slot     common.Hash
+		offset   int
+		label    string
+		err      string
+	}{
+		{
+			name:     "Packed",
+			variable: "b",
+			slot:     slotN(0),
+			offset:   16,
+			label:    "int64",
+		},
+		{
+			name:     "Mapping",
+			variable: "balances[" + holder.Hex() + "]",
+			slot:     crypto.Keccak256Hash(common.LeftPadBytes(holder.Bytes(), 32), slotN(1).Bytes()),
+			label:    "uint256",
+		},
+		{
+			name:     "StructMember",
+			variable: "config.fee",
+			slot:     slotN(4),
+			label:    "uint256",
+		},
+		{
+			name:     "DynamicArray",
+			variable: "list[2]",
+			slot:     common.BigToHash(new(big.Int).Add(listStart, big.NewInt(2))),
+			label:    "uint256",
+		},
+		{
+			name:     "PackedArray",
+			variable: "small[2]",
+			slot:     slotN(6),
+			offset:   2,
+			label:    "uint8",
+		},
+		{
+			name:     "StringKeyStruct",
+			variable: `configs["main"].fee`,
+			slot:     common.BigToHash(new(big.Int).Add(configsSlot, big.NewInt(1))),
+			label:    "uint256",
+		},
+		{
+			name:     "Unknown",
+			variable: "missing",
+			err:      "unknown variable missing",
+		},
+		{
+			name:     "OutOfRange",
+			variable: "small[3]",
+			err:      "index 3 out of range for small",
+		},
+		{
+			name:     "NotStruct",
+			variable: "a.b",
+			err:      "a is not a struct",
+		},
+		{
+			name:     "BadKey",
+			variable: "balances[bob]",
+			err:      "invalid key for balances: unknown name",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loc, err := layout.Locate(test.variable, resolve)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.slot, loc.Slot)
+				require.Equal(t, test.offset, loc.Offset)
+				require.Equal(t, test.label, loc.Type.Label)
+			}
+		})
+	}
+}
+
+func TestStorageLayoutRead(t *testing.T) {
+	layout, err := ParseStorageLayout(testStorageLayout)
+	require.NoError(t, err)
+	owner := common.HexToAddress("0x5FfC014343cd971B7eb70732021E26C35B744cc4")
+	long := strings.Repeat("a long string that spans slots ", 3)
+	nameData := crypto.Keccak256Hash(slotN(2).Bytes()).Big()
+
+	// a = 5, b = -2, c = true.
+	slot0 := make([]byte, 32)
+	slot0[31] = 5
+	for i := 8; i < 16; i++ {
+		slot0[i] = 0xff
+	}
+	slot0[15] = 0xfe
+	slot0[7] = 1
+	storage := mapStorage{
+		slotN(0): slot0,
+		slotN(2): common.BigToHash(big.NewInt(int64(len(long)*2 + 1))).Bytes(),
+		slotN(3): common.LeftPadBytes(owner.Bytes(), 32),
+		slotN(4): common.BigToHash(big.NewInt(30)).Bytes(),
+		slotN(5): common.BigToHash(big.NewInt(7)).Bytes(),
+		slotN(6): common.FromHex("0x0000000000000000000000000000000000000000000000000000000000030201"),
+	}
+	for i := 0; i*32 < len(long); i++ {
+		end := (i + 1) * 32
+		if end > len(long) {
+			end = len(long)
+		}
+		storage[common.BigToHash(new(big.Int).Add(nameData, big.NewInt(int64(i))))] = common.RightPadBytes([]byte(long[i*32:end]), 32)
+	}
+
+	tests := []struct {
+		name     string
+		variable string
+		values   map[string]interface{}
+		err      string
+	}{
+		{
+			name:     "Packed",
+			variable: "b",
+			values:   map[string]interface{}{"b": big.NewInt(-2)},
+		},
+		{
+			name:     "Bool",
+			variable: "c",
+			values:   map[string]interface{}{"c": true},
+		},
+		{
+			name:     "LongString",
+			variable: "name",
+			values:   map[string]interface{}{"name": long},
+		},
+		{
+			name:     "Struct",
+			variable: "config",
+			values:   map[string]interface{}{"config.owner": owner, "config.fee": big.NewInt(30)},
+		},
+		{
+			name:     "DynamicArray",
+			variable: "list",
+			values:   map[string]interface{}{"list.length": big.NewInt(7)},
+		},
+		{
+			name:     "StaticArray",
+			variable: "small",
+			values:   map[string]interface{}{"small[0]": big.NewInt(1), "small[1]": big.NewInt(2), "small[2]": big.NewInt(3)},
+		},
+		{
+			name:     "Mapping",
+			variable: "balances",
+			err:      "balances is a mapping; a key is required",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loc, err := layout.Locate(test.variable, nil)
+			require.NoError(t, err)
+			values, err := layout.Read(context.Background(), storage, common.Address{}, nil, loc)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				res := make(map[string]interface{})
+				for _, value := range values {
+					res[value.Name] = value.Value
+				}
+				require.Equal(t, test.values, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,456 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txdata
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/pkg/errors"
+	ens "github.com/wealdtech/go-ens/v3"
+)
+
+// maxCallDepth is the maximum depth to which nested calls are decoded.
+const maxCallDepth = 8
+
+// callSignatures are the signatures of functions that commonly contain
+// nested calls, which are always available for decoding.
+var callSignatures = []string{
+	"aggregate((address,bytes)[])",
+	"aggregate3((address,bool,bytes)[])",
+	"aggregate3Value((address,bool,uint256,bytes)[])",
+	"execTransaction(address,uint256,bytes,uint8,uint256,uint256,uint256,address,address,bytes)",
+	"execute(address,uint256,bytes,bytes32,bytes32)",
+	"execute(bytes,bytes[])",
+	"execute(bytes,bytes[],uint256)",
+	"executeBatch(address[],uint256[],bytes[],bytes32,bytes32)",
+	"multiSend(bytes)",
+	"multicall(bytes[])",
+	"multicall(bytes32,bytes[])",
+	"multicall(uint256,bytes[])",
+	"schedule(address,uint256,bytes,bytes32,bytes32,uint256)",
+	"scheduleBatch(address[],uint256[],bytes[],bytes32,bytes32,uint256)",
+	"tryAggregate(bool,(address,bytes)[])",
+}
+
+// callFunctions are the functions of callSignatures, keyed by selector.
+var callFunctions = make(map[[4]byte]function)
+
+// routerCommands are the signatures of the inputs of the Uniswap universal
+// router commands, keyed by command type.
+var routerCommands = map[byte]string{
+	0x00: "V3_SWAP_EXACT_IN(address,uint256,uint256,bytes,bool)",
+	0x01: "V3_SWAP_EXACT_OUT(address,uint256,uint256,bytes,bool)",
+	0x02: "PERMIT2_TRANSFER_FROM(address,address,uint160)",
+	0x03: "PERMIT2_PERMIT_BATCH(((address,uint160,uint48,uint48)[],address,uint256),bytes)",
+	0x04: "SWEEP(address,address,uint256)",
+	0x05: "TRANSFER(address,address,uint256)",
+	0x06: "PAY_PORTION(address,address,uint256)",
+	0x08: "V2_SWAP_EXACT_IN(address,uint256,uint256,address[],bool)",
+	0x09: "V2_SWAP_EXACT_OUT(address,uint256,uint256,address[],bool)",
+	0x0a: "PERMIT2_PERMIT(((address,uint160,uint48,uint48),address,uint256),bytes)",
+	0x0b: "WRAP_ETH(address,uint256)",
+	0x0c: "UNWRAP_WETH(address,uint256)",
+	0x0d: "PERMIT2_TRANSFER_FROM_BATCH((address,address,uint160,address)[])",
+	0x0e: "BALANCE_CHECK_ERC20(address,address,uint256)",
+}
+
+// routerCommandMask masks the command type from a universal router command.
+const routerCommandMask = 0x3f
+
+func init() {
+	for _, signature := range callSignatures {
+		name, params, signature, err := parseSignature(signature)
+		if err != nil {
+			panic(err)
+		}
+		var selector [4]byte
+		copy(selector[:], crypto.Keccak256([]byte(signature))[:4])
+		callFunctions[selector] = function{name: name, params: params}
+	}
+
+	for _, param := range []string{"uint8", "address", "uint256", "bytes"} {
+		argType, err := parseType(param)
+		if err != nil {
+			panic(err)
+		}
+		multiSendArgs = append(multiSendArgs, abi.Argument{Type: argType})
+	}
+}
+
+// Call is a function call decoded from calldata, along with the calls nested
+// within its arguments.
+type Call struct {
+	Name   string
+	Args   abi.Arguments
+	Values []interface{}
+	// Calls are the calls decoded from the bytes arguments of the call, in order.
+	Calls []*Call
+	// nested holds the call decoded from each bytes argument, in order, or
+	// nil if the argument is not a call.
+	nested []*Call
+	// packed is set if the calls are packed together in a single bytes argument.
+	packed bool
+}
+
+// DecodeCall decodes calldata, including any calls nested within its
+// arguments.  Where more than one signature matches the selector the
+// highest-ranked signature that decodes the data is used.
+func DecodeCall(data []byte) (*Call, error) {
+	return decodeCall(data, 0, false)
+}
+
+// decodeCall decodes calldata at the given depth.  If strict is set then the
+// data must be exactly the encoding of the arguments, to avoid decoding data
+// that happens to start with a known selector.
+func decodeCall(data []byte, depth int, strict bool) (*Call, error) {
+	if len(data) < 4 {
+		return nil, errors.New("data too short for a call")
+	}
+	var selector [4]byte
+	copy(selector[:], data[:4])
+	for _, candidate := range FunctionSignatures(selector) {
+		if candidate.Blacklisted() {
+			continue
+		}
+		call, err := decodeArgs(candidate.Text, data[4:], strict)
+		if err != nil {
+			continue
+		}
+		call.expand(depth)
+		return call, nil
+	}
+
+	return nil, fmt.Errorf("unknown function %#x", selector)
+}
+
+// decodeArgs decodes the arguments of a call with the given signature.
+func decodeArgs(signature string, data []byte, strict bool) (*Call, error) {
+	name, params, _, err := parseSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	args := make(abi.Arguments, len(params))
+	for i, param := range params {
+		args[i].Type, err = parseType(param)
+		if err != nil {
+			return nil, err
+		}
+	}
+	values, err := args.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	if strict {
+		encoded, err := args.Pack(values...)
+		if err != nil || !bytes.Equal(encoded, data) {
+			return nil, errors.New("data is not an exact encoding of the arguments")
+		}
+	}
+
+	return &Call{
+		Name:   name,
+		Args:   args,
+		Values: values,
+	}, nil
+}
+
+// parseType parses a type, including tuples, for example "(address,bytes)[]".
+func parseType(param string) (abi.Type, error) {
+	marshaling, err := typeMarshaling(param)
+	if err != nil {
+		return abi.Type{}, err
+	}
+
+	return abi.NewType(marshaling.Type, "", marshaling.Components)
+}
+
+// typeMarshaling returns the marshaling of a type, with the components of
+// tuples named by their position.
+func typeMarshaling(param string) (abi.ArgumentMarshaling, error) {
+	param = strings.TrimSpace(param)
+	if strings.HasPrefix(param, "tuple(") {
+		param = strings.TrimPrefix(param, "tuple")
+	}
+	if !strings.HasPrefix(param, "(") {
+		return abi.ArgumentMarshaling{Type: param}, nil
+	}
+
+	end := -1
+	depth := 0
+	for i, char := range param {
+		if char == '(' {
+			depth++
+		} else if char == ')' {
+			depth--
+			if depth == 0 {
+				end = i
+				break
+			}
+		}
+	}
+	if end == -1 {
+		return abi.ArgumentMarshaling{}, fmt.Errorf("unterminated tuple %s", param)
+	}
+
+	components := splitParams(param[1:end])
+	marshaling := abi.ArgumentMarshaling{
+		Type:       "tuple" + param[end+1:],
+		Components: make([]abi.ArgumentMarshaling, len(components)),
+	}
+	for i, component := range components {
+		componentMarshaling, err := typeMarshaling(component)
+		if err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+		componentMarshaling.Name = fmt.Sprintf("field%d", i)
+		marshaling.Components[i] = componentMarshaling
+	}
+
+	return marshaling, nil
+}
+
+// expand decodes the calls nested within the arguments of the call.
+func (c *Call) expand(depth int) {
+	if depth >= maxCallDepth {
+		return
+	}
+
+	switch {
+	case c.Name == "execute" && len(c.Values) >= 2 && c.Args[0].Type.T == abi.BytesTy && c.Args[1].Type.String() == "bytes[]":
+		c.expandRouterCommands(depth)
+	case c.Name == "multiSend" && len(c.Values) == 1 && c.Args[0].Type.T == abi.BytesTy:
+		c.expandMultiSend(depth)
+	default:
+		for i := range c.Args {
+			walkBytes(c.Args[i].Type, c.Values[i], func(data []byte) {
+				nested, err := decodeCall(data, depth+1, true)
+				if err != nil {
+					nested = nil
+				}
+				c.addNested(nested)
+			})
+		}
+	}
+}
+
+// addNested adds the call decoded from a bytes argument, or nil if the
+// argument is not a call.
+func (c *Call) addNested(nested *Call) {
+	c.nested = append(c.nested, nested)
+	if nested != nil {
+		c.Calls = append(c.Calls, nested)
+	}
+}
+
+// expandRouterCommands decodes the inputs of the commands of a Uniswap
+// universal router execute() call.
+func (c *Call) expandRouterCommands(depth int) {
+	commands, _ := c.Values[0].([]byte)
+	inputs, _ := c.Values[1].([][]byte)
+	// The commands argument is not itself a call.
+	c.addNested(nil)
+	for i, input := range inputs {
+		var nested *Call
+		if i < len(commands) {
+			if signature, exists := routerCommands[commands[i]&routerCommandMask]; exists {
+				var err error
+				if nested, err = decodeArgs(signature, input, false); err == nil {
+					nested.expand(depth + 1)
+				}
+			}
+		}
+		c.addNested(nested)
+	}
+}
+
+// multiSendArgs are the arguments of a transaction packed in to a Safe
+// multiSend() call.
+var multiSendArgs abi.Arguments
+
+// expandMultiSend decodes the transactions packed in to a Safe multiSend()
+// call, each of which is the operation, address, value, data length and data.
+func (c *Call) expandMultiSend(depth int) {
+	data, _ := c.Values[0].([]byte)
+	calls := make([]*Call, 0)
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < 85 {
+			c.addNested(nil)
+			return
+		}
+		length := new(big.Int).SetBytes(data[offset+53 : offset+85])
+		if !length.IsUint64() || length.Uint64() > uint64(len(data)-offset-85) {
+			// Not a valid set of transactions.
+			c.addNested(nil)
+			return
+		}
+		end := offset + 85 + int(length.Uint64())
+		call := &Call{
+			Name: "transaction",
+			Args: multiSendArgs,
+			Values: []interface{}{
+				data[offset],
+				common.BytesToAddress(data[offset+1 : offset+21]),
+				new(big.Int).SetBytes(data[offset+21 : offset+53]),
+				data[offset+85 : end],
+			},
+		}
+		call.expand(depth + 1)
+		calls = append(calls, call)
+		offset = end
+	}
+
+	c.nested = []*Call{nil}
+	c.Calls = calls
+	c.packed = true
+}
+
+// walkBytes calls the function for each bytes value within a value, in order.
+func walkBytes(argType abi.Type, value interface{}, fn func([]byte)) {
+	switch argType.T {
+	case abi.BytesTy:
+		data, _ := value.([]byte)
+		fn(data)
+	case abi.SliceTy, abi.ArrayTy:
+		values := reflect.ValueOf(value)
+		for i := 0; i < values.Len(); i++ {
+			walkBytes(*argType.Elem, values.Index(i).Interface(), fn)
+		}
+	case abi.TupleTy:
+		values := reflect.ValueOf(value)
+		for i, elem := range argType.TupleElems {
+			walkBytes(*elem, values.Field(i).Interface(), fn)
+		}
+	}
+}
+
+// Tree returns the call and its nested calls as lines of text.  Arguments
+// holding nested calls are shown as labels such as #1, with the nested calls
+// on the following lines, indented by two spaces per level.
+func (c *Call) Tree(client *ethclient.Client) []string {
+	return c.tree(client, "", "")
+}
+
+// tree returns the lines of the call with the given label and indent.
+func (c *Call) tree(client *ethclient.Client, label string, indent string) []string {
+	labels := make([]string, len(c.Calls))
+	for i := range c.Calls {
+		labels[i] = fmt.Sprintf("%s.%d", label, i+1)
+		if label == "" {
+			labels[i] = fmt.Sprintf("#%d", i+1)
+		}
+	}
+
+	// Map the bytes arguments to the labels of the calls they hold.
+	argLabels := make([]string, len(c.nested))
+	next := 0
+	for i, nested := range c.nested {
+		if nested != nil {
+			argLabels[i] = labels[next]
+			next++
+		}
+	}
+	if c.packed {
+		argLabels = []string{"[" + strings.Join(labels, ",") + "]"}
+	}
+
+	formatter := &callFormatter{
+		client: client,
+		labels: argLabels,
+	}
+	args := make([]string, len(c.Args))
+	for i := range c.Args {
+		args[i] = formatter.format(c.Args[i].Type, c.Values[i])
+	}
+	line := fmt.Sprintf("%s(%s)", c.Name, strings.Join(args, ","))
+	if label != "" {
+		line = fmt.Sprintf("%s%s: %s", indent, label, line)
+	}
+
+	lines := []string{line}
+	if label != "" {
+		indent += "  "
+	}
+	for i, nested := range c.Calls {
+		lines = append(lines, nested.tree(client, labels[i], indent)...)
+	}
+
+	return lines
+}
+
+// callFormatter formats the arguments of a call.
+type callFormatter struct {
+	client *ethclient.Client
+	// labels are the labels of the calls held in each bytes argument, in
+	// order, or empty if the argument does not hold a call.
+	labels []string
+	next   int
+}
+
+// format formats a value.
+func (f *callFormatter) format(argType abi.Type, value interface{}) string {
+	switch argType.T {
+	case abi.BoolTy:
+		return fmt.Sprintf("%t", value)
+	case abi.StringTy:
+		return fmt.Sprintf("%q", value)
+	case abi.SliceTy, abi.ArrayTy:
+		values := reflect.ValueOf(value)
+		res := make([]string, values.Len())
+		for i := range res {
+			res[i] = f.format(*argType.Elem, values.Index(i).Interface())
+		}
+		return "[" + strings.Join(res, ",") + "]"
+	case abi.TupleTy:
+		values := reflect.ValueOf(value)
+		res := make([]string, len(argType.TupleElems))
+		for i, elem := range argType.TupleElems {
+			res[i] = f.format(*elem, values.Field(i).Interface())
+		}
+		return "(" + strings.Join(res, ",") + ")"
+	case abi.AddressTy:
+		address, _ := value.(common.Address)
+		if f.client == nil {
+			return address.Hex()
+		}
+		return ens.Format(f.client, address)
+	case abi.BytesTy:
+		label := ""
+		if f.next < len(f.labels) {
+			label = f.labels[f.next]
+		}
+		f.next++
+		if label != "" {
+			return label
+		}
+		return fmt.Sprintf("0x%x", value)
+	case abi.FixedBytesTy, abi.FunctionTy:
+		array := reflect.ValueOf(value)
+		data := make([]byte, array.Len())
+		reflect.Copy(reflect.ValueOf(data), array)
+		return fmt.Sprintf("0x%x", data)
+	case abi.HashTy:
+		hash, _ := value.(common.Hash)
+		return hash.Hex()
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
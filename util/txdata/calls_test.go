@@ -0,0 +1,193 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txdata
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeCall encodes a call to the function with the given signature.
+func encodeCall(t *testing.T, signature string, values ...interface{}) []byte {
+	t.Helper()
+	_, params, signature, err := parseSignature(signature)
+	require.NoError(t, err)
+	args := make(abi.Arguments, len(params))
+	for i, param := range params {
+		args[i].Type, err = parseType(param)
+		require.NoError(t, err)
+	}
+	data, err := args.Pack(values...)
+	require.NoError(t, err)
+
+	return append(crypto.Keccak256([]byte(signature))[:4], data...)
+}
+
+// call3 is an aggregate3() call.
+type call3 struct {
+	Field0 common.Address
+	Field1 bool
+	Field2 []byte
+}
+
+func TestDecodeCall(t *testing.T) {
+	db, err := OpenSignatureDB(filepath.Join(t.TempDir(), "signatures.json"))
+	require.NoError(t, err)
+	require.NoError(t, db.AddFunction("transfer(address,uint256)", ContractScore, 0, ""))
+	require.NoError(t, db.AddFunction("deposit()", ContractScore, 0, ""))
+	UseSignatureDB(db)
+	defer UseSignatureDB(nil)
+
+	recipient := common.HexToAddress("0x7755B69903BcbCc419260dBb65772412E0C4ad2b")
+	token := common.HexToAddress("0xf3db7560E820834658B590C96234c333Cd3D5E5e")
+	transfer := encodeCall(t, "transfer(address,uint256)", recipient, big.NewInt(100))
+	deposit := encodeCall(t, "deposit()")
+	multicall := encodeCall(t, "multicall(bytes[])", [][]byte{transfer, {0x01, 0x02}, deposit})
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+		tree []string
+	}{
+		{
+			name: "Short",
+			data: []byte{0x01, 0x02},
+			err:  "data too short for a call",
+		},
+		{
+			name: "Unknown",
+			data: []byte{0x01, 0x02, 0x03, 0x04},
+			err:  "unknown function 0x01020304",
+		},
+		{
+			name: "Flat",
+			data: transfer,
+			tree: []string{"transfer(0x7755B69903BcbCc419260dBb65772412E0C4ad2b,100)"},
+		},
+		{
+			name: "Multicall",
+			data: multicall,
+			tree: []string{
+				"multicall([#1,0x0102,#2])",
+				"#1: transfer(0x7755B69903BcbCc419260dBb65772412E0C4ad2b,100)",
+				"#2: deposit()",
+			},
+		},
+		{
+			name: "NotExactEncoding",
+			data: encodeCall(t, "multicall(bytes[])", [][]byte{append(transfer, 0x00)}),
+			tree: []string{"multicall([0x" + common.Bytes2Hex(transfer) + "00])"},
+		},
+		{
+			name: "Aggregate3",
+			data: encodeCall(t, "aggregate3((address,bool,bytes)[])", []call3{{Field0: token, Field1: true, Field2: transfer}}),
+			tree: []string{
+				"aggregate3([(0xf3db7560E820834658B590C96234c333Cd3D5E5e,true,#1)])",
+				"#1: transfer(0x7755B69903BcbCc419260dBb65772412E0C4ad2b,100)",
+			},
+		},
+		{
+			name: "SafeSchedule",
+			data: encodeCall(t, "execTransaction(address,uint256,bytes,uint8,uint256,uint256,uint256,address,address,bytes)",
+				token, big.NewInt(0),
+				encodeCall(t, "schedule(address,uint256,bytes,bytes32,bytes32,uint256)", token, big.NewInt(0), multicall, [32]byte{}, [32]byte{0x01}, big.NewInt(86400)),
+				uint8(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), common.Address{}, common.Address{}, []byte{}),
+			tree: []string{
+				"execTransaction(0xf3db7560E820834658B590C96234c333Cd3D5E5e,0,#1,0,0,0,0,0x0000000000000000000000000000000000000000,0x0000000000000000000000000000000000000000,0x)",
+				"#1: schedule(0xf3db7560E820834658B590C96234c333Cd3D5E5e,0,#1.1,0x0000000000000000000000000000000000000000000000000000000000000000,0x0100000000000000000000000000000000000000000000000000000000000000,86400)",
+				"  #1.1: multicall([#1.1.1,0x0102,#1.1.2])",
+				"    #1.1.1: transfer(0x7755B69903BcbCc419260dBb65772412E0C4ad2b,100)",
+				"    #1.1.2: deposit()",
+			},
+		},
+		{
+			name: "MultiSend",
+			data: encodeCall(t, "multiSend(bytes)", append(
+				append(append(append([]byte{0x00}, token.Bytes()...), common.LeftPadBytes([]byte{0x05}, 32)...), common.LeftPadBytes([]byte{byte(len(transfer))}, 32)...),
+				transfer...)),
+			tree: []string{
+				"multiSend([#1])",
+				"#1: transaction(0,0xf3db7560E820834658B590C96234c333Cd3D5E5e,5,#1.1)",
+				"  #1.1: transfer(0x7755B69903BcbCc419260dBb65772412E0C4ad2b,100)",
+			},
+		},
+		{
+			name: "Router",
+			data: encodeCall(t, "execute(bytes,bytes[],uint256)", []byte{0x0b, 0x3f}, [][]byte{
+				common.FromHex("0x0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000a"),
+				{0x01},
+			}, big.NewInt(1700000000)),
+			tree: []string{
+				"execute(0x0b3f,[#1,0x01],1700000000)",
+				"#1: WRAP_ETH(0x0000000000000000000000000000000000000002,10)",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			call, err := DecodeCall(test.data)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.tree, call.Tree(nil))
+		})
+	}
+}
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		param string
+		str   string
+		err   string
+	}{
+		{
+			param: "uint256",
+			str:   "uint256",
+		},
+		{
+			param: "(address,bool,bytes)[]",
+			str:   "(address,bool,bytes)[]",
+		},
+		{
+			param: "tuple((address,uint160,uint48,uint48)[],address,uint256)",
+			str:   "((address,uint160,uint48,uint48)[],address,uint256)",
+		},
+		{
+			param: "(address,bool",
+			err:   "unterminated tuple (address,bool",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.param, func(t *testing.T) {
+			argType, err := parseType(test.param)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.str, argType.String())
+		})
+	}
+}
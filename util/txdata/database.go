@@ -0,0 +1,440 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txdata
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DirectoryScore is the score of a signature from a signature directory,
+	// to which anyone can submit signatures.
+	DirectoryScore = 1
+	// ContractScore is the score of a signature from an ABI or contract
+	// artifact, where it is known to belong to a contract.
+	ContractScore = 10
+	// UserScore is the score of a signature supplied directly by the user.
+	UserScore = 100
+	// BuiltInScore is the score of a built-in signature, which is as likely
+	// to be genuine as one from a contract.
+	BuiltInScore = ContractScore
+)
+
+// database is the signature database consulted when decoding, if any.
+var database *SignatureDB
+
+// databaseLoader loads the signature database when it is first required.
+var databaseLoader func() *SignatureDB
+
+// UseSignatureDB sets the signature database to consult when decoding,
+// alongside the built-in signatures.
+func UseSignatureDB(db *SignatureDB) {
+	database = db
+	databaseLoader = nil
+}
+
+// UseSignatureDBLoader sets a function to load the signature database to
+// consult when decoding.  The database is only loaded when a signature is
+// first looked up, to avoid the cost of loading it when it is not needed.
+func UseSignatureDBLoader(loader func() *SignatureDB) {
+	database = nil
+	databaseLoader = loader
+}
+
+// signatureDatabase returns the signature database in use, if any, loading it
+// if it has yet to be.
+func signatureDatabase() *SignatureDB {
+	if database == nil && databaseLoader != nil {
+		database = databaseLoader()
+		databaseLoader = nil
+	}
+
+	return database
+}
+
+// Signature is a candidate signature for a function selector or event topic.
+type Signature struct {
+	Text string `json:"signature"`
+	// Score is the weight of evidence for the signature, summed across imports.
+	Score uint64 `json:"score"`
+	// ID is the lowest signature directory ID of the signature, if known.
+	ID uint64 `json:"id,omitempty"`
+	// Sources are the sources from which the signature has been imported,
+	// so that importing the same source again does not add to the score.
+	Sources []string `json:"sources,omitempty"`
+	// builtIn is set if the signature is built in.
+	builtIn bool
+}
+
+// Blacklisted returns true if the signature is a known deliberate collision.
+func (s *Signature) Blacklisted() bool {
+	return blacklist[s.Text]
+}
+
+// SignatureDB is a persistent database of function and event signatures,
+// keyed by function selector and event topic.  A selector or topic can have
+// more than one candidate signature, in which case they are ranked.
+type SignatureDB struct {
+	path      string
+	functions map[string][]*Signature
+	events    map[string][]*Signature
+}
+
+// signatureDBJSON is the stored form of the signature database.
+type signatureDBJSON struct {
+	Functions map[string][]*Signature `json:"functions"`
+	Events    map[string][]*Signature `json:"events"`
+}
+
+// DefaultSignatureDBPath returns the default path of the signature database,
+// which is $HOME/.ethereal/signatures.json.
+func DefaultSignatureDBPath() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain home directory")
+	}
+
+	return filepath.Join(home, ".ethereal", "signatures.json"), nil
+}
+
+// OpenSignatureDB opens the signature database at the given path.  The
+// database is empty if the path does not exist.
+func OpenSignatureDB(path string) (*SignatureDB, error) {
+	db := &SignatureDB{
+		path:      path,
+		functions: make(map[string][]*Signature),
+		events:    make(map[string][]*Signature),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return db, nil
+		}
+		return nil, errors.Wrap(err, "failed to read signature database")
+	}
+	var stored signatureDBJSON
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, errors.Wrap(err, "invalid signature database")
+	}
+	if stored.Functions != nil {
+		db.functions = stored.Functions
+	}
+	if stored.Events != nil {
+		db.events = stored.Events
+	}
+
+	return db, nil
+}
+
+// Save writes the signature database to its path.
+func (db *SignatureDB) Save() error {
+	data, err := json.Marshal(&signatureDBJSON{
+		Functions: db.functions,
+		Events:    db.events,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode signature database")
+	}
+	if err := os.MkdirAll(filepath.Dir(db.path), 0o700); err != nil {
+		return errors.Wrap(err, "failed to create signature database directory")
+	}
+	// Write to a temporary file and rename, to avoid leaving a partial database.
+	tmpPath := db.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return errors.Wrap(err, "failed to write signature database")
+	}
+	if err := os.Rename(tmpPath, db.path); err != nil {
+		return errors.Wrap(err, "failed to write signature database")
+	}
+
+	return nil
+}
+
+// AddFunction adds a function signature from the given source with the given
+// score and signature directory ID, or adds to the score of the signature if
+// already present.  If the signature has already been added from the source
+// then it is unchanged.  The source can be empty, in which case the score is
+// always added.
+func (db *SignatureDB) AddFunction(signature string, score uint64, id uint64, source string) error {
+	_, _, signature, err := parseSignature(signature)
+	if err != nil {
+		return err
+	}
+	selector := hex.EncodeToString(crypto.Keccak256([]byte(signature))[:4])
+	db.functions["0x"+selector] = addSignature(db.functions["0x"+selector], signature, score, id, source)
+
+	return nil
+}
+
+// AddEvent adds an event signature from the given source with the given
+// score and signature directory ID, or adds to the score of the signature if
+// already present.  If the signature has already been added from the source
+// then it is unchanged.  The source can be empty, in which case the score is
+// always added.
+func (db *SignatureDB) AddEvent(signature string, score uint64, id uint64, source string) error {
+	_, _, signature, err := parseSignature(signature)
+	if err != nil {
+		return err
+	}
+	topic := crypto.Keccak256Hash([]byte(signature)).Hex()
+	db.events[topic] = addSignature(db.events[topic], signature, score, id, source)
+
+	return nil
+}
+
+// addSignature adds a signature to a list of candidates.
+func addSignature(candidates []*Signature, signature string, score uint64, id uint64, source string) []*Signature {
+	for _, candidate := range candidates {
+		if candidate.Text != signature {
+			continue
+		}
+		if source != "" {
+			for _, existing := range candidate.Sources {
+				if existing == source {
+					return candidates
+				}
+			}
+			candidate.Sources = append(candidate.Sources, source)
+		}
+		candidate.Score += score
+		if id != 0 && (candidate.ID == 0 || id < candidate.ID) {
+			candidate.ID = id
+		}
+		return candidates
+	}
+
+	candidate := &Signature{Text: signature, Score: score, ID: id}
+	if source != "" {
+		candidate.Sources = []string{source}
+	}
+
+	return append(candidates, candidate)
+}
+
+// Functions returns the candidate signatures for a function selector, ranked.
+func (db *SignatureDB) Functions(selector [4]byte) []*Signature {
+	return rankSignatures(db.functions["0x"+hex.EncodeToString(selector[:])])
+}
+
+// Events returns the candidate signatures for an event topic, ranked.
+func (db *SignatureDB) Events(topic common.Hash) []*Signature {
+	return rankSignatures(db.events[topic.Hex()])
+}
+
+// FunctionsByName returns the function signatures with the given name.
+func (db *SignatureDB) FunctionsByName(name string) []string {
+	return signaturesByName(db.functions, name)
+}
+
+// EventsByName returns the event signatures with the given name.
+func (db *SignatureDB) EventsByName(name string) []string {
+	return signaturesByName(db.events, name)
+}
+
+// signaturesByName returns the signatures with the given name.
+func signaturesByName(signatures map[string][]*Signature, name string) []string {
+	res := make([]string, 0)
+	for _, candidates := range signatures {
+		for _, candidate := range candidates {
+			if candidateName, _, _, err := parseSignature(candidate.Text); err == nil && candidateName == name {
+				res = append(res, candidate.Text)
+			}
+		}
+	}
+
+	return uniqueSorted(res)
+}
+
+// rankSignatures ranks candidate signatures.  Blacklisted signatures are
+// ranked last, then signatures are ranked by score, then built-in signatures
+// ahead of others with the same score, and then by signature directory ID, as
+// the first signature registered for a selector is more likely to be genuine
+// than later collisions.
+func rankSignatures(candidates []*Signature) []*Signature {
+	res := make([]*Signature, len(candidates))
+	copy(res, candidates)
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Blacklisted() != res[j].Blacklisted() {
+			return !res[i].Blacklisted()
+		}
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		if res[i].builtIn != res[j].builtIn {
+			return res[i].builtIn
+		}
+		if res[i].ID != res[j].ID {
+			// Unknown IDs rank after known IDs.
+			return res[j].ID == 0 || (res[i].ID != 0 && res[i].ID < res[j].ID)
+		}
+		return res[i].Text < res[j].Text
+	})
+
+	return res
+}
+
+// FunctionSignatures returns the candidate signatures for a function
+// selector, ranked, from the built-in signatures and the signature database
+// in use.
+func FunctionSignatures(selector [4]byte) []*Signature {
+	var res []*Signature
+	if function, exists := functions[selector]; exists {
+		res = appendBuiltIn(res, function.String())
+	}
+	if function, exists := callFunctions[selector]; exists {
+		res = appendBuiltIn(res, function.String())
+	}
+	if db := signatureDatabase(); db != nil {
+		res = mergeSignatures(res, db.Functions(selector))
+	}
+
+	return rankSignatures(res)
+}
+
+// EventSignatures returns the candidate signatures for an event topic,
+// ranked, from the built-in signatures and the signature database in use.
+func EventSignatures(topic common.Hash) []*Signature {
+	var res []*Signature
+	if event, exists := events[topic]; exists {
+		res = appendBuiltIn(res, event.String())
+	}
+	if db := signatureDatabase(); db != nil {
+		res = mergeSignatures(res, db.Events(topic))
+	}
+
+	return rankSignatures(res)
+}
+
+// FunctionsByName returns the function signatures with the given name from
+// the signature database in use and the built-in signatures.
+func FunctionsByName(name string) []string {
+	var res []string
+	if db := signatureDatabase(); db != nil {
+		res = db.FunctionsByName(name)
+	}
+	for _, function := range functions {
+		if function.name == name {
+			res = append(res, function.String())
+		}
+	}
+	for _, function := range callFunctions {
+		if function.name == name {
+			res = append(res, function.String())
+		}
+	}
+
+	return uniqueSorted(res)
+}
+
+// EventsByName returns the event signatures with the given name from the
+// signature database in use and the built-in signatures.
+func EventsByName(name string) []string {
+	var res []string
+	if db := signatureDatabase(); db != nil {
+		res = db.EventsByName(name)
+	}
+	for _, event := range events {
+		if event.name == name {
+			res = append(res, event.String())
+		}
+	}
+
+	return uniqueSorted(res)
+}
+
+// uniqueSorted returns the unique strings of a list, sorted.
+func uniqueSorted(input []string) []string {
+	sort.Strings(input)
+	res := make([]string, 0, len(input))
+	for i := range input {
+		if i == 0 || input[i] != input[i-1] {
+			res = append(res, input[i])
+		}
+	}
+
+	return res
+}
+
+// appendBuiltIn appends a built-in signature to a list of candidates if it
+// is not already present.
+func appendBuiltIn(candidates []*Signature, signature string) []*Signature {
+	for _, candidate := range candidates {
+		if candidate.Text == signature {
+			return candidates
+		}
+	}
+
+	return append(candidates, &Signature{Text: signature, Score: BuiltInScore, builtIn: true})
+}
+
+// mergeSignatures merges signatures from the database in to a list of
+// built-in candidates.  A signature that is also built in keeps the higher of
+// its scores.
+func mergeSignatures(candidates []*Signature, signatures []*Signature) []*Signature {
+	for _, signature := range signatures {
+		merged := false
+		for i, candidate := range candidates {
+			if candidate.Text == signature.Text {
+				if signature.Score > candidate.Score {
+					// Copy rather than alter the signature held by the database.
+					updated := *signature
+					updated.builtIn = true
+					candidates[i] = &updated
+				}
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			candidates = append(candidates, signature)
+		}
+	}
+
+	return candidates
+}
+
+// bestFunction returns the best candidate function for a selector.
+func bestFunction(selector [4]byte) (function, bool) {
+	return bestCandidate(FunctionSignatures(selector))
+}
+
+// bestEvent returns the best candidate event for a topic.
+func bestEvent(topic common.Hash) (function, bool) {
+	return bestCandidate(EventSignatures(topic))
+}
+
+// bestCandidate returns the highest-ranked candidate that is not blacklisted.
+func bestCandidate(candidates []*Signature) (function, bool) {
+	for _, candidate := range candidates {
+		if candidate.Blacklisted() {
+			continue
+		}
+		name, params, _, err := parseSignature(candidate.Text)
+		if err == nil {
+			return function{name: name, params: params}, true
+		}
+	}
+
+	return function{}, false
+}
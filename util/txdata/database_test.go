@@ -0,0 +1,222 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txdata
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// signatureTexts returns the text of signatures.
+func signatureTexts(signatures []*Signature) []string {
+	var res []string
+	for _, signature := range signatures {
+		res = append(res, signature.Text)
+	}
+
+	return res
+}
+
+func TestSignatureDBImport(t *testing.T) {
+	transferSelector := [4]byte{0xa9, 0x05, 0x9c, 0xbb}
+	transferTopic := common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+	tests := []struct {
+		name      string
+		data      string
+		err       string
+		result    *ImportResult
+		functions []string
+		events    []string
+	}{
+		{
+			name:      "Text",
+			data:      "# Comment\ntransfer(address,uint256)\n\nTransfer(address indexed from,address indexed to,uint256 value)\ninvalid\n",
+			result:    &ImportResult{Functions: 2, Events: 2, Skipped: 1},
+			functions: []string{"transfer(address,uint256)"},
+			events:    []string{"Transfer(address,address,uint256)"},
+		},
+		{
+			name:      "TextWithSelectors",
+			data:      "0xa9059cbb transfer(address,uint256)\n0xa9059cbb,transfer(address)\n0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef\tTransfer(address,address,uint256)\n",
+			result:    &ImportResult{Functions: 1, Events: 1, Skipped: 1},
+			functions: []string{"transfer(address,uint256)"},
+			events:    []string{"Transfer(address,address,uint256)"},
+		},
+		{
+			name:      "DirectoryResults",
+			data:      `{"count":2,"results":[{"id":31780,"text_signature":"transfer(address,uint256)","hex_signature":"0xa9059cbb"},{"id":2,"text_signature":"Transfer(address,address,uint256)","hex_signature":"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"}]}`,
+			result:    &ImportResult{Functions: 1, Events: 1},
+			functions: []string{"transfer(address,uint256)"},
+			events:    []string{"Transfer(address,address,uint256)"},
+		},
+		{
+			name:      "DirectoryMap",
+			data:      `{"0xa9059cbb":["transfer(address,uint256)","bad(uint256)"]}`,
+			result:    &ImportResult{Functions: 1, Skipped: 1},
+			functions: []string{"transfer(address,uint256)"},
+		},
+		{
+			name:      "ABI",
+			data:      `[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]}]`,
+			result:    &ImportResult{Functions: 1, Events: 1},
+			functions: []string{"transfer(address,uint256)"},
+			events:    []string{"Transfer(address,address,uint256)"},
+		},
+		{
+			name:      "Artifact",
+			data:      `{"abi":[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[]}],"bytecode":{"object":"0x"},"methodIdentifiers":{"transfer(address,uint256)":"a9059cbb"}}`,
+			result:    &ImportResult{Functions: 1},
+			functions: []string{"transfer(address,uint256)"},
+		},
+		{
+			name:      "CombinedJSON",
+			data:      `{"contracts":{"Token.sol:Token":{"abi":"[]","hashes":{"transfer(address,uint256)":"a9059cbb"}}}}`,
+			result:    &ImportResult{Functions: 1},
+			functions: []string{"transfer(address,uint256)"},
+		},
+		{
+			name: "NoSignatures",
+			data: `{"contracts":{}}`,
+			err:  "no signatures found in JSON",
+		},
+		{
+			name: "InvalidJSON",
+			data: `{"contracts":`,
+			err:  "invalid JSON: unexpected end of JSON input",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := OpenSignatureDB(filepath.Join(t.TempDir(), "signatures.json"))
+			require.NoError(t, err)
+			result, err := db.Import("test", []byte(test.data))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.result, result)
+			require.Equal(t, test.functions, signatureTexts(db.Functions(transferSelector)))
+			require.Equal(t, test.events, signatureTexts(db.Events(transferTopic)))
+		})
+	}
+}
+
+func TestRankSignatures(t *testing.T) {
+	candidates := []*Signature{
+		{Text: "clash550254402()", Score: ContractScore},
+		{Text: "b()", Score: DirectoryScore},
+		{Text: "c()", Score: DirectoryScore, ID: 200},
+		{Text: "d()", Score: DirectoryScore, ID: 100},
+		{Text: "e()", Score: ContractScore},
+		{Text: "a()", Score: DirectoryScore},
+	}
+	require.Equal(t, []string{
+		"e()",
+		"d()",
+		"c()",
+		"a()",
+		"b()",
+		"clash550254402()",
+	}, signatureTexts(rankSignatures(candidates)))
+}
+
+func TestSignatureDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signatures.json")
+	db, err := OpenSignatureDB(path)
+	require.NoError(t, err)
+
+	withdrawSelector := [4]byte{0x2e, 0x1a, 0x7d, 0x4d}
+	require.NoError(t, db.AddFunction("withdraw(uint256)", DirectoryScore, 200, ""))
+	require.NoError(t, db.AddFunction("withdraw(uint256 amount)", ContractScore, 0, ""))
+	require.NoError(t, db.AddFunction("withdraw(uint256)", DirectoryScore, 100, ""))
+	require.EqualError(t, db.AddFunction("withdraw", DirectoryScore, 0, ""), `invalid signature "withdraw"`)
+	candidates := db.Functions(withdrawSelector)
+	require.Len(t, candidates, 1)
+	require.Equal(t, &Signature{Text: "withdraw(uint256)", Score: 2*DirectoryScore + ContractScore, ID: 100}, candidates[0])
+
+	// Reverse lookup.
+	require.Equal(t, []string{"withdraw(uint256)"}, db.FunctionsByName("withdraw"))
+	require.Empty(t, db.EventsByName("withdraw"))
+
+	// Save and reopen.
+	require.NoError(t, db.Save())
+	reopened, err := OpenSignatureDB(path)
+	require.NoError(t, err)
+	require.Equal(t, candidates, reopened.Functions(withdrawSelector))
+}
+
+func TestDataToStringSignatureDB(t *testing.T) {
+	InitFunctionMap()
+	db, err := OpenSignatureDB(filepath.Join(t.TempDir(), "signatures.json"))
+	require.NoError(t, err)
+	require.NoError(t, db.AddFunction("setValue(uint256 value)", UserScore, 0, ""))
+	UseSignatureDB(db)
+	defer UseSignatureDB(nil)
+
+	data := common.FromHex("0x552410770000000000000000000000000000000000000000000000000000000000000005")
+	require.Equal(t, "setValue(5)", DataToString(nil, data))
+}
+
+func TestSignatureDBReimport(t *testing.T) {
+	db, err := OpenSignatureDB(filepath.Join(t.TempDir(), "signatures.json"))
+	require.NoError(t, err)
+
+	data := []byte("transfer(address,uint256)\n")
+	transferSelector := [4]byte{0xa9, 0x05, 0x9c, 0xbb}
+	_, err = db.Import("a.txt", data)
+	require.NoError(t, err)
+	_, err = db.Import("a.txt", data)
+	require.NoError(t, err)
+	require.Equal(t, uint64(DirectoryScore), db.Functions(transferSelector)[0].Score)
+
+	// A different source adds to the score.
+	_, err = db.Import("b.txt", data)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2*DirectoryScore), db.Functions(transferSelector)[0].Score)
+}
+
+func TestFunctionSignaturesBuiltIn(t *testing.T) {
+	// multicall(bytes[]) is always built in.
+	multicallSelector := [4]byte{0xac, 0x96, 0x50, 0xd8}
+
+	loads := 0
+	UseSignatureDBLoader(func() *SignatureDB {
+		loads++
+		db, err := OpenSignatureDB(filepath.Join(t.TempDir(), "signatures.json"))
+		require.NoError(t, err)
+		db.functions["0xac9650d8"] = []*Signature{
+			{Text: "collision(uint256)", Score: 5 * DirectoryScore, ID: 1},
+			{Text: "abi(uint256)", Score: ContractScore},
+		}
+		db.functions["0x01020304"] = []*Signature{{Text: "unknown()", Score: DirectoryScore}}
+		return db
+	})
+	defer UseSignatureDB(nil)
+
+	// The database is loaded on first use, and the ranking is the same for
+	// every lookup.  Built-in signatures rank ahead of those with the same
+	// score from the database.
+	expected := []string{"multicall(bytes[])", "abi(uint256)", "collision(uint256)"}
+	require.Equal(t, expected, signatureTexts(FunctionSignatures(multicallSelector)))
+	require.Equal(t, 1, loads)
+	require.Equal(t, []string{"unknown()"}, signatureTexts(FunctionSignatures([4]byte{0x01, 0x02, 0x03, 0x04})))
+	require.Equal(t, expected, signatureTexts(FunctionSignatures(multicallSelector)))
+	require.Equal(t, 1, loads)
+}
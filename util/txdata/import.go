@@ -0,0 +1,338 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txdata
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// hexIDRegexp matches a function selector or event topic.
+var hexIDRegexp = regexp.MustCompile("^0x([0-9a-fA-F]{8}|[0-9a-fA-F]{64})$")
+
+// ImportResult is the result of importing signatures.
+type ImportResult struct {
+	Functions int
+	Events    int
+	// Skipped is the number of signatures that were invalid or did not
+	// match their selector or topic.
+	Skipped int
+}
+
+// importer imports signatures in to a database, counting each signature
+// once however many times it appears in the input.
+type importer struct {
+	db     *SignatureDB
+	source string
+	seen   map[string]bool
+	result *ImportResult
+}
+
+// Import imports signatures in to the database.  The data can be:
+//   - text, with one signature per line, optionally preceded by its selector
+//     or topic; signatures without a selector or topic are imported as both
+//     functions and events
+//   - a signature directory JSON dump, either as a list of results with
+//     text_signature and hex_signature fields or as a map of selector or
+//     topic to signatures
+//   - a contract ABI
+//   - a contract artifact or compiler output, from which the ABIs and method
+//     identifiers of all contracts are imported
+//
+// Signatures from a signature directory score less than those from ABIs and
+// artifacts.  The source identifies where the data came from, for example the
+// path of the file; importing from the same source again does not add to the
+// scores of signatures already imported from it.  The database is not saved.
+func (db *SignatureDB) Import(source string, data []byte) (*ImportResult, error) {
+	imp := &importer{
+		db:     db,
+		source: source,
+		seen:   make(map[string]bool),
+		result: &ImportResult{},
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && (data[0] == '[' || data[0] == '{') {
+		if err := imp.importJSON(data); err != nil {
+			return nil, err
+		}
+	} else {
+		imp.importText(data)
+	}
+
+	return imp.result, nil
+}
+
+// importText imports signatures from text.
+func (imp *importer) importText(data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hexID := ""
+		if strings.HasPrefix(line, "0x") {
+			end := strings.IndexAny(line, " \t,:")
+			if end == -1 {
+				imp.result.Skipped++
+				continue
+			}
+			hexID = line[:end]
+			line = strings.TrimLeft(line[end:], " \t,:")
+		}
+		if hexID == "" {
+			if _, _, _, err := parseSignature(line); err != nil || !strings.HasSuffix(line, ")") {
+				imp.result.Skipped++
+				continue
+			}
+			imp.addFunction(line, "", DirectoryScore, 0)
+			imp.addEvent(line, "", DirectoryScore, 0)
+		} else {
+			imp.add(line, hexID, DirectoryScore, 0)
+		}
+	}
+}
+
+// importJSON imports signatures from JSON.
+func (imp *importer) importJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errors.Wrap(err, "invalid JSON")
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		if len(v) > 0 {
+			if entry, isMap := v[0].(map[string]interface{}); isMap {
+				if _, exists := entry["text_signature"]; exists {
+					imp.importDirectoryResults(v)
+					return nil
+				}
+			}
+		}
+		return imp.importABI(data)
+	case map[string]interface{}:
+		if results, isList := v["results"].([]interface{}); isList {
+			imp.importDirectoryResults(results)
+			return nil
+		}
+		if isDirectoryMap(v) {
+			imp.importDirectoryMap(v)
+			return nil
+		}
+		before := *imp.result
+		if err := imp.importContracts(v); err != nil {
+			return err
+		}
+		if *imp.result == before {
+			return errors.New("no signatures found in JSON")
+		}
+	}
+
+	return nil
+}
+
+// importDirectoryResults imports signature directory results.
+func (imp *importer) importDirectoryResults(results []interface{}) {
+	for _, result := range results {
+		entry, isMap := result.(map[string]interface{})
+		if !isMap {
+			imp.result.Skipped++
+			continue
+		}
+		signature, _ := entry["text_signature"].(string)
+		hexID, _ := entry["hex_signature"].(string)
+		id, _ := entry["id"].(float64)
+		if hexID == "" {
+			imp.addFunction(signature, "", DirectoryScore, uint64(id))
+			continue
+		}
+		imp.add(signature, hexID, DirectoryScore, uint64(id))
+	}
+}
+
+// isDirectoryMap returns true if the map is keyed by selectors or topics.
+func isDirectoryMap(v map[string]interface{}) bool {
+	if len(v) == 0 {
+		return false
+	}
+	for key := range v {
+		if !hexIDRegexp.MatchString(key) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// importDirectoryMap imports a map of selectors or topics to signatures.
+func (imp *importer) importDirectoryMap(v map[string]interface{}) {
+	for hexID, signatures := range v {
+		switch signatures := signatures.(type) {
+		case string:
+			imp.add(signatures, hexID, DirectoryScore, 0)
+		case []interface{}:
+			for _, signature := range signatures {
+				text, _ := signature.(string)
+				imp.add(text, hexID, DirectoryScore, 0)
+			}
+		default:
+			imp.result.Skipped++
+		}
+	}
+}
+
+// importContracts imports the ABIs and method identifiers found anywhere
+// within contract artifacts or compiler output.
+func (imp *importer) importContracts(value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			switch key {
+			case "abi":
+				if err := imp.importEmbeddedABI(child); err != nil {
+					return err
+				}
+			case "methodIdentifiers", "hashes":
+				identifiers, isMap := child.(map[string]interface{})
+				if !isMap {
+					continue
+				}
+				for signature, selector := range identifiers {
+					selectorStr, _ := selector.(string)
+					imp.addFunction(signature, "0x"+strings.TrimPrefix(selectorStr, "0x"), ContractScore, 0)
+				}
+			default:
+				if err := imp.importContracts(child); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if err := imp.importContracts(child); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// importEmbeddedABI imports an ABI held within JSON, either directly or as a string.
+func (imp *importer) importEmbeddedABI(value interface{}) error {
+	var data []byte
+	if str, isString := value.(string); isString {
+		data = []byte(str)
+	} else {
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return errors.Wrap(err, "failed to encode ABI")
+		}
+	}
+
+	return imp.importABI(data)
+}
+
+// importABI imports the functions and events of an ABI.
+func (imp *importer) importABI(data []byte) error {
+	contractAbi, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "invalid ABI")
+	}
+	for _, method := range contractAbi.Methods {
+		imp.addFunction(method.Sig, "", ContractScore, 0)
+	}
+	for _, event := range contractAbi.Events {
+		imp.addEvent(event.Sig, "", ContractScore, 0)
+	}
+
+	return nil
+}
+
+// add adds a signature as a function or event according to the length of its
+// selector or topic.
+func (imp *importer) add(signature string, hexID string, score uint64, id uint64) {
+	if !hexIDRegexp.MatchString(hexID) {
+		imp.result.Skipped++
+		return
+	}
+	if len(hexID) == 10 {
+		imp.addFunction(signature, hexID, score, id)
+	} else {
+		imp.addEvent(signature, hexID, score, id)
+	}
+}
+
+// addFunction adds a function signature, checking it against its selector if supplied.
+func (imp *importer) addFunction(signature string, selector string, score uint64, id uint64) {
+	if !strings.HasSuffix(signature, ")") {
+		imp.result.Skipped++
+		return
+	}
+	_, _, signature, err := parseSignature(signature)
+	if err != nil {
+		imp.result.Skipped++
+		return
+	}
+	if selector != "" && !strings.EqualFold(selector, "0x"+hex.EncodeToString(crypto.Keccak256([]byte(signature))[:4])) {
+		imp.result.Skipped++
+		return
+	}
+	if imp.seen["function:"+signature] {
+		return
+	}
+	imp.seen["function:"+signature] = true
+	if err := imp.db.AddFunction(signature, score, id, imp.source); err != nil {
+		imp.result.Skipped++
+		return
+	}
+	imp.result.Functions++
+}
+
+// addEvent adds an event signature, checking it against its topic if supplied.
+func (imp *importer) addEvent(signature string, topic string, score uint64, id uint64) {
+	if !strings.HasSuffix(signature, ")") {
+		imp.result.Skipped++
+		return
+	}
+	_, _, signature, err := parseSignature(signature)
+	if err != nil {
+		imp.result.Skipped++
+		return
+	}
+	if topic != "" && !strings.EqualFold(topic, crypto.Keccak256Hash([]byte(signature)).Hex()) {
+		imp.result.Skipped++
+		return
+	}
+	if imp.seen["event:"+signature] {
+		return
+	}
+	imp.seen["event:"+signature] = true
+	if err := imp.db.AddEvent(signature, score, id, imp.source); err != nil {
+		imp.result.Skipped++
+		return
+	}
+	imp.result.Events++
+}
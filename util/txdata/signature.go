@@ -96,7 +96,7 @@ func DataToString(client *ethclient.Client, input []byte) string {
 	}
 	var sig [4]byte
 	copy(sig[:], input[:4])
-	function, exists := functions[sig]
+	function, exists := bestFunction(sig)
 	if !exists {
 		return fmt.Sprintf("0x%x", input)
 	}
@@ -121,7 +121,7 @@ func DataToString(client *ethclient.Client, input []byte) string {
 
 // EventToString takes a transaction's event information and converts it to a useful representation if one exists.
 func EventToString(client *ethclient.Client, input *types.Log) string {
-	function, exists := events[input.Topics[0]]
+	function, exists := bestEvent(input.Topics[0])
 	if !exists {
 		return ""
 	}
@@ -210,20 +210,56 @@ func valueToString(client *ethclient.Client, argType abi.Type, index uint32, off
 	}
 }
 
-// AddFunctionSignature adds a function signature to the translation list.
-func AddFunctionSignature(signature string) {
-	// Start off removing parameter names if present.
-	sigBits := strings.Split(strings.TrimSuffix(signature, ")"), "(")
-	name := sigBits[0]
-	params := strings.Split(sigBits[1], ",")
-	if params[0] == "" {
-		params = make([]string, 0)
+// parseSignature parses a signature in to its name and parameter types,
+// removing parameter names if present, and returns them along with the
+// canonical form of the signature.
+func parseSignature(signature string) (string, []string, string, error) {
+	name, paramsStr, found := strings.Cut(strings.TrimSuffix(strings.TrimSpace(signature), ")"), "(")
+	name = strings.TrimSpace(name)
+	if !found || name == "" || strings.ContainsAny(name, " ,)") {
+		return "", nil, "", fmt.Errorf("invalid signature %q", signature)
 	}
+
+	params := splitParams(paramsStr)
 	for i := range params {
 		params[i] = strings.TrimSpace(params[i])
 		params[i] = strings.Split(params[i], " ")[0]
 	}
-	signature = fmt.Sprintf("%s(%s)", name, strings.Join(params, ","))
+
+	return name, params, fmt.Sprintf("%s(%s)", name, strings.Join(params, ",")), nil
+}
+
+// splitParams splits a list of parameters on commas outside of tuples.
+func splitParams(input string) []string {
+	params := make([]string, 0)
+	if strings.TrimSpace(input) == "" {
+		return params
+	}
+	depth := 0
+	start := 0
+	for i, char := range input {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, input[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(params, input[start:])
+}
+
+// AddFunctionSignature adds a function signature to the translation list.
+func AddFunctionSignature(signature string) {
+	name, params, signature, err := parseSignature(signature)
+	if err != nil {
+		return
+	}
 
 	// Do not add if on the blacklist.
 	if _, exists := blacklist[signature]; exists {
@@ -232,7 +268,7 @@ func AddFunctionSignature(signature string) {
 
 	var hash [32]byte
 	sha := sha3.NewLegacyKeccak256()
-	_, err := sha.Write([]byte(signature))
+	_, err = sha.Write([]byte(signature))
 	if err != nil {
 		return
 	}
@@ -247,22 +283,14 @@ func AddFunctionSignature(signature string) {
 
 // AddEventSignature adds an event signature to the translation list.
 func AddEventSignature(signature string) {
-	// Start off removing parameter names if present.
-	sigBits := strings.Split(strings.TrimSuffix(signature, ")"), "(")
-	name := sigBits[0]
-	params := strings.Split(sigBits[1], ",")
-	if params[0] == "" {
-		params = make([]string, 0)
-	}
-	for i := range params {
-		params[i] = strings.TrimSpace(params[i])
-		params[i] = strings.Split(params[i], " ")[0]
+	name, params, signature, err := parseSignature(signature)
+	if err != nil {
+		return
 	}
-	signature = fmt.Sprintf("%s(%s)", name, strings.Join(params, ","))
 
 	var hash [32]byte
 	sha := sha3.NewLegacyKeccak256()
-	_, err := sha.Write([]byte(signature))
+	_, err = sha.Write([]byte(signature))
 	if err != nil {
 		return
 	}
@@ -0,0 +1,184 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package userop
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// Bundler is a connection to an ERC-4337 bundler.
+type Bundler struct {
+	client     *rpc.Client
+	entryPoint common.Address
+}
+
+// GasEstimate is the estimate of gas for a user operation, as returned by a bundler.
+type GasEstimate struct {
+	PreVerificationGas            *big.Int
+	VerificationGasLimit          *big.Int
+	CallGasLimit                  *big.Int
+	PaymasterVerificationGasLimit *big.Int
+	PaymasterPostOpGasLimit       *big.Int
+}
+
+// gasEstimateJSON is the JSON representation of a gas estimate.
+type gasEstimateJSON struct {
+	PreVerificationGas            *hexutil.Big `json:"preVerificationGas"`
+	VerificationGasLimit          *hexutil.Big `json:"verificationGasLimit"`
+	CallGasLimit                  *hexutil.Big `json:"callGasLimit"`
+	PaymasterVerificationGasLimit *hexutil.Big `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       *hexutil.Big `json:"paymasterPostOpGasLimit,omitempty"`
+}
+
+// Receipt is the receipt for an included user operation.
+type Receipt struct {
+	UserOpHash      common.Hash
+	Sender          common.Address
+	Nonce           *big.Int
+	Success         bool
+	Reason          string
+	ActualGasCost   *big.Int
+	ActualGasUsed   *big.Int
+	TransactionHash common.Hash
+	BlockNumber     uint64
+}
+
+// receiptJSON is the JSON representation of a receipt.
+type receiptJSON struct {
+	UserOpHash    common.Hash    `json:"userOpHash"`
+	Sender        common.Address `json:"sender"`
+	Nonce         *hexutil.Big   `json:"nonce"`
+	Success       bool           `json:"success"`
+	Reason        string         `json:"reason"`
+	ActualGasCost *hexutil.Big   `json:"actualGasCost"`
+	ActualGasUsed *hexutil.Big   `json:"actualGasUsed"`
+	Receipt       struct {
+		TransactionHash common.Hash    `json:"transactionHash"`
+		BlockNumber     hexutil.Uint64 `json:"blockNumber"`
+	} `json:"receipt"`
+}
+
+// NewBundler connects to the bundler at the given URL, for user operations
+// on the given entry point.
+func NewBundler(ctx context.Context, url string, entryPoint common.Address) (*Bundler, error) {
+	if url == "" {
+		return nil, errors.New("no bundler URL supplied")
+	}
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to bundler")
+	}
+
+	return &Bundler{
+		client:     client,
+		entryPoint: entryPoint,
+	}, nil
+}
+
+// Close closes the connection to the bundler.
+func (b *Bundler) Close() {
+	b.client.Close()
+}
+
+// SupportedEntryPoints returns the entry points supported by the bundler.
+func (b *Bundler) SupportedEntryPoints(ctx context.Context) ([]common.Address, error) {
+	var res []common.Address
+	if err := b.client.CallContext(ctx, &res, "eth_supportedEntryPoints"); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain supported entry points")
+	}
+	return res, nil
+}
+
+// EstimateGas estimates the gas required for a user operation.
+func (b *Bundler) EstimateGas(ctx context.Context, op *UserOperation) (*GasEstimate, error) {
+	var res gasEstimateJSON
+	if err := b.client.CallContext(ctx, &res, "eth_estimateUserOperationGas", op, b.entryPoint); err != nil {
+		return nil, errors.Wrap(err, "failed to estimate user operation gas")
+	}
+	if res.PreVerificationGas == nil || res.VerificationGasLimit == nil || res.CallGasLimit == nil {
+		return nil, errors.New("incomplete gas estimate from bundler")
+	}
+
+	return &GasEstimate{
+		PreVerificationGas:            (*big.Int)(res.PreVerificationGas),
+		VerificationGasLimit:          (*big.Int)(res.VerificationGasLimit),
+		CallGasLimit:                  (*big.Int)(res.CallGasLimit),
+		PaymasterVerificationGasLimit: (*big.Int)(res.PaymasterVerificationGasLimit),
+		PaymasterPostOpGasLimit:       (*big.Int)(res.PaymasterPostOpGasLimit),
+	}, nil
+}
+
+// Send sends a user operation to the bundler, returning its hash.
+func (b *Bundler) Send(ctx context.Context, op *UserOperation) (common.Hash, error) {
+	var res common.Hash
+	if err := b.client.CallContext(ctx, &res, "eth_sendUserOperation", op, b.entryPoint); err != nil {
+		return common.Hash{}, errors.Wrap(err, "failed to send user operation")
+	}
+	return res, nil
+}
+
+// Receipt obtains the receipt for a user operation.  It returns nil if the
+// user operation has not been included.
+func (b *Bundler) Receipt(ctx context.Context, hash common.Hash) (*Receipt, error) {
+	var res *receiptJSON
+	if err := b.client.CallContext(ctx, &res, "eth_getUserOperationReceipt", hash); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain user operation receipt")
+	}
+	if res == nil {
+		return nil, nil
+	}
+
+	return &Receipt{
+		UserOpHash:      res.UserOpHash,
+		Sender:          res.Sender,
+		Nonce:           (*big.Int)(res.Nonce),
+		Success:         res.Success,
+		Reason:          res.Reason,
+		ActualGasCost:   (*big.Int)(res.ActualGasCost),
+		ActualGasUsed:   (*big.Int)(res.ActualGasUsed),
+		TransactionHash: res.Receipt.TransactionHash,
+		BlockNumber:     uint64(res.Receipt.BlockNumber),
+	}, nil
+}
+
+// WaitForReceipt waits for a user operation to be included, polling at the
+// given interval.  It returns nil if the user operation is not included
+// within the limit; a limit of 0 waits forever.
+func (b *Bundler) WaitForReceipt(ctx context.Context, hash common.Hash, interval time.Duration, limit time.Duration) (*Receipt, error) {
+	start := time.Now()
+	for {
+		receipt, err := b.Receipt(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			return receipt, nil
+		}
+		if limit != 0 && time.Since(start)+interval > limit {
+			return nil, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package userop provides support for ERC-4337 v0.7 user operations.
+package userop
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/ethereal/v2/util/contracts"
+)
+
+// EntryPointV07 is the address of the v0.7 EntryPoint contract.
+var EntryPointV07 = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+
+// executeSelector is the selector for execute(address,uint256,bytes), as
+// used by the reference SimpleAccount and many other smart accounts.
+var executeSelector = crypto.Keccak256([]byte("execute(address,uint256,bytes)"))[:4]
+
+// dummySignature is a signature of the correct form but no validity, used
+// when estimating gas before the user operation can be signed.
+var dummySignature = hexutil.MustDecode("0xfffffffffffffffffffffffffffffff0000000000000000000000000000000007aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa1c")
+
+// UserOperation is an ERC-4337 v0.7 user operation, in its unpacked form.
+type UserOperation struct {
+	Sender                        common.Address
+	Nonce                         *big.Int
+	Factory                       *common.Address
+	FactoryData                   []byte
+	CallData                      []byte
+	CallGasLimit                  *big.Int
+	VerificationGasLimit          *big.Int
+	PreVerificationGas            *big.Int
+	MaxFeePerGas                  *big.Int
+	MaxPriorityFeePerGas          *big.Int
+	Paymaster                     *common.Address
+	PaymasterVerificationGasLimit *big.Int
+	PaymasterPostOpGasLimit       *big.Int
+	PaymasterData                 []byte
+	Signature                     []byte
+}
+
+// userOperationJSON is the JSON representation of a user operation, as
+// used by bundlers.
+type userOperationJSON struct {
+	Sender                        common.Address  `json:"sender"`
+	Nonce                         *hexutil.Big    `json:"nonce"`
+	Factory                       *common.Address `json:"factory,omitempty"`
+	FactoryData                   hexutil.Bytes   `json:"factoryData,omitempty"`
+	CallData                      hexutil.Bytes   `json:"callData"`
+	CallGasLimit                  *hexutil.Big    `json:"callGasLimit"`
+	VerificationGasLimit          *hexutil.Big    `json:"verificationGasLimit"`
+	PreVerificationGas            *hexutil.Big    `json:"preVerificationGas"`
+	MaxFeePerGas                  *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas          *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Paymaster                     *common.Address `json:"paymaster,omitempty"`
+	PaymasterVerificationGasLimit *hexutil.Big    `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       *hexutil.Big    `json:"paymasterPostOpGasLimit,omitempty"`
+	PaymasterData                 hexutil.Bytes   `json:"paymasterData,omitempty"`
+	Signature                     hexutil.Bytes   `json:"signature"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (u *UserOperation) MarshalJSON() ([]byte, error) {
+	data := &userOperationJSON{
+		Sender:               u.Sender,
+		Nonce:                (*hexutil.Big)(bigOrZero(u.Nonce)),
+		Factory:              u.Factory,
+		CallData:             u.CallData,
+		CallGasLimit:         (*hexutil.Big)(bigOrZero(u.CallGasLimit)),
+		VerificationGasLimit: (*hexutil.Big)(bigOrZero(u.VerificationGasLimit)),
+		PreVerificationGas:   (*hexutil.Big)(bigOrZero(u.PreVerificationGas)),
+		MaxFeePerGas:         (*hexutil.Big)(bigOrZero(u.MaxFeePerGas)),
+		MaxPriorityFeePerGas: (*hexutil.Big)(bigOrZero(u.MaxPriorityFeePerGas)),
+		Paymaster:            u.Paymaster,
+		Signature:            u.Signature,
+	}
+	if data.CallData == nil {
+		data.CallData = hexutil.Bytes{}
+	}
+	if data.Signature == nil {
+		data.Signature = hexutil.Bytes{}
+	}
+	if u.Factory != nil {
+		data.FactoryData = u.FactoryData
+	}
+	if u.Paymaster != nil {
+		data.PaymasterVerificationGasLimit = (*hexutil.Big)(bigOrZero(u.PaymasterVerificationGasLimit))
+		data.PaymasterPostOpGasLimit = (*hexutil.Big)(bigOrZero(u.PaymasterPostOpGasLimit))
+		data.PaymasterData = u.PaymasterData
+	}
+
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *UserOperation) UnmarshalJSON(input []byte) error {
+	var data userOperationJSON
+	if err := json.Unmarshal(input, &data); err != nil {
+		return errors.Wrap(err, "invalid JSON")
+	}
+	if data.Nonce == nil {
+		return errors.New("nonce missing")
+	}
+
+	u.Sender = data.Sender
+	u.Nonce = data.Nonce.ToInt()
+	u.Factory = data.Factory
+	u.FactoryData = data.FactoryData
+	u.CallData = data.CallData
+	u.CallGasLimit = (*big.Int)(data.CallGasLimit)
+	u.VerificationGasLimit = (*big.Int)(data.VerificationGasLimit)
+	u.PreVerificationGas = (*big.Int)(data.PreVerificationGas)
+	u.MaxFeePerGas = (*big.Int)(data.MaxFeePerGas)
+	u.MaxPriorityFeePerGas = (*big.Int)(data.MaxPriorityFeePerGas)
+	u.Paymaster = data.Paymaster
+	u.PaymasterVerificationGasLimit = (*big.Int)(data.PaymasterVerificationGasLimit)
+	u.PaymasterPostOpGasLimit = (*big.Int)(data.PaymasterPostOpGasLimit)
+	u.PaymasterData = data.PaymasterData
+	u.Signature = data.Signature
+
+	return nil
+}
+
+// Pack returns the packed form of the user operation, as used on-chain.  It
+// returns an error if a gas value does not fit in its packed field.
+func (u *UserOperation) Pack() (*contracts.PackedUserOperation, error) {
+	accountGasLimits, err := packUint128s("verification gas limit", u.VerificationGasLimit, "call gas limit", u.CallGasLimit)
+	if err != nil {
+		return nil, err
+	}
+	gasFees, err := packUint128s("max priority fee per gas", u.MaxPriorityFeePerGas, "max fee per gas", u.MaxFeePerGas)
+	if err != nil {
+		return nil, err
+	}
+	packed := &contracts.PackedUserOperation{
+		Sender:             u.Sender,
+		Nonce:              bigOrZero(u.Nonce),
+		CallData:           u.CallData,
+		AccountGasLimits:   accountGasLimits,
+		PreVerificationGas: bigOrZero(u.PreVerificationGas),
+		GasFees:            gasFees,
+		InitCode:           []byte{},
+		PaymasterAndData:   []byte{},
+		Signature:          u.Signature,
+	}
+	if packed.CallData == nil {
+		packed.CallData = []byte{}
+	}
+	if packed.Signature == nil {
+		packed.Signature = []byte{}
+	}
+	if u.Factory != nil {
+		packed.InitCode = append(u.Factory.Bytes(), u.FactoryData...)
+	}
+	if u.Paymaster != nil {
+		paymasterGasLimits, err := packUint128s("paymaster verification gas limit", u.PaymasterVerificationGasLimit, "paymaster post-op gas limit", u.PaymasterPostOpGasLimit)
+		if err != nil {
+			return nil, err
+		}
+		packed.PaymasterAndData = append(packed.PaymasterAndData, u.Paymaster.Bytes()...)
+		packed.PaymasterAndData = append(packed.PaymasterAndData, paymasterGasLimits[:]...)
+		packed.PaymasterAndData = append(packed.PaymasterAndData, u.PaymasterData...)
+	}
+
+	return packed, nil
+}
+
+// Hash returns the hash of the user operation for the given entry point and chain.
+func (u *UserOperation) Hash(entryPoint common.Address, chainID *big.Int) (common.Hash, error) {
+	packed, err := u.Pack()
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	bytes32Type, err := abi.NewType("bytes32", "", nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	uint256Type, err := abi.NewType("uint256", "", nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	addressType, err := abi.NewType("address", "", nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	inner, err := abi.Arguments{
+		{Type: addressType},
+		{Type: uint256Type},
+		{Type: bytes32Type},
+		{Type: bytes32Type},
+		{Type: bytes32Type},
+		{Type: uint256Type},
+		{Type: bytes32Type},
+		{Type: bytes32Type},
+	}.Pack(
+		packed.Sender,
+		packed.Nonce,
+		crypto.Keccak256Hash(packed.InitCode),
+		crypto.Keccak256Hash(packed.CallData),
+		packed.AccountGasLimits,
+		packed.PreVerificationGas,
+		packed.GasFees,
+		crypto.Keccak256Hash(packed.PaymasterAndData),
+	)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "failed to encode user operation")
+	}
+
+	outer, err := abi.Arguments{
+		{Type: bytes32Type},
+		{Type: addressType},
+		{Type: uint256Type},
+	}.Pack(crypto.Keccak256Hash(inner), entryPoint, bigOrZero(chainID))
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "failed to encode user operation hash")
+	}
+
+	return crypto.Keccak256Hash(outer), nil
+}
+
+// Sign signs the user operation with the given key.  The signature is over
+// the Ethereum signed message of the user operation hash, as expected by the
+// reference SimpleAccount.
+func (u *UserOperation) Sign(entryPoint common.Address, chainID *big.Int, key *ecdsa.PrivateKey) error {
+	hash, err := u.Hash(entryPoint, chainID)
+	if err != nil {
+		return err
+	}
+	signature, err := crypto.Sign(accounts.TextHash(hash.Bytes()), key)
+	if err != nil {
+		return errors.Wrap(err, "failed to sign user operation")
+	}
+	signature[64] += 27
+	u.Signature = signature
+
+	return nil
+}
+
+// SetDummySignature sets a signature suitable for estimating gas.
+func (u *UserOperation) SetDummySignature() {
+	u.Signature = dummySignature
+}
+
+// ExecuteCallData returns the call data for an account to call execute(to, value, data).
+func ExecuteCallData(to common.Address, value *big.Int, data []byte) ([]byte, error) {
+	addressType, err := abi.NewType("address", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	uint256Type, err := abi.NewType("uint256", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	bytesType, err := abi.NewType("bytes", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	args, err := abi.Arguments{
+		{Type: addressType},
+		{Type: uint256Type},
+		{Type: bytesType},
+	}.Pack(to, bigOrZero(value), data)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]byte{}, executeSelector...), args...), nil
+}
+
+// packUint128s packs two 128-bit values in to a single 32-byte value.  It
+// returns an error if either value does not fit in 128 bits.
+func packUint128s(highName string, high *big.Int, lowName string, low *big.Int) ([32]byte, error) {
+	var res [32]byte
+	for _, value := range []struct {
+		name  string
+		value *big.Int
+	}{{highName, high}, {lowName, low}} {
+		if value.value != nil && (value.value.Sign() < 0 || value.value.BitLen() > 128) {
+			return res, errors.Errorf("%s %v does not fit in 128 bits", value.name, value.value)
+		}
+	}
+	copy(res[:16], common.LeftPadBytes(bigOrZero(high).Bytes(), 16))
+	copy(res[16:], common.LeftPadBytes(bigOrZero(low).Bytes(), 16))
+	return res, nil
+}
+
+func bigOrZero(input *big.Int) *big.Int {
+	if input == nil {
+		return big.NewInt(0)
+	}
+	return input
+}
@@ -0,0 +1,287 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package userop
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testUserOperation() *UserOperation {
+	return &UserOperation{
+		Sender:               common.HexToAddress("0x5FfC014343cd971B7eb70732021E26C35B744cc4"),
+		Nonce:                big.NewInt(3),
+		CallData:             hexutil.MustDecode("0xb61d27f6"),
+		CallGasLimit:         big.NewInt(100000),
+		VerificationGasLimit: big.NewInt(200000),
+		PreVerificationGas:   big.NewInt(50000),
+		MaxFeePerGas:         big.NewInt(30000000000),
+		MaxPriorityFeePerGas: big.NewInt(1500000000),
+	}
+}
+
+// word returns a value as a 32-byte big-endian word.
+func word(input []byte) []byte {
+	return common.LeftPadBytes(input, 32)
+}
+
+func TestPack(t *testing.T) {
+	op := testUserOperation()
+	factory := common.HexToAddress("0x2ab7150Bba7D5F181b3aF5623e52b15bB1054845")
+	op.Factory = &factory
+	op.FactoryData = []byte{0x01, 0x02}
+	paymaster := common.HexToAddress("0xd26114cd6EE289AccF82350c8d8487fedB8A0C07")
+	op.Paymaster = &paymaster
+	op.PaymasterVerificationGasLimit = big.NewInt(0x1234)
+	op.PaymasterPostOpGasLimit = big.NewInt(0x5678)
+	op.PaymasterData = []byte{0x03}
+
+	packed, err := op.Pack()
+	require.NoError(t, err)
+	require.Equal(t, append(factory.Bytes(), 0x01, 0x02), packed.InitCode)
+	require.Equal(t, common.LeftPadBytes(big.NewInt(200000).Bytes(), 16), packed.AccountGasLimits[:16])
+	require.Equal(t, common.LeftPadBytes(big.NewInt(100000).Bytes(), 16), packed.AccountGasLimits[16:])
+	require.Equal(t, common.LeftPadBytes(big.NewInt(1500000000).Bytes(), 16), packed.GasFees[:16])
+	require.Equal(t, common.LeftPadBytes(big.NewInt(30000000000).Bytes(), 16), packed.GasFees[16:])
+	require.Len(t, packed.PaymasterAndData, 20+16+16+1)
+	require.Equal(t, paymaster.Bytes(), packed.PaymasterAndData[:20])
+	require.Equal(t, byte(0x34), packed.PaymasterAndData[35])
+	require.Equal(t, byte(0x78), packed.PaymasterAndData[51])
+	require.Equal(t, byte(0x03), packed.PaymasterAndData[52])
+}
+
+func TestPackOverflow(t *testing.T) {
+	tooLarge := new(big.Int).Lsh(big.NewInt(1), 128)
+
+	op := testUserOperation()
+	op.CallGasLimit = tooLarge
+	_, err := op.Pack()
+	require.EqualError(t, err, "call gas limit 340282366920938463463374607431768211456 does not fit in 128 bits")
+
+	op = testUserOperation()
+	op.MaxFeePerGas = big.NewInt(-1)
+	_, err = op.Pack()
+	require.EqualError(t, err, "max fee per gas -1 does not fit in 128 bits")
+
+	op = testUserOperation()
+	paymaster := common.HexToAddress("0xd26114cd6EE289AccF82350c8d8487fedB8A0C07")
+	op.Paymaster = &paymaster
+	op.PaymasterPostOpGasLimit = tooLarge
+	_, err = op.Hash(EntryPointV07, big.NewInt(1))
+	require.EqualError(t, err, "paymaster post-op gas limit 340282366920938463463374607431768211456 does not fit in 128 bits")
+
+	// The largest 128-bit value fits.
+	op = testUserOperation()
+	op.VerificationGasLimit = new(big.Int).Sub(tooLarge, big.NewInt(1))
+	_, err = op.Pack()
+	require.NoError(t, err)
+}
+
+func TestHash(t *testing.T) {
+	op := testUserOperation()
+	entryPoint := EntryPointV07
+	chainID := big.NewInt(11155111)
+
+	// Build the hash by hand, as all encoded values are static.
+	packed, err := op.Pack()
+	require.NoError(t, err)
+	inner := make([]byte, 0)
+	inner = append(inner, word(op.Sender.Bytes())...)
+	inner = append(inner, word(op.Nonce.Bytes())...)
+	inner = append(inner, crypto.Keccak256(packed.InitCode)...)
+	inner = append(inner, crypto.Keccak256(packed.CallData)...)
+	inner = append(inner, packed.AccountGasLimits[:]...)
+	inner = append(inner, word(op.PreVerificationGas.Bytes())...)
+	inner = append(inner, packed.GasFees[:]...)
+	inner = append(inner, crypto.Keccak256(packed.PaymasterAndData)...)
+	outer := make([]byte, 0)
+	outer = append(outer, crypto.Keccak256(inner)...)
+	outer = append(outer, word(entryPoint.Bytes())...)
+	outer = append(outer, word(chainID.Bytes())...)
+	expected := crypto.Keccak256Hash(outer)
+
+	hash, err := op.Hash(entryPoint, chainID)
+	require.NoError(t, err)
+	require.Equal(t, expected, hash)
+
+	// Signature is not part of the hash.
+	op.SetDummySignature()
+	hash2, err := op.Hash(entryPoint, chainID)
+	require.NoError(t, err)
+	require.Equal(t, hash, hash2)
+
+	// Chain is part of the hash.
+	hash3, err := op.Hash(entryPoint, big.NewInt(1))
+	require.NoError(t, err)
+	require.NotEqual(t, hash, hash3)
+}
+
+func TestSign(t *testing.T) {
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	require.NoError(t, err)
+	op := testUserOperation()
+	chainID := big.NewInt(1)
+	require.NoError(t, op.Sign(EntryPointV07, chainID, key))
+	require.Len(t, op.Signature, 65)
+
+	hash, err := op.Hash(EntryPointV07, chainID)
+	require.NoError(t, err)
+	signature := append([]byte{}, op.Signature...)
+	signature[64] -= 27
+	pubKey, err := crypto.SigToPub(accounts.TextHash(hash.Bytes()), signature)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), crypto.PubkeyToAddress(*pubKey))
+}
+
+func TestExecuteCallData(t *testing.T) {
+	data, err := ExecuteCallData(common.HexToAddress("0x2ab7150Bba7D5F181b3aF5623e52b15bB1054845"), big.NewInt(1), []byte{0xaa})
+	require.NoError(t, err)
+	require.Equal(t, hexutil.MustDecode("0xb61d27f6"), data[:4])
+	require.Len(t, data, 4+32*5)
+	assert.Equal(t, byte(0x01), data[4+63])
+	assert.Equal(t, byte(0xaa), data[4+32*4])
+}
+
+func TestJSON(t *testing.T) {
+	op := testUserOperation()
+	op.SetDummySignature()
+	data, err := json.Marshal(op)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "factory")
+	require.NotContains(t, string(data), "paymaster")
+	require.Contains(t, string(data), `"nonce":"0x3"`)
+
+	op2 := &UserOperation{}
+	require.NoError(t, json.Unmarshal(data, op2))
+	require.Equal(t, op, op2)
+
+	require.EqualError(t, json.Unmarshal([]byte(`{"sender":"0x5FfC014343cd971B7eb70732021E26C35B744cc4"}`), op2), "nonce missing")
+}
+
+// testBundler is a stand-in bundler.
+type testBundler struct {
+	chainID *big.Int
+	sent    map[common.Hash]*UserOperation
+}
+
+func (b *testBundler) SupportedEntryPoints() []common.Address {
+	return []common.Address{EntryPointV07}
+}
+
+func (b *testBundler) EstimateUserOperationGas(op *UserOperation, entryPoint common.Address) (map[string]*hexutil.Big, error) {
+	if entryPoint != EntryPointV07 {
+		return nil, errors.New("unsupported entry point")
+	}
+	if len(op.Signature) != 65 {
+		return nil, errors.New("invalid signature length")
+	}
+	return map[string]*hexutil.Big{
+		"preVerificationGas":   (*hexutil.Big)(big.NewInt(45000)),
+		"verificationGasLimit": (*hexutil.Big)(big.NewInt(150000)),
+		"callGasLimit":         (*hexutil.Big)(big.NewInt(80000)),
+	}, nil
+}
+
+func (b *testBundler) SendUserOperation(op *UserOperation, entryPoint common.Address) (common.Hash, error) {
+	hash, err := op.Hash(entryPoint, b.chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	b.sent[hash] = op
+	return hash, nil
+}
+
+func (b *testBundler) GetUserOperationReceipt(hash common.Hash) (map[string]interface{}, error) {
+	op, exists := b.sent[hash]
+	if !exists {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"userOpHash":    hash,
+		"sender":        op.Sender,
+		"nonce":         (*hexutil.Big)(op.Nonce),
+		"success":       true,
+		"actualGasCost": (*hexutil.Big)(big.NewInt(1000)),
+		"actualGasUsed": (*hexutil.Big)(big.NewInt(100)),
+		"receipt": map[string]interface{}{
+			"transactionHash": common.HexToHash("0x01"),
+			"blockNumber":     hexutil.Uint64(12),
+		},
+	}, nil
+}
+
+func TestBundler(t *testing.T) {
+	ctx := context.Background()
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", &testBundler{
+		chainID: big.NewInt(1),
+		sent:    make(map[common.Hash]*UserOperation),
+	}))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	_, err := NewBundler(ctx, "", EntryPointV07)
+	require.EqualError(t, err, "no bundler URL supplied")
+
+	bundler, err := NewBundler(ctx, httpServer.URL, EntryPointV07)
+	require.NoError(t, err)
+	defer bundler.Close()
+
+	entryPoints, err := bundler.SupportedEntryPoints(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{EntryPointV07}, entryPoints)
+
+	op := testUserOperation()
+	_, err = bundler.EstimateGas(ctx, op)
+	require.EqualError(t, err, "failed to estimate user operation gas: invalid signature length")
+	op.SetDummySignature()
+	estimate, err := bundler.EstimateGas(ctx, op)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(80000), estimate.CallGasLimit)
+	require.Nil(t, estimate.PaymasterVerificationGasLimit)
+
+	hash, err := op.Hash(EntryPointV07, big.NewInt(1))
+	require.NoError(t, err)
+	receipt, err := bundler.Receipt(ctx, hash)
+	require.NoError(t, err)
+	require.Nil(t, receipt)
+
+	sentHash, err := bundler.Send(ctx, op)
+	require.NoError(t, err)
+	require.Equal(t, hash, sentHash)
+
+	receipt, err = bundler.WaitForReceipt(ctx, hash, time.Millisecond, time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, receipt)
+	require.True(t, receipt.Success)
+	require.Equal(t, op.Sender, receipt.Sender)
+	require.Equal(t, uint64(12), receipt.BlockNumber)
+
+	receipt, err = bundler.WaitForReceipt(ctx, common.HexToHash("0x02"), time.Millisecond, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Nil(t, receipt)
+}
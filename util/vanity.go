@@ -0,0 +1,210 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// VanityPattern is a pattern that a vanity address must match.
+type VanityPattern struct {
+	prefix        string
+	suffix        string
+	caseSensitive bool
+	lowerPrefix   []byte
+	lowerSuffix   []byte
+}
+
+// NewVanityPattern creates a pattern for addresses that start with the prefix
+// and end with the suffix.  If the pattern is case-sensitive then the letters
+// must match those of the checksummed address.
+func NewVanityPattern(prefix string, suffix string, caseSensitive bool) (*VanityPattern, error) {
+	prefix = strings.TrimPrefix(prefix, "0x")
+	if prefix == "" && suffix == "" {
+		return nil, errors.New("prefix or suffix is required")
+	}
+	if len(prefix)+len(suffix) > 2*common.AddressLength {
+		return nil, errors.New("prefix and suffix are too long")
+	}
+	for _, c := range prefix + suffix {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return nil, errors.New("prefix and suffix must be hex characters")
+		}
+	}
+	if !caseSensitive {
+		prefix = strings.ToLower(prefix)
+		suffix = strings.ToLower(suffix)
+	}
+
+	return &VanityPattern{
+		prefix:        prefix,
+		suffix:        suffix,
+		caseSensitive: caseSensitive,
+		lowerPrefix:   []byte(strings.ToLower(prefix)),
+		lowerSuffix:   []byte(strings.ToLower(suffix)),
+	}, nil
+}
+
+// Matches returns true if the address matches the pattern.
+func (p *VanityPattern) Matches(address common.Address) bool {
+	var buf [2 * common.AddressLength]byte
+	hex.Encode(buf[:], address[:])
+	if !bytes.HasPrefix(buf[:], p.lowerPrefix) || !bytes.HasSuffix(buf[:], p.lowerSuffix) {
+		return false
+	}
+	if !p.caseSensitive {
+		return true
+	}
+	// Only calculate the checksum once the characters themselves match.
+	checksummed := address.Hex()[2:]
+
+	return strings.HasPrefix(checksummed, p.prefix) && strings.HasSuffix(checksummed, p.suffix)
+}
+
+// Difficulty returns the expected number of attempts to find a matching address.
+func (p *VanityPattern) Difficulty() float64 {
+	difficulty := math.Pow(16, float64(len(p.prefix)+len(p.suffix)))
+	if p.caseSensitive {
+		// Each letter in a checksummed address is equally likely to be upper or lower case.
+		for _, c := range p.prefix + p.suffix {
+			if c > '9' {
+				difficulty *= 2
+			}
+		}
+	}
+
+	return difficulty
+}
+
+// MineVanityKey generates private keys with the given number of workers until
+// the address of a key matches the pattern.  The number of attempts made is
+// added to attempts as mining progresses.
+func MineVanityKey(ctx context.Context, pattern *VanityPattern, workers int, attempts *atomic.Uint64) (*ecdsa.PrivateKey, error) {
+	var res *ecdsa.PrivateKey
+	err := mineVanity(ctx, workers, attempts, func(claim func() bool) (func() bool, error) {
+		return func() bool {
+			key, err := crypto.GenerateKey()
+			if err != nil || !pattern.Matches(crypto.PubkeyToAddress(key.PublicKey)) {
+				return false
+			}
+			if claim() {
+				res = key
+			}
+			return true
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// MineVanitySalt searches for a CREATE2 salt with the given number of workers
+// until the address of the contract deployed by the factory with the init code
+// hash matches the pattern.  The number of attempts made is added to attempts
+// as mining progresses.
+func MineVanitySalt(ctx context.Context, pattern *VanityPattern, factory common.Address, initCodeHash common.Hash, workers int, attempts *atomic.Uint64) ([32]byte, common.Address, error) {
+	var salt [32]byte
+	var address common.Address
+	err := mineVanity(ctx, workers, attempts, func(claim func() bool) (func() bool, error) {
+		// Each worker starts from its own random salt and increments it.
+		var candidate [32]byte
+		if _, err := rand.Read(candidate[:]); err != nil {
+			return nil, errors.Wrap(err, "failed to generate salt")
+		}
+		return func() bool {
+			for i := len(candidate) - 1; i >= 0; i-- {
+				candidate[i]++
+				if candidate[i] != 0 {
+					break
+				}
+			}
+			candidateAddress := crypto.CreateAddress2(factory, candidate, initCodeHash[:])
+			if !pattern.Matches(candidateAddress) {
+				return false
+			}
+			if claim() {
+				salt = candidate
+				address = candidateAddress
+			}
+			return true
+		}, nil
+	})
+
+	return salt, address, err
+}
+
+// vanityBatch is the number of attempts a worker makes between checks for cancellation.
+const vanityBatch = 256
+
+// mineVanity runs attempts created by the supplied generator on each worker
+// until one of them succeeds or the context is done.  An attempt that finds a
+// match must call claim, and only record its result if claim returns true.
+func mineVanity(ctx context.Context, workers int, attempts *atomic.Uint64, generator func(claim func() bool) (func() bool, error)) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var found atomic.Bool
+	claim := func() bool {
+		return found.CompareAndSwap(false, true)
+	}
+	attemptFuncs := make([]func() bool, workers)
+	for i := range attemptFuncs {
+		var err error
+		attemptFuncs[i], err = generator(claim)
+		if err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, attempt := range attemptFuncs {
+		attempt := attempt
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil && !found.Load() {
+				made := uint64(0)
+				for ; made < vanityBatch; made++ {
+					if attempt() {
+						made++
+						break
+					}
+				}
+				attempts.Add(made)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !found.Load() {
+		return ctx.Err()
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVanityPattern(t *testing.T) {
+	// Checksummed form is 0x5FfC014343cd971B7eb70732021E26C35B744cc4.
+	address := common.HexToAddress("0x5ffc014343cd971b7eb70732021e26c35b744cc4")
+
+	tests := []struct {
+		name          string
+		prefix        string
+		suffix        string
+		caseSensitive bool
+		matches       bool
+		difficulty    float64
+		err           string
+	}{
+		{
+			name: "Empty",
+			err:  "prefix or suffix is required",
+		},
+		{
+			name:   "TooLong",
+			prefix: strings.Repeat("0", 21),
+			suffix: strings.Repeat("0", 20),
+			err:    "prefix and suffix are too long",
+		},
+		{
+			name:   "Invalid",
+			prefix: "0xfg",
+			err:    "prefix and suffix must be hex characters",
+		},
+		{
+			name:       "Prefix",
+			prefix:     "0x5FFC",
+			matches:    true,
+			difficulty: 65536,
+		},
+		{
+			name:       "Suffix",
+			suffix:     "4CC4",
+			matches:    true,
+			difficulty: 65536,
+		},
+		{
+			name:       "PrefixAndSuffix",
+			prefix:     "5ffc",
+			suffix:     "4cc5",
+			difficulty: 16777216 * 256,
+		},
+		{
+			name:          "CaseSensitive",
+			prefix:        "5Ff",
+			suffix:        "4cc4",
+			caseSensitive: true,
+			matches:       true,
+			difficulty:    16777216 * 256,
+		},
+		{
+			name:          "CaseSensitiveMismatch",
+			prefix:        "5ff",
+			caseSensitive: true,
+			difficulty:    4096 * 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pattern, err := NewVanityPattern(test.prefix, test.suffix, test.caseSensitive)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.matches, pattern.Matches(address))
+				require.Equal(t, test.difficulty, pattern.Difficulty())
+			}
+		})
+	}
+}
+
+func TestMineVanityKey(t *testing.T) {
+	pattern, err := NewVanityPattern("a", "", false)
+	require.NoError(t, err)
+
+	var attempts atomic.Uint64
+	key, err := MineVanityKey(context.Background(), pattern, 2, &attempts)
+	require.NoError(t, err)
+	require.True(t, pattern.Matches(crypto.PubkeyToAddress(key.PublicKey)))
+	require.NotZero(t, attempts.Load())
+}
+
+func TestMineVanitySalt(t *testing.T) {
+	pattern, err := NewVanityPattern("00", "", false)
+	require.NoError(t, err)
+	initCode := []byte{0x00}
+
+	var attempts atomic.Uint64
+	salt, address, err := MineVanitySalt(context.Background(), pattern, DeterministicDeploymentProxy, crypto.Keccak256Hash(initCode), 2, &attempts)
+	require.NoError(t, err)
+	require.True(t, pattern.Matches(address))
+	require.Equal(t, Create2Address(DeterministicDeploymentProxy, salt, initCode), address)
+}
+
+func TestMineVanityCancelled(t *testing.T) {
+	pattern, err := NewVanityPattern(strings.Repeat("0", 40), "", false)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var attempts atomic.Uint64
+	_, _, err = MineVanitySalt(ctx, pattern, DeterministicDeploymentProxy, common.Hash{}, 2, &attempts)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,447 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// maxWatchRetry is the longest that a watcher waits before reconnecting.
+const maxWatchRetry = 30 * time.Second
+
+// Watcher streams notifications from a node until its context is done.  It
+// uses subscriptions if the connection supports them, and otherwise polls
+// filters.  If the connection fails it reconnects, with increasing delays,
+// and backfills blocks and logs that were missed while disconnected.
+type Watcher struct {
+	dial         func(ctx context.Context) (*rpc.Client, error)
+	pollInterval time.Duration
+	retry        time.Duration
+	onError      func(error)
+}
+
+// NewWatcher creates a watcher that connects with the supplied dial function
+// and, if the connection does not support subscriptions, polls at the given
+// interval.  Errors that cause the watcher to reconnect are passed to onError.
+func NewWatcher(dial func(ctx context.Context) (*rpc.Client, error), pollInterval time.Duration, onError func(error)) *Watcher {
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	return &Watcher{
+		dial:         dial,
+		pollInterval: pollInterval,
+		retry:        time.Second,
+		onError:      onError,
+	}
+}
+
+// watchStream is a stream of notifications, obtained with a subscription or
+// by polling a filter.
+type watchStream struct {
+	subscription []interface{}
+	filterMethod string
+	filterArgs   []interface{}
+	// notification handles a notification from the subscription.
+	notification func(ctx context.Context, client *rpc.Client, msg json.RawMessage) error
+	// change handles a change from the filter.
+	change func(ctx context.Context, client *rpc.Client, msg json.RawMessage) error
+	// backfill, if present, handles anything missed since the last
+	// notification or change.  It is called after the subscription or filter
+	// is created, so the handlers ignore anything that it has handled.
+	backfill func(ctx context.Context, client *rpc.Client) error
+}
+
+// WatchLogs streams new logs matching the query to the handler.
+func (w *Watcher) WatchLogs(ctx context.Context, query ethereum.FilterQuery, handler func(*types.Log) error) error {
+	filter := logFilterArg(query)
+
+	// The position of the last log handled, from which to backfill.
+	seen := false
+	lastBlock := uint64(0)
+	lastIndex := uint(0)
+	// Logs up to and including this block have been handled by backfilling.
+	backfilled := uint64(0)
+
+	deliver := func(log *types.Log) error {
+		if !log.Removed {
+			seen = true
+			lastBlock = log.BlockNumber
+			lastIndex = log.Index
+		}
+		return handled(handler(log))
+	}
+	handle := func(_ context.Context, _ *rpc.Client, msg json.RawMessage) error {
+		log := new(types.Log)
+		if err := json.Unmarshal(msg, log); err != nil {
+			return handled(errors.Wrap(err, "invalid log"))
+		}
+		if log.BlockNumber <= backfilled && !log.Removed {
+			return nil
+		}
+		return deliver(log)
+	}
+	backfill := func(ctx context.Context, client *rpc.Client) error {
+		if !seen {
+			return nil
+		}
+		var head hexutil.Uint64
+		if err := client.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+			return errors.Wrap(err, "failed to obtain block number")
+		}
+		if uint64(head) < lastBlock {
+			return nil
+		}
+		rangeFilter := logFilterArg(query)
+		rangeFilter["fromBlock"] = hexutil.Uint64(lastBlock)
+		rangeFilter["toBlock"] = head
+		var logs []*types.Log
+		if err := client.CallContext(ctx, &logs, "eth_getLogs", rangeFilter); err != nil {
+			return errors.Wrap(err, "failed to obtain missed logs")
+		}
+		from, index := lastBlock, lastIndex
+		for _, log := range logs {
+			if log.BlockNumber == from && log.Index <= index {
+				continue
+			}
+			if err := deliver(log); err != nil {
+				return err
+			}
+		}
+		backfilled = uint64(head)
+
+		return nil
+	}
+
+	return w.watch(ctx, &watchStream{
+		subscription: []interface{}{"logs", filter},
+		filterMethod: "eth_newFilter",
+		filterArgs:   []interface{}{filter},
+		notification: handle,
+		change:       handle,
+		backfill:     backfill,
+	})
+}
+
+// WatchPendingTransactions streams the hashes of new pending transactions to
+// the handler, along with the watcher's current connection.  Pending
+// transactions missed while disconnected are not backfilled.
+func (w *Watcher) WatchPendingTransactions(ctx context.Context, handler func(context.Context, *ethclient.Client, common.Hash) error) error {
+	handle := func(ctx context.Context, client *rpc.Client, msg json.RawMessage) error {
+		var hash common.Hash
+		if err := json.Unmarshal(msg, &hash); err != nil {
+			return handled(errors.Wrap(err, "invalid transaction hash"))
+		}
+		return handled(handler(ctx, ethclient.NewClient(client), hash))
+	}
+
+	return w.watch(ctx, &watchStream{
+		subscription: []interface{}{"newPendingTransactions"},
+		filterMethod: "eth_newPendingTransactionFilter",
+		notification: handle,
+		change:       handle,
+	})
+}
+
+// WatchBlocks streams the hashes of new blocks to the handler, along with the
+// watcher's current connection.  If the handler returns a WatchRetryError the
+// watcher reconnects and backfills from the block.
+func (w *Watcher) WatchBlocks(ctx context.Context, handler func(context.Context, *ethclient.Client, common.Hash) error) error {
+	// The number of the last block handled, from which to backfill.
+	last := uint64(0)
+	// Blocks up to and including this number have been handled by backfilling.
+	backfilled := uint64(0)
+
+	deliver := func(ctx context.Context, client *rpc.Client, number uint64, hash common.Hash) error {
+		if err := handler(ctx, ethclient.NewClient(client), hash); err != nil {
+			if last == 0 && number > 0 {
+				// Nothing handled yet, so backfill from this block.
+				last = number - 1
+			}
+			return handled(err)
+		}
+		if number > last {
+			last = number
+		}
+		return nil
+	}
+	// Subscriptions notify headers, whereas filters notify hashes.
+	handleHeader := func(ctx context.Context, client *rpc.Client, msg json.RawMessage) error {
+		var header watchHeader
+		if err := json.Unmarshal(msg, &header); err != nil {
+			return handled(errors.Wrap(err, "invalid header"))
+		}
+		if uint64(header.Number) <= backfilled {
+			return nil
+		}
+		return deliver(ctx, client, uint64(header.Number), header.Hash)
+	}
+	handleHash := func(ctx context.Context, client *rpc.Client, msg json.RawMessage) error {
+		var hash common.Hash
+		if err := json.Unmarshal(msg, &hash); err != nil {
+			return handled(errors.Wrap(err, "invalid block hash"))
+		}
+		var header *watchHeader
+		if err := client.CallContext(ctx, &header, "eth_getBlockByHash", hash, false); err != nil {
+			return errors.Wrap(err, "failed to obtain block")
+		}
+		if header == nil {
+			// Block has been reorganised away since the notification.
+			return handled(handler(ctx, ethclient.NewClient(client), hash))
+		}
+		if uint64(header.Number) <= backfilled {
+			return nil
+		}
+		return deliver(ctx, client, uint64(header.Number), hash)
+	}
+	backfill := func(ctx context.Context, client *rpc.Client) error {
+		if last == 0 {
+			return nil
+		}
+		var head hexutil.Uint64
+		if err := client.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+			return errors.Wrap(err, "failed to obtain block number")
+		}
+		for number := last + 1; number <= uint64(head); number++ {
+			var header *watchHeader
+			if err := client.CallContext(ctx, &header, "eth_getBlockByNumber", hexutil.Uint64(number), false); err != nil {
+				return errors.Wrap(err, "failed to obtain missed block")
+			}
+			if header == nil {
+				return errors.Errorf("missed block %d not found", number)
+			}
+			if err := deliver(ctx, client, number, header.Hash); err != nil {
+				return err
+			}
+		}
+		if uint64(head) > backfilled {
+			backfilled = uint64(head)
+		}
+
+		return nil
+	}
+
+	return w.watch(ctx, &watchStream{
+		subscription: []interface{}{"newHeads"},
+		filterMethod: "eth_newBlockFilter",
+		notification: handleHeader,
+		change:       handleHash,
+		backfill:     backfill,
+	})
+}
+
+// watchHeader is the part of a block header used by the watcher.
+type watchHeader struct {
+	Hash   common.Hash    `json:"hash"`
+	Number hexutil.Uint64 `json:"number"`
+}
+
+// watch runs a subscription or filter until the context is done or a handler
+// returns an error, reconnecting as required.
+func (w *Watcher) watch(ctx context.Context, stream *watchStream) error {
+	retry := w.retry
+	// Set if the node rejects the subscription, in which case its filter is
+	// polled for the rest of the watch.
+	rejected := false
+	for {
+		client, err := w.dial(ctx)
+		if err == nil {
+			started := time.Now()
+			if client.SupportsSubscriptions() && !rejected {
+				err = w.subscribe(ctx, client, stream)
+				var rejection *subscriptionRejectedError
+				if errors.As(err, &rejection) {
+					rejected = true
+					err = w.poll(ctx, client, stream)
+				}
+			} else {
+				err = w.poll(ctx, client, stream)
+			}
+			client.Close()
+			if time.Since(started) > maxWatchRetry {
+				// Connection was good for a while, so start again with short retries.
+				retry = w.retry
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		var handlerErr *watchHandlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+		w.onError(err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retry):
+		}
+		retry *= 2
+		if retry > maxWatchRetry {
+			retry = maxWatchRetry
+		}
+	}
+}
+
+// watchHandlerError is an error returned by a handler, which stops the watcher.
+type watchHandlerError struct {
+	err error
+}
+
+func (e *watchHandlerError) Error() string {
+	return e.err.Error()
+}
+
+// WatchRetryError is returned by a handler that could not handle a
+// notification because of a failed request, so that the watcher reconnects
+// rather than stopping.
+type WatchRetryError struct {
+	Err error
+}
+
+func (e *WatchRetryError) Error() string {
+	return e.Err.Error()
+}
+
+// handled returns an error from a handler as a watchHandlerError, unless the
+// handler asked for a retry.
+func handled(err error) error {
+	if err == nil {
+		return nil
+	}
+	var retryErr *WatchRetryError
+	if errors.As(err, &retryErr) {
+		return retryErr
+	}
+
+	return &watchHandlerError{err: err}
+}
+
+// subscriptionRejectedError is an error returned when the node rejects a
+// subscription, for example because it does not support it.
+type subscriptionRejectedError struct {
+	err error
+}
+
+func (e *subscriptionRejectedError) Error() string {
+	return fmt.Sprintf("subscription rejected: %v", e.err)
+}
+
+// subscribe handles notifications from a subscription until it fails.
+func (w *Watcher) subscribe(ctx context.Context, client *rpc.Client, stream *watchStream) error {
+	ch := make(chan json.RawMessage)
+	sub, err := client.EthSubscribe(ctx, ch, stream.subscription...)
+	if err != nil {
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) {
+			return &subscriptionRejectedError{err: err}
+		}
+		return errors.Wrap(err, "failed to subscribe")
+	}
+	defer sub.Unsubscribe()
+
+	if stream.backfill != nil {
+		if err := stream.backfill(ctx, client); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return errors.Wrap(err, "subscription failed")
+		case msg := <-ch:
+			if err := stream.notification(ctx, client, msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// poll handles changes to a filter until polling fails.
+func (w *Watcher) poll(ctx context.Context, client *rpc.Client, stream *watchStream) error {
+	var id string
+	if err := client.CallContext(ctx, &id, stream.filterMethod, stream.filterArgs...); err != nil {
+		return errors.Wrap(err, "failed to create filter")
+	}
+	defer func() {
+		// Best effort; the node will expire the filter in any case.
+		uninstallCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		var uninstalled bool
+		_ = client.CallContext(uninstallCtx, &uninstalled, "eth_uninstallFilter", id)
+	}()
+
+	if stream.backfill != nil {
+		if err := stream.backfill(ctx, client); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		var changes []json.RawMessage
+		if err := client.CallContext(ctx, &changes, "eth_getFilterChanges", id); err != nil {
+			if strings.Contains(strings.ToLower(err.Error()), "filter not found") {
+				// Filter has expired, so create it again.
+				if err := client.CallContext(ctx, &id, stream.filterMethod, stream.filterArgs...); err != nil {
+					return errors.Wrap(err, "failed to recreate filter")
+				}
+				continue
+			}
+			return errors.Wrap(err, "failed to obtain filter changes")
+		}
+		for _, change := range changes {
+			if err := stream.change(ctx, client, change); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// logFilterArg returns the JSON-RPC representation of a log filter for new logs.
+func logFilterArg(query ethereum.FilterQuery) map[string]interface{} {
+	arg := map[string]interface{}{
+		"topics": query.Topics,
+	}
+	if len(query.Addresses) > 0 {
+		arg["address"] = query.Addresses
+	}
+
+	return arg
+}
@@ -0,0 +1,328 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// watchService provides pending transaction hashes over subscriptions and filters.
+type watchService struct {
+	mu      sync.Mutex
+	next    int
+	filters map[string]int
+	// rejectSubscriptions rejects subscriptions, as for a node that does not
+	// support them.
+	rejectSubscriptions bool
+}
+
+func (s *watchService) hash(i int) common.Hash {
+	return common.BigToHash(big.NewInt(int64(i + 1)))
+}
+
+// NewPendingTransactions sends three hashes on each subscription.
+func (s *watchService) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	if s.rejectSubscriptions {
+		return nil, errors.New("subscription not supported")
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := 0; i < 3; i++ {
+			s.mu.Lock()
+			hash := s.hash(s.next)
+			s.next++
+			s.mu.Unlock()
+			if err := notifier.Notify(sub.ID, hash); err != nil {
+				return
+			}
+		}
+	}()
+
+	return sub, nil
+}
+
+func (s *watchService) NewPendingTransactionFilter() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := fmt.Sprintf("0x%x", len(s.filters)+1)
+	s.filters[id] = 0
+
+	return id
+}
+
+// GetFilterChanges returns two hashes per call, and expires the first filter after its first call.
+func (s *watchService) GetFilterChanges(id string) ([]common.Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls, exists := s.filters[id]
+	if !exists {
+		return nil, errors.New("filter not found")
+	}
+	if id == "0x1" && calls == 1 {
+		delete(s.filters, id)
+		return nil, errors.New("filter not found")
+	}
+	s.filters[id]++
+	hashes := []common.Hash{s.hash(s.next), s.hash(s.next + 1)}
+	s.next += 2
+
+	return hashes, nil
+}
+
+func (s *watchService) UninstallFilter(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.filters[id]
+	delete(s.filters, id)
+
+	return exists
+}
+
+func newWatchServer(t *testing.T) (*rpc.Server, *watchService) {
+	t.Helper()
+	service := &watchService{filters: make(map[string]int)}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+
+	return server, service
+}
+
+// collectHashes watches pending transactions until count hashes are received.
+func collectHashes(t *testing.T, watcher *Watcher, count int) []common.Hash {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	hashes := make([]common.Hash, 0)
+	done := errors.New("done")
+	err := watcher.WatchPendingTransactions(ctx, func(_ context.Context, _ *ethclient.Client, hash common.Hash) error {
+		hashes = append(hashes, hash)
+		if len(hashes) == count {
+			return done
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, done)
+
+	return hashes
+}
+
+func TestWatchSubscription(t *testing.T) {
+	server, service := newWatchServer(t)
+	defer server.Stop()
+
+	reconnects := 0
+	watcher := NewWatcher(func(context.Context) (*rpc.Client, error) {
+		return rpc.DialInProc(server), nil
+	}, time.Millisecond, func(error) { reconnects++ })
+	watcher.retry = time.Millisecond
+
+	hashes := collectHashes(t, watcher, 3)
+	for i, hash := range hashes {
+		require.Equal(t, service.hash(i), hash)
+	}
+	require.Zero(t, reconnects)
+}
+
+func TestWatchPolling(t *testing.T) {
+	server, service := newWatchServer(t)
+	defer server.Stop()
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	var errs []error
+	watcher := NewWatcher(func(ctx context.Context) (*rpc.Client, error) {
+		return rpc.DialContext(ctx, httpServer.URL)
+	}, time.Millisecond, func(err error) { errs = append(errs, err) })
+	watcher.retry = time.Millisecond
+
+	// The first filter expires after one poll, and is recreated transparently.
+	hashes := collectHashes(t, watcher, 6)
+	for i, hash := range hashes {
+		require.Equal(t, service.hash(i), hash)
+	}
+	require.Empty(t, errs)
+}
+
+func TestWatchReconnect(t *testing.T) {
+	server, _ := newWatchServer(t)
+	defer server.Stop()
+
+	var errs []error
+	attempts := 0
+	watcher := NewWatcher(func(context.Context) (*rpc.Client, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return rpc.DialInProc(server), nil
+	}, time.Millisecond, func(err error) { errs = append(errs, err) })
+	watcher.retry = time.Millisecond
+
+	collectHashes(t, watcher, 2)
+	require.Equal(t, 3, attempts)
+	require.Len(t, errs, 2)
+	require.EqualError(t, errs[0], "connection refused")
+}
+
+func TestWatchSubscriptionRejected(t *testing.T) {
+	server, service := newWatchServer(t)
+	defer server.Stop()
+	service.rejectSubscriptions = true
+
+	var errs []error
+	dials := 0
+	watcher := NewWatcher(func(context.Context) (*rpc.Client, error) {
+		dials++
+		return rpc.DialInProc(server), nil
+	}, time.Millisecond, func(err error) { errs = append(errs, err) })
+	watcher.retry = time.Millisecond
+
+	// The watcher falls back to polling on the same connection.
+	hashes := collectHashes(t, watcher, 4)
+	for i, hash := range hashes {
+		require.Equal(t, service.hash(i), hash)
+	}
+	require.Equal(t, 1, dials)
+	require.Empty(t, errs)
+}
+
+// blockWatchService provides blocks over subscriptions.  The first
+// subscription notifies blocks 1 and 2, after which the chain advances to
+// block 5 and later subscriptions notify block 6.
+type blockWatchService struct {
+	mu            sync.Mutex
+	subscriptions int
+}
+
+func (s *blockWatchService) header(number uint64) map[string]interface{} {
+	return map[string]interface{}{
+		"hash":   common.BigToHash(new(big.Int).SetUint64(number)),
+		"number": hexutil.Uint64(number),
+	}
+}
+
+func (s *blockWatchService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	s.mu.Lock()
+	s.subscriptions++
+	numbers := []uint64{6}
+	if s.subscriptions == 1 {
+		numbers = []uint64{1, 2}
+	}
+	s.mu.Unlock()
+	sub := notifier.CreateSubscription()
+	go func() {
+		for _, number := range numbers {
+			if err := notifier.Notify(sub.ID, s.header(number)); err != nil {
+				return
+			}
+		}
+	}()
+
+	return sub, nil
+}
+
+func (s *blockWatchService) BlockNumber() hexutil.Uint64 {
+	return 5
+}
+
+func (s *blockWatchService) GetBlockByNumber(number hexutil.Uint64, _ bool) map[string]interface{} {
+	return s.header(uint64(number))
+}
+
+func TestWatchBlocksBackfill(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	require.NoError(t, server.RegisterName("eth", &blockWatchService{}))
+
+	var client *rpc.Client
+	watcher := NewWatcher(func(context.Context) (*rpc.Client, error) {
+		client = rpc.DialInProc(server)
+		return client, nil
+	}, time.Millisecond, nil)
+	watcher.retry = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	numbers := make([]uint64, 0)
+	done := errors.New("done")
+	err := watcher.WatchBlocks(ctx, func(_ context.Context, _ *ethclient.Client, hash common.Hash) error {
+		numbers = append(numbers, hash.Big().Uint64())
+		switch len(numbers) {
+		case 2:
+			// Drop the connection, so that blocks 3 to 5 are missed.
+			go client.Close()
+		case 6:
+			return done
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, done)
+	require.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, numbers)
+}
+
+func TestWatchBlocksRetry(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	require.NoError(t, server.RegisterName("eth", &blockWatchService{}))
+
+	var errs []error
+	watcher := NewWatcher(func(context.Context) (*rpc.Client, error) {
+		return rpc.DialInProc(server), nil
+	}, time.Millisecond, func(err error) { errs = append(errs, err) })
+	watcher.retry = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	numbers := make([]uint64, 0)
+	failed := false
+	done := errors.New("done")
+	err := watcher.WatchBlocks(ctx, func(_ context.Context, _ *ethclient.Client, hash common.Hash) error {
+		number := hash.Big().Uint64()
+		if number == 2 && !failed {
+			// Fail to handle block 2 once, so that it is backfilled.
+			failed = true
+			return &WatchRetryError{Err: errors.New("failed to obtain block")}
+		}
+		numbers = append(numbers, number)
+		if len(numbers) == 6 {
+			return done
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, done)
+	require.Equal(t, []uint64{1, 2, 3, 4, 5, 6}, numbers)
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], "failed to obtain block")
+}